@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JSONSchema is a draft-07 JSON schema fragment as used by the NEAR ABI.
+type JSONSchema map[string]interface{}
+
+type AbiRoot struct {
+	SchemaVersion string      `json:"schema_version"`
+	Metadata      AbiMetadata `json:"metadata"`
+	Body          AbiBody     `json:"body"`
+}
+
+type AbiMetadata struct {
+	Name    string        `json:"name,omitempty"`
+	Version string        `json:"version,omitempty"`
+	Build   *AbiBuildInfo `json:"build,omitempty"`
+}
+
+type AbiBuildInfo struct {
+	Compiler string `json:"compiler"`
+	Builder  string `json:"builder"`
+}
+
+type AbiBody struct {
+	Functions []AbiFunction `json:"functions"`
+	// Events are the NEP-297 events the contract logs, for indexers.
+	Events     []AbiEvent `json:"events,omitempty"`
+	RootSchema JSONSchema `json:"root_schema"`
+}
+
+type AbiFunction struct {
+	Name      string     `json:"name"`
+	Doc       string     `json:"doc,omitempty"`
+	Kind      string     `json:"kind"`
+	Modifiers []string   `json:"modifiers,omitempty"`
+	Params    *AbiParams `json:"params,omitempty"`
+	Result    *AbiType   `json:"result,omitempty"`
+}
+
+type AbiParams struct {
+	SerializationType string   `json:"serialization_type"`
+	Args              []AbiArg `json:"args"`
+}
+
+type AbiArg struct {
+	Name       string     `json:"name"`
+	TypeSchema JSONSchema `json:"type_schema"`
+}
+
+type AbiType struct {
+	SerializationType string     `json:"serialization_type"`
+	TypeSchema        JSONSchema `json:"type_schema"`
+}
+
+// GenerateABI builds the NEAR ABI document for an already scanned contract.
+func GenerateABI(contract *ContractInfo, name string) ([]byte, error) {
+	gen := &abiSchemaGenerator{
+		structs:     contract.Structs(),
+		definitions: make(map[string]interface{}),
+	}
+
+	functions := []AbiFunction{}
+	for _, m := range contract.Methods {
+		if !m.IsExported() {
+			continue
+		}
+		fn, err := gen.function(m)
+		if err != nil {
+			return nil, err
+		}
+		functions = append(functions, fn)
+	}
+	if roles := contract.Roles(); len(roles) > 0 {
+		functions = append(functions, roleFunctions(roles)...)
+	}
+
+	root := AbiRoot{
+		SchemaVersion: AbiSchemaVersion,
+		Metadata: AbiMetadata{
+			Name: name,
+			Build: &AbiBuildInfo{
+				Compiler: "tinygo",
+				Builder:  "near-go " + NearSdkGoVersion,
+			},
+		},
+		Body: AbiBody{
+			Functions: functions,
+			Events:    gen.events(contract.Events()),
+			RootSchema: JSONSchema{
+				"$schema":     "http://json-schema.org/draft-07/schema#",
+				"title":       "String",
+				"type":        "string",
+				"definitions": gen.definitions,
+			},
+		},
+	}
+
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// abiPathForWasm places the ABI next to the WASM binary. The default output keeps the
+// conventional 'abi.json' name, custom outputs get '<name>.abi.json'.
+func abiPathForWasm(wasmPath string) string {
+	dir, base := filepath.Split(wasmPath)
+	if base == "main.wasm" {
+		return filepath.Join(dir, AbiFileName)
+	}
+	return filepath.Join(dir, strings.TrimSuffix(base, ".wasm")+"."+AbiFileName)
+}
+
+// readModuleName returns the last element of the module path declared in the nearest go.mod.
+func readModuleName(dir string) string {
+	module, _ := readModulePath(dir)
+	return module[strings.LastIndex(module, "/")+1:]
+}
+
+// readModulePath returns the module path declared in the nearest go.mod and the
+// folder holding it, or empty strings when there is none.
+func readModulePath(dir string) (string, string) {
+	for {
+		f, err := os.Open(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			defer f.Close()
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if strings.HasPrefix(line, "module ") {
+					return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), "\""), dir
+				}
+			}
+			return "", ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+type abiSchemaGenerator struct {
+	structs     map[string]*StructInfo
+	definitions map[string]interface{}
+}
+
+func (g *abiSchemaGenerator) function(m *MethodInfo) (AbiFunction, error) {
+	fn := AbiFunction{
+		Name: toSnakeCase(m.Name),
+		Doc:  m.Doc,
+		Kind: "call",
+	}
+	if m.IsView {
+		fn.Kind = "view"
+	}
+	if m.IsInit || m.IsMigrate {
+		fn.Modifiers = append(fn.Modifiers, "init")
+	}
+	if m.IsPayable {
+		fn.Modifiers = append(fn.Modifiers, "payable")
+	}
+	if m.IsPrivate || m.IsMigrate {
+		fn.Modifiers = append(fn.Modifiers, "private")
+	}
+	if m.IsMigrate {
+		// The previous state is read from storage, the method takes no arguments.
+		return fn, nil
+	}
+
+	if m.ArgsSerializer == SerializerBorsh {
+		var args []AbiArg
+		for _, p := range m.Params {
+			if m.IsPromiseCallback && isPromiseResultType(p.Type) {
+				continue
+			}
+			schema, err := borshSchema(p.Type, g.structs)
+			if err != nil {
+				return fn, fmt.Errorf("method '%s' parameter '%s': %w", m.Name, p.Name, err)
+			}
+			args = append(args, AbiArg{Name: p.Name, TypeSchema: schema})
+		}
+		if len(args) > 0 {
+			fn.Params = &AbiParams{SerializationType: SerializerBorsh, Args: args}
+		}
+	} else if args := g.args(m); len(args) > 0 {
+		fn.Params = &AbiParams{SerializationType: SerializerJSON, Args: args}
+	}
+
+	returns := m.Returns
+	if len(returns) > 0 && returns[len(returns)-1] == "error" {
+		returns = returns[:len(returns)-1]
+	}
+	switch {
+	case len(returns) == 0:
+	case m.ResultSerializer == SerializerBorsh:
+		schema, err := borshSchema(returns[0], g.structs)
+		if err != nil {
+			return fn, fmt.Errorf("method '%s' result: %w", m.Name, err)
+		}
+		fn.Result = &AbiType{SerializationType: SerializerBorsh, TypeSchema: schema}
+	default:
+		fn.Result = &AbiType{SerializationType: SerializerJSON, TypeSchema: g.schema(returns[0])}
+	}
+
+	return fn, nil
+}
+
+// args mirrors generateParamParser: a single struct parameter is decoded from the whole
+// input, so its fields become the ABI arguments.
+func (g *abiSchemaGenerator) args(m *MethodInfo) []AbiArg {
+	var params []Param
+	for _, p := range m.Params {
+		if m.IsPromiseCallback && isPromiseResultType(p.Type) {
+			continue
+		}
+		params = append(params, p)
+	}
+
+	if len(params) == 1 && params[0].Struct {
+		if st, ok := g.structs[strings.TrimPrefix(params[0].Type, "*")]; ok {
+			var args []AbiArg
+			props, _ := g.structProperties(st)
+			for _, prop := range props {
+				args = append(args, AbiArg{Name: prop.name, TypeSchema: prop.schema})
+			}
+			return args
+		}
+	}
+
+	var args []AbiArg
+	for _, p := range params {
+		args = append(args, AbiArg{Name: p.Name, TypeSchema: g.schema(p.Type)})
+	}
+	return args
+}
+
+func isPromiseResultType(typeStr string) bool {
+	return typeStr == "promise.PromiseResult" || typeStr == "*promise.PromiseResult" || typeStr == "[]promise.PromiseResult"
+}
+
+func (g *abiSchemaGenerator) schema(typeStr string) JSONSchema {
+	// The generated code passes the NEAR types in their string form, at any depth.
+	if t, ok := lookupNearType(typeStr); ok {
+		return t.schema
+	}
+
+	switch typeStr {
+	case "string":
+		return JSONSchema{"type": "string"}
+	case "bool":
+		return JSONSchema{"type": "boolean"}
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		return JSONSchema{"type": "integer", "format": integerFormat(typeStr)}
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+		return JSONSchema{"type": "integer", "format": integerFormat(typeStr), "minimum": 0}
+	case "float32", "float64":
+		return JSONSchema{"type": "number", "format": "double"}
+	case "[]byte":
+		return JSONSchema{"type": "string", "contentEncoding": "base64"}
+	case "interface{}", "any":
+		return JSONSchema{}
+	}
+
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		return JSONSchema{"anyOf": []JSONSchema{g.schema(typeStr[1:]), {"type": "null"}}}
+	case strings.HasPrefix(typeStr, "[]"):
+		return JSONSchema{"type": "array", "items": g.schema(typeStr[2:])}
+	case isArrayType(typeStr):
+		// encoding/json writes every array as a JSON array, byte arrays included.
+		length, elem := splitArrayType(typeStr)
+		schema := JSONSchema{"type": "array", "items": g.schema(elem)}
+		if n, err := strconv.Atoi(length); err == nil {
+			schema["minItems"], schema["maxItems"] = n, n
+		}
+		return schema
+	case strings.HasPrefix(typeStr, "map["):
+		_, value := splitMapType(typeStr)
+		return JSONSchema{"type": "object", "additionalProperties": g.schema(value)}
+	}
+
+	st, ok := g.structs[typeStr]
+	if !ok {
+		return JSONSchema{}
+	}
+	ref := JSONSchema{"$ref": "#/definitions/" + st.Name}
+	if _, done := g.definitions[st.Name]; done {
+		return ref
+	}
+	// Reserve the slot first so self-referencing structs terminate.
+	g.definitions[st.Name] = JSONSchema{}
+
+	props, required := g.structProperties(st)
+	properties := JSONSchema{}
+	for _, prop := range props {
+		properties[prop.name] = prop.schema
+	}
+	def := JSONSchema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		def["required"] = required
+	}
+	g.definitions[st.Name] = def
+	return ref
+}
+
+type abiProperty struct {
+	name   string
+	schema JSONSchema
+}
+
+// structProperties follows encoding/json rules: unexported fields and `json:"-"` are skipped,
+// embedded structs without a name tag are flattened and omitempty/pointer fields are optional.
+func (g *abiSchemaGenerator) structProperties(st *StructInfo) ([]abiProperty, []string) {
+	var props []abiProperty
+	var required []string
+	for _, field := range st.Fields {
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+		if field.Embedded && name == field.Name {
+			if embedded, ok := g.structs[strings.TrimPrefix(field.Type, "*")]; ok {
+				embeddedProps, embeddedRequired := g.structProperties(embedded)
+				props = append(props, embeddedProps...)
+				required = append(required, embeddedRequired...)
+				continue
+			}
+		}
+		props = append(props, abiProperty{name: name, schema: g.schema(field.Type)})
+		if !omitEmpty && !strings.HasPrefix(field.Type, "*") {
+			required = append(required, name)
+		}
+	}
+	return props, required
+}
+
+func jsonFieldName(field FieldInfo) (name string, omitEmpty bool, skip bool) {
+	if field.Name == "" || !unicode.IsUpper([]rune(field.Name)[0]) {
+		return "", false, true
+	}
+	name = field.Name
+	tag := lookupStructTag(field.Tag, "json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] != "" {
+		name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// lookupStructTag is reflect.StructTag.Get for raw tag strings taken from the AST.
+func lookupStructTag(tag, key string) string {
+	for _, part := range strings.Fields(tag) {
+		if strings.HasPrefix(part, key+":\"") && strings.HasSuffix(part, "\"") {
+			return strings.TrimSuffix(strings.TrimPrefix(part, key+":\""), "\"")
+		}
+	}
+	return ""
+}
+
+// isArrayType reports whether typeStr is a fixed-size array such as '[32]byte'.
+func isArrayType(typeStr string) bool {
+	length, _ := splitArrayType(typeStr)
+	return length != ""
+}
+
+// splitArrayType returns the length and element type of a fixed-size array type.
+func splitArrayType(typeStr string) (string, string) {
+	if !strings.HasPrefix(typeStr, "[") || strings.HasPrefix(typeStr, "[]") {
+		return "", ""
+	}
+	end := strings.Index(typeStr, "]")
+	if end < 0 {
+		return "", ""
+	}
+	return typeStr[1:end], typeStr[end+1:]
+}
+
+func splitMapType(typeStr string) (string, string) {
+	depth := 0
+	for i := len("map["); i < len(typeStr); i++ {
+		switch typeStr[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return typeStr[len("map["):i], typeStr[i+1:]
+			}
+			depth--
+		}
+	}
+	return "", ""
+}
+
+func integerFormat(typeStr string) string {
+	switch typeStr {
+	case "int":
+		return "int64"
+	case "uint", "uintptr":
+		return "uint64"
+	case "rune":
+		return "int32"
+	case "byte":
+		return "uint8"
+	default:
+		return typeStr
+	}
+}
+
+func writeABI(contract *ContractInfo, sourceDir, wasmPath string) (string, error) {
+	abi, err := GenerateABI(contract, readModuleName(sourceDir))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate ABI: %w", err)
+	}
+	abiPath := abiPathForWasm(wasmPath)
+	if err := WriteToFile(abiPath, string(abi)); err != nil {
+		return "", fmt.Errorf("failed to write ABI file '%s': %w", abiPath, err)
+	}
+	return abiPath, nil
+}
+
+// readABI loads an ABI file written by writeABI.
+func readABI(path string) (*AbiRoot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	var abi AbiRoot
+	if err := json.Unmarshal(data, &abi); err != nil {
+		return nil, fmt.Errorf("%s: '%s' is not a valid ABI file: %w", ErrToReadFile, path, err)
+	}
+	return &abi, nil
+}
+
+// Function returns the ABI entry of an exported method, or nil if it is not listed.
+func (a *AbiRoot) Function(name string) *AbiFunction {
+	for i := range a.Body.Functions {
+		if a.Body.Functions[i].Name == name {
+			return &a.Body.Functions[i]
+		}
+	}
+	return nil
+}
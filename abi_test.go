@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func generateTestABI(t *testing.T, content string) AbiRoot {
+	dir := setupTestProject(t, content)
+
+	contract, err := ScanContract(dir)
+	if err != nil {
+		t.Fatalf("ScanContract failed: %v", err)
+	}
+
+	data, err := GenerateABI(contract, "test")
+	if err != nil {
+		t.Fatalf("GenerateABI failed: %v", err)
+	}
+
+	var abi AbiRoot
+	if err := json.Unmarshal(data, &abi); err != nil {
+		t.Fatalf("Generated ABI is not valid JSON: %v", err)
+	}
+	return abi
+}
+
+func findAbiFunction(abi AbiRoot, name string) *AbiFunction {
+	for i := range abi.Body.Functions {
+		if abi.Body.Functions[i].Name == name {
+			return &abi.Body.Functions[i]
+		}
+	}
+	return nil
+}
+
+func TestGenerateABI_KindsAndModifiers(t *testing.T) {
+	contractCode := `
+package main
+
+// @contract:state
+type Contract struct {
+	Count int
+}
+
+// @contract:init
+// @contract:payable min_deposit=1NEAR
+func (c *Contract) InitContract() {}
+
+// GetCount returns the counter.
+//
+// @contract:view
+func (c *Contract) GetCount() int { return c.Count }
+
+// @contract:mutating
+func (c *Contract) Increment() {}
+
+// @contract:private
+func (c *Contract) Hidden() {}
+`
+	abi := generateTestABI(t, contractCode)
+
+	if abi.SchemaVersion != AbiSchemaVersion {
+		t.Errorf("Expected schema version %s, got %s", AbiSchemaVersion, abi.SchemaVersion)
+	}
+
+	init := findAbiFunction(abi, "init_contract")
+	if init == nil {
+		t.Fatalf("init_contract missing from ABI")
+	}
+	if init.Kind != "call" || len(init.Modifiers) != 2 || init.Modifiers[0] != "init" || init.Modifiers[1] != "payable" {
+		t.Errorf("Unexpected init function kind/modifiers: %s %v", init.Kind, init.Modifiers)
+	}
+
+	view := findAbiFunction(abi, "get_count")
+	if view == nil || view.Kind != "view" {
+		t.Fatalf("get_count should be a view function, got %+v", view)
+	}
+	if view.Doc != "GetCount returns the counter." {
+		t.Errorf("Expected doc comment to be carried over, got %q", view.Doc)
+	}
+	if view.Result == nil || view.Result.TypeSchema["type"] != "integer" {
+		t.Errorf("Expected integer result schema, got %+v", view.Result)
+	}
+
+	if fn := findAbiFunction(abi, "increment"); fn == nil || fn.Kind != "call" || fn.Params != nil {
+		t.Errorf("increment should be a call function without params, got %+v", fn)
+	}
+	if fn := findAbiFunction(abi, "hidden"); fn == nil || len(fn.Modifiers) != 1 || fn.Modifiers[0] != "private" {
+		t.Errorf("Private method should be a call with the private modifier, got %+v", fn)
+	}
+}
+
+func TestGenerateABI_NestedStructs(t *testing.T) {
+	contractCode := `
+package main
+
+// @contract:state
+type Contract struct {}
+
+type Meta struct {
+	Tags []string ` + "`json:\"tags,omitempty\"`" + `
+}
+
+type Item struct {
+	Meta
+	Title  string
+	Owner  *Owner ` + "`json:\"owner\"`" + `
+	secret string
+	Skip   int ` + "`json:\"-\"`" + `
+}
+
+type Owner struct {
+	Id   string ` + "`json:\"id\"`" + `
+	Next *Owner ` + "`json:\"next\"`" + `
+}
+
+// @contract:mutating
+func (c *Contract) AddItems(items []Item, counts map[string]uint64) {}
+
+// @contract:mutating
+func (c *Contract) AddItem(item Item) (*Owner, error) { return nil, nil }
+`
+	abi := generateTestABI(t, contractCode)
+
+	addItems := findAbiFunction(abi, "add_items")
+	if addItems == nil || addItems.Params == nil || len(addItems.Params.Args) != 2 {
+		t.Fatalf("Expected add_items with 2 args, got %+v", addItems)
+	}
+	items := addItems.Params.Args[0]
+	if items.Name != "items" || items.TypeSchema["type"] != "array" {
+		t.Errorf("Expected array schema for items, got %+v", items)
+	}
+	counts := addItems.Params.Args[1].TypeSchema["additionalProperties"].(map[string]interface{})
+	if counts["format"] != "uint64" {
+		t.Errorf("Expected uint64 map values, got %+v", counts)
+	}
+
+	defs := abi.Body.RootSchema["definitions"].(map[string]interface{})
+	item, ok := defs["Item"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected Item definition, got %+v", defs)
+	}
+	props := item["properties"].(map[string]interface{})
+	for _, name := range []string{"tags", "Title", "owner"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("Expected property %q in Item definition", name)
+		}
+	}
+	for _, name := range []string{"secret", "Skip", "Meta"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("Property %q must not be in Item definition", name)
+		}
+	}
+	required, _ := json.Marshal(item["required"])
+	if string(required) != `["Title"]` {
+		t.Errorf("Expected only Title to be required, got %s", required)
+	}
+	if _, ok := defs["Owner"]; !ok {
+		t.Errorf("Expected recursive Owner definition")
+	}
+
+	addItem := findAbiFunction(abi, "add_item")
+	if addItem == nil || addItem.Params == nil || len(addItem.Params.Args) != 3 {
+		t.Fatalf("Single struct param should expand to its fields, got %+v", addItem)
+	}
+	if addItem.Result == nil || addItem.Result.TypeSchema["anyOf"] == nil {
+		t.Errorf("Expected nullable result schema for *Owner, got %+v", addItem.Result)
+	}
+}
+
+func TestAbiPathForWasm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{filepath.Join("out", "main.wasm"), filepath.Join("out", "abi.json")},
+		{filepath.Join("out", "token.wasm"), filepath.Join("out", "token.abi.json")},
+	}
+
+	for _, tt := range tests {
+		if result := abiPathForWasm(tt.input); result != tt.expected {
+			t.Errorf("abiPathForWasm(%q) = %q; want %q", tt.input, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// roleExports are the exports generated for the role registry of @contract:roles.
+var roleExports = []string{"grant_role", "revoke_role", "has_role"}
+
+// ownerFieldNames are the state fields @contract:owner_only checks against when
+// the annotation doesn't name one with field=.
+var ownerFieldNames = []string{"Owner", "OwnerId", "OwnerID"}
+
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Roles returns the roles the exported methods require, sorted.
+func (c *ContractInfo) Roles() []string {
+	return contractRoles(c.Methods)
+}
+
+func contractRoles(methods []*MethodInfo) []string {
+	seen := map[string]bool{}
+	var roles []string
+	for _, m := range methods {
+		if !m.IsExported() {
+			continue
+		}
+		for _, role := range m.Roles {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// validateAccessControl checks the access annotations of m against the state
+// and resolves the owner field of @contract:owner_only.
+func validateAccessControl(m *MethodInfo, state *StateInfo) error {
+	if m.IsView && (m.IsPrivate || m.IsOwnerOnly || len(m.Roles) > 0) {
+		return fmt.Errorf("method '%s' cannot combine @contract:view with @contract:private, owner_only or roles: view calls have no predecessor account to check", m.Name)
+	}
+	if (m.IsInit || m.IsMigrate) && (m.IsOwnerOnly || len(m.Roles) > 0) {
+		return fmt.Errorf("method '%s' cannot combine @contract:init or migrate with @contract:owner_only or roles: there is no state to check against yet", m.Name)
+	}
+	for _, role := range m.Roles {
+		if role == "" {
+			return fmt.Errorf("method '%s' has @contract:roles without roles, e.g. '@contract:roles admin,minter'", m.Name)
+		}
+		if !roleNamePattern.MatchString(role) {
+			return fmt.Errorf("method '%s' has invalid role '%s': use letters, digits, '-' and '_'", m.Name, role)
+		}
+	}
+
+	if !m.IsOwnerOnly {
+		return nil
+	}
+	if m.OwnerField != "" {
+		field, ok := stateField(state, m.OwnerField)
+		if !ok {
+			return fmt.Errorf("method '%s' is @contract:owner_only field=%s, but state '%s' has no field '%s'", m.Name, m.OwnerField, state.Name, m.OwnerField)
+		}
+		return checkOwnerField(state, field)
+	}
+	field, ok := ownerField(state)
+	if !ok {
+		return fmt.Errorf("method '%s' is @contract:owner_only, but state '%s' has no %s field; name the owner field with field=", m.Name, state.Name, strings.Join(ownerFieldNames, ", "))
+	}
+	m.OwnerField = field.Name
+	return checkOwnerField(state, field)
+}
+
+func checkOwnerField(state *StateInfo, field FieldInfo) error {
+	if near, ok := lookupNearType(field.Type); field.Type != "string" && (!ok || near.base != "string") {
+		return fmt.Errorf("owner field '%s.%s' must hold an account ID as a string or AccountId, not %s", state.Name, field.Name, field.Type)
+	}
+	return nil
+}
+
+func stateField(state *StateInfo, name string) (FieldInfo, bool) {
+	for _, field := range state.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// validateOwnerFields checks that the @contract:owner_only methods, resolved by
+// validateAccessControl, all check the same field: the contract has one owner,
+// who also grants and revokes roles.
+func validateOwnerFields(methods []*MethodInfo) error {
+	var first *MethodInfo
+	for _, m := range methods {
+		if !m.IsExported() || !m.IsOwnerOnly {
+			continue
+		}
+		if first == nil {
+			first = m
+			continue
+		}
+		if m.OwnerField != first.OwnerField {
+			return sourceError(m.FilePath, m.RelativePath, m.Line,
+				fmt.Errorf("method '%s' is @contract:owner_only on field '%s', but method '%s' on field '%s'; use the same owner field for both", m.Name, m.OwnerField, first.Name, first.OwnerField))
+		}
+	}
+	return nil
+}
+
+// contractOwnerField returns the owner field the @contract:owner_only methods
+// check, or "" when there are none.
+func contractOwnerField(methods []*MethodInfo) string {
+	for _, m := range methods {
+		if m.IsExported() && m.IsOwnerOnly {
+			return m.OwnerField
+		}
+	}
+	return ""
+}
+
+// ownerField returns the owner field of the state, found by its name.
+func ownerField(state *StateInfo) (FieldInfo, bool) {
+	for _, name := range ownerFieldNames {
+		if field, ok := stateField(state, name); ok {
+			return field, true
+		}
+	}
+	return FieldInfo{}, false
+}
+
+// generateAccessCheck returns the guard of an export for @contract:private,
+// owner_only and roles. It runs after the state is loaded.
+func generateAccessCheck(m *MethodInfo, indent string) string {
+	if !m.IsPrivate && !m.IsOwnerOnly && len(m.Roles) == 0 {
+		return ""
+	}
+	exportName := toSnakeCase(m.Name)
+
+	var sb strings.Builder
+	sb.WriteString(indent + "// Access control\n")
+	if m.IsPrivate {
+		sb.WriteString(indent + "if !accessIsContract(accessPredecessor()) {\n")
+		sb.WriteString(fmt.Sprintf("%s\tenv.PanicStr(\"Method '%s' is private: only the contract account can call it\")\n", indent, exportName))
+		sb.WriteString(indent + "}\n")
+	}
+	if m.IsOwnerOnly {
+		sb.WriteString(fmt.Sprintf("%sif accessPredecessor() != string(state.%s) {\n", indent, m.OwnerField))
+		sb.WriteString(fmt.Sprintf("%s\tenv.PanicStr(\"Method '%s' can only be called by the owner\")\n", indent, exportName))
+		sb.WriteString(indent + "}\n")
+	}
+	if len(m.Roles) > 0 {
+		quoted := make([]string, len(m.Roles))
+		for i, role := range m.Roles {
+			quoted[i] = fmt.Sprintf("%q", role)
+		}
+		sb.WriteString(fmt.Sprintf("%sif !accessHasAnyRole(accessPredecessor(), %s) {\n", indent, strings.Join(quoted, ", ")))
+		sb.WriteString(fmt.Sprintf("%s\tenv.PanicStr(\"Method '%s' requires one of the roles: %s\")\n", indent, exportName, strings.Join(m.Roles, ", ")))
+		sb.WriteString(indent + "}\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// generateRoleRegistry returns the role registry exports and helpers. Grants are
+// kept in contract storage next to the state, one key per role and account, so
+// the state struct stays as declared. The contract account, and the owner when
+// the state has an owner field, grant and revoke roles. owner is the field the
+// @contract:owner_only methods check; without them it is found by name.
+func generateRoleRegistry(roles []string, state *StateInfo, owner string) string {
+	if owner == "" {
+		if field, ok := ownerField(state); ok && checkOwnerField(state, field) == nil {
+			owner = field.Name
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// ===== Role Registry =====\n")
+	for _, export := range roleExports[:2] {
+		sb.WriteString(fmt.Sprintf("//go:export %s\n", export))
+		sb.WriteString(fmt.Sprintf("func %s() {\n", export))
+		sb.WriteString("\tcontractBuilder.HandleClientJSONInput(func(input *contractBuilder.ContractInput) error {\n")
+		sb.WriteString(generateNoDepositCheck(export, "\t\t"))
+		sb.WriteString("\t\trole, accountId := accessRoleParams(input.Data)\n")
+		if owner != "" {
+			sb.WriteString(fmt.Sprintf("\t\tif predecessor := accessPredecessor(); !accessIsContract(predecessor) && predecessor != string(getState().%s) {\n", owner))
+		} else {
+			sb.WriteString("\t\tif !accessIsContract(accessPredecessor()) {\n")
+		}
+		sb.WriteString(fmt.Sprintf("\t\t\tenv.PanicStr(\"Method '%s' can only be called by the contract account or the owner\")\n", export))
+		sb.WriteString("\t\t}\n")
+		if export == "grant_role" {
+			sb.WriteString("\t\tif _, err := env.StorageWrite(accessRoleKey(role, accountId), []byte{1}); err != nil {\n")
+			sb.WriteString("\t\t\tenv.PanicStr(\"Failed to write role\")\n")
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t\tenv.LogString(\"Granted role '\" + role + \"' to \" + accountId)\n")
+		} else {
+			sb.WriteString("\t\tif _, err := env.StorageRemove(accessRoleKey(role, accountId)); err != nil {\n")
+			sb.WriteString("\t\t\tenv.PanicStr(\"Failed to remove role\")\n")
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t\tenv.LogString(\"Revoked role '\" + role + \"' from \" + accountId)\n")
+		}
+		sb.WriteString("\t\treturn nil\n")
+		sb.WriteString("\t})\n")
+		sb.WriteString("}\n\n")
+	}
+
+	sb.WriteString("//go:export has_role\n")
+	sb.WriteString("func has_role() {\n")
+	sb.WriteString("\tcontractBuilder.HandleClientJSONInput(func(input *contractBuilder.ContractInput) error {\n")
+	sb.WriteString("\t\trole, accountId := accessRoleParams(input.Data)\n")
+	sb.WriteString("\t\tcontractBuilder.ReturnValue(accessHasRole(role, accountId))\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(`func accessRoleParams(data []byte) (string, string) {
+	var params struct {
+		Role      string ` + "`json:\"role\"`" + `
+		AccountId string ` + "`json:\"account_id\"`" + `
+	}
+	if err := encodingJson.Unmarshal(data, &params); err != nil {
+		env.PanicStr("Failed to parse input parameters")
+	}
+	switch params.Role {
+`)
+	quoted := make([]string, len(roles))
+	for i, role := range roles {
+		quoted[i] = fmt.Sprintf("%q", role)
+	}
+	sb.WriteString(fmt.Sprintf("\tcase %s:\n", strings.Join(quoted, ", ")))
+	sb.WriteString(fmt.Sprintf("\tdefault:\n\t\tenv.PanicStr(\"Unknown role '\" + params.Role + \"', expected one of: %s\")\n\t}\n", strings.Join(roles, ", ")))
+	sb.WriteString(`	return params.Role, nearParseAccountId("account_id", params.AccountId)
+}
+
+func accessRoleKey(role, accountId string) []byte {
+	return []byte("__roles:" + role + ":" + accountId)
+}
+
+func accessHasRole(role, accountId string) bool {
+	has, _ := env.StorageHasKey(accessRoleKey(role, accountId))
+	return has
+}
+
+func accessHasAnyRole(accountId string, roles ...string) bool {
+	for _, role := range roles {
+		if accessHasRole(role, accountId) {
+			return true
+		}
+	}
+	return false
+}
+`)
+	return sb.String()
+}
+
+// generateAccessHelpers returns the account helpers the access checks call.
+func generateAccessHelpers(generated string) string {
+	if !strings.Contains(generated, "accessPredecessor()") {
+		return ""
+	}
+	return `func accessPredecessor() string {
+	predecessor, err := env.GetPredecessorAccountID()
+	if err != nil {
+		env.PanicStr("Failed to read the predecessor account")
+	}
+	return predecessor
+}
+
+func accessIsContract(accountId string) bool {
+	current, err := env.GetCurrentAccountId()
+	if err != nil {
+		env.PanicStr("Failed to read the contract account")
+	}
+	return accountId == current
+}
+`
+}
+
+// roleFunctions describes the role registry exports in the ABI.
+func roleFunctions(roles []string) []AbiFunction {
+	params := &AbiParams{
+		SerializationType: SerializerJSON,
+		Args: []AbiArg{
+			{Name: "role", TypeSchema: JSONSchema{"type": "string", "enum": roles}},
+			{Name: "account_id", TypeSchema: nearTypes["AccountId"].schema},
+		},
+	}
+	return []AbiFunction{
+		{Name: "grant_role", Doc: "Grants a role to an account. Only the contract account or the owner can call it.", Kind: "call", Params: params},
+		{Name: "revoke_role", Doc: "Revokes a role from an account. Only the contract account or the owner can call it.", Kind: "call", Params: params},
+		{
+			Name:   "has_role",
+			Doc:    "Reports whether an account has a role.",
+			Kind:   "view",
+			Params: params,
+			Result: &AbiType{SerializationType: SerializerJSON, TypeSchema: JSONSchema{"type": "boolean"}},
+		},
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const accessContract = `
+package main
+
+type AccountId string
+
+// @contract:state
+type Contract struct {
+	Owner AccountId
+	Admin string
+	Fee   uint64
+}
+
+// @contract:mutating
+// @contract:owner_only
+func (c *Contract) SetFee(fee uint64) {}
+
+// @contract:mutating
+// @contract:owner_only
+func (c *Contract) Pause() {}
+
+// @contract:mutating
+// @contract:roles minter, admin
+func (c *Contract) Mint(amount uint64) {}
+
+// @contract:roles admin
+func (c *Contract) Burn() {}
+
+// @contract:mutating
+// @contract:private
+func (c *Contract) Sweep() {}
+`
+
+func TestGenerateCode_AccessControl(t *testing.T) {
+	dir := setupTestProject(t, accessContract)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	expected := []string{
+		"if accessPredecessor() != string(state.Owner) {",
+		"env.PanicStr(\"Method 'set_fee' can only be called by the owner\")",
+		"if !accessHasAnyRole(accessPredecessor(), \"minter\", \"admin\") {",
+		"env.PanicStr(\"Method 'mint' requires one of the roles: minter, admin\")",
+		"env.PanicStr(\"Method 'sweep' is private: only the contract account can call it\")",
+		"//go:export grant_role",
+		"//go:export revoke_role",
+		"//go:export has_role",
+		"!accessIsContract(predecessor) && predecessor != string(getState().Owner)",
+		"case \"admin\", \"minter\":",
+		"return params.Role, nearParseAccountId(\"account_id\", params.AccountId)",
+		"func nearParseAccountId(arg, s string) string {",
+		"func accessPredecessor() string {",
+		"func accessIsContract(accountId string) bool {",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(generated, exp) {
+			t.Errorf("Expected generated code to contain %q", exp)
+		}
+	}
+
+	// The guard runs before the arguments are parsed.
+	mint := generated[strings.Index(generated, "func mint()"):]
+	if strings.Index(mint, "accessHasAnyRole") > strings.Index(mint, "encodingJson.Unmarshal") {
+		t.Errorf("Role check must come before parsing the arguments")
+	}
+
+	contract, err := ScanContract(dir)
+	if err != nil {
+		t.Fatalf("ScanContract failed: %v", err)
+	}
+	exports := strings.Join(contract.ExportNames(), ",")
+	if exports != "set_fee,pause,mint,burn,sweep,grant_role,revoke_role,has_role" {
+		t.Errorf("Unexpected exports %s", exports)
+	}
+}
+
+func TestGenerateCode_RoleRegistryOwnerField(t *testing.T) {
+	dir := setupTestProject(t, `
+package main
+
+// @contract:state
+type Contract struct {
+	Owner string
+	Admin string
+}
+
+// @contract:mutating
+// @contract:owner_only field=Admin
+func (c *Contract) Pause() {}
+
+// @contract:mutating
+// @contract:roles minter
+func (c *Contract) Mint() {}
+`)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+	// The owner of owner_only grants roles, not the field found by name.
+	if !strings.Contains(generated, "!accessIsContract(predecessor) && predecessor != string(getState().Admin)") {
+		t.Error("Expected grant_role and revoke_role to check the Admin field")
+	}
+	if strings.Contains(generated, "getState().Owner") {
+		t.Error("Expected the role registry not to check the Owner field")
+	}
+}
+
+func TestGenerateABI_RoleRegistry(t *testing.T) {
+	abi := generateTestABI(t, accessContract)
+
+	grant := findAbiFunction(abi, "grant_role")
+	if grant == nil || grant.Kind != "call" || grant.Params == nil || len(grant.Params.Args) != 2 {
+		t.Fatalf("Expected grant_role with 2 args, got %+v", grant)
+	}
+	if roles, ok := grant.Params.Args[0].TypeSchema["enum"].([]interface{}); !ok || len(roles) != 2 || roles[0] != "admin" {
+		t.Errorf("Expected the declared roles as enum, got %v", grant.Params.Args[0].TypeSchema)
+	}
+	if has := findAbiFunction(abi, "has_role"); has == nil || has.Kind != "view" || has.Result == nil {
+		t.Errorf("Expected has_role view with a result, got %+v", has)
+	}
+	if sweep := findAbiFunction(abi, "sweep"); sweep == nil || len(sweep.Modifiers) != 1 || sweep.Modifiers[0] != "private" {
+		t.Errorf("Expected sweep with the private modifier, got %+v", sweep)
+	}
+}
+
+func TestScanContract_AccessControlErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		method   string
+		expected string
+	}{
+		{
+			name:     "private view",
+			method:   "// @contract:view\n// @contract:private\nfunc (c *Contract) Get() int { return 0 }",
+			expected: "cannot combine @contract:view with @contract:private, owner_only or roles",
+		},
+		{
+			name:     "no owner field",
+			state:    "Count int",
+			method:   "// @contract:mutating\n// @contract:owner_only\nfunc (c *Contract) Reset() {}",
+			expected: "state 'Contract' has no Owner, OwnerId, OwnerID field",
+		},
+		{
+			name:     "missing named field",
+			method:   "// @contract:mutating\n// @contract:owner_only field=Boss\nfunc (c *Contract) Reset() {}",
+			expected: "state 'Contract' has no field 'Boss'",
+		},
+		{
+			name:     "owner field type",
+			state:    "OwnerID int",
+			method:   "// @contract:mutating\n// @contract:owner_only\nfunc (c *Contract) Reset() {}",
+			expected: "owner field 'Contract.OwnerID' must hold an account ID as a string or AccountId, not int",
+		},
+		{
+			name:     "conflicting owner fields",
+			state:    "Owner string\n\tAdmin string",
+			method:   "// @contract:mutating\n// @contract:owner_only\nfunc (c *Contract) Reset() {}\n\n// @contract:mutating\n// @contract:owner_only field=Admin\nfunc (c *Contract) Pause() {}",
+			expected: "method 'Pause' is @contract:owner_only on field 'Admin', but method 'Reset' on field 'Owner'",
+		},
+		{
+			name:     "owner on init",
+			method:   "// @contract:init\n// @contract:owner_only\nfunc (c *Contract) Init() {}",
+			expected: "cannot combine @contract:init or migrate with @contract:owner_only or roles",
+		},
+		{
+			name:     "no roles",
+			method:   "// @contract:mutating\n// @contract:roles\nfunc (c *Contract) Mint() {}",
+			expected: "has @contract:roles without roles",
+		},
+		{
+			name:     "invalid role",
+			method:   "// @contract:mutating\n// @contract:roles admin:all\nfunc (c *Contract) Mint() {}",
+			expected: "has invalid role 'admin:all'",
+		},
+		{
+			name:     "export clash",
+			method:   "// @contract:mutating\n// @contract:roles admin\nfunc (c *Contract) Mint() {}\n\n// @contract:view\nfunc (c *Contract) HasRole() bool { return false }",
+			expected: "method 'HasRole' clashes with the 'has_role' export generated for @contract:roles",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := tt.state
+			if state == "" {
+				state = "Owner string"
+			}
+			dir := setupTestProject(t, "package main\n\n// @contract:state\ntype Contract struct {\n\t"+state+"\n}\n\n"+tt.method+"\n")
+			_, err := ScanContract(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
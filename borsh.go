@@ -0,0 +1,448 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// borshGenerator emits Borsh encode/decode code for Go types known at build time.
+// Struct types reached while generating are queued and rendered by structFunctions.
+type borshGenerator struct {
+	structs   map[string]*StructInfo
+	queued    map[string]bool
+	pending   []string
+	seq       int
+	used      bool
+	usesMath  bool
+	usesSort  bool
+	generated strings.Builder
+}
+
+func newBorshGenerator(structs map[string]*StructInfo) *borshGenerator {
+	return &borshGenerator{
+		structs: structs,
+		queued:  make(map[string]bool),
+	}
+}
+
+type borshPrimitive struct {
+	method   string
+	wireType string
+	declared string
+}
+
+var borshPrimitives = map[string]borshPrimitive{
+	"bool":    {"Bool", "bool", "bool"},
+	"uint8":   {"U8", "uint8", "u8"},
+	"byte":    {"U8", "uint8", "u8"},
+	"int8":    {"U8", "uint8", "i8"},
+	"uint16":  {"U16", "uint16", "u16"},
+	"int16":   {"U16", "uint16", "i16"},
+	"uint32":  {"U32", "uint32", "u32"},
+	"int32":   {"U32", "uint32", "i32"},
+	"rune":    {"U32", "uint32", "i32"},
+	"uint64":  {"U64", "uint64", "u64"},
+	"int64":   {"U64", "uint64", "i64"},
+	"uint":    {"U64", "uint64", "u64"},
+	"int":     {"U64", "uint64", "i64"},
+	"uintptr": {"U64", "uint64", "u64"},
+	"float32": {"F32", "float32", "f32"},
+	"float64": {"F64", "float64", "f64"},
+	"string":  {"String", "string", "String"},
+	"[]byte":  {"Bytes", "[]byte", "Vec<u8>"},
+	"[]uint8": {"Bytes", "[]byte", "Vec<u8>"},
+
+	"types.Uint128": {"U128", "types.Uint128", "u128"},
+}
+
+func (g *borshGenerator) nextVar(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s%d", prefix, g.seq)
+}
+
+// encode writes expr of type typeStr into the writer variable w.
+func (g *borshGenerator) encode(typeStr, expr, w, indent string) (string, error) {
+	g.used = true
+
+	if prim, ok := borshPrimitives[typeStr]; ok {
+		if prim.method == "F32" || prim.method == "F64" {
+			g.usesMath = true
+		}
+		return fmt.Sprintf("%s%s.write%s(%s(%s))\n", indent, w, prim.method, prim.wireType, expr), nil
+	}
+
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		inner, err := g.encode(typeStr[1:], "(*"+expr+")", w, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%sif %s == nil {\n%s\t%s.writeU8(0)\n%s} else {\n%s\t%s.writeU8(1)\n%s%s}\n",
+			indent, expr, indent, w, indent, indent, w, inner, indent), nil
+
+	case strings.HasPrefix(typeStr, "[]"):
+		elem := g.nextVar("elem")
+		inner, err := g.encode(typeStr[2:], elem, w, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s%s.writeU32(uint32(len(%s)))\n%sfor _, %s := range %s {\n%s%s}\n",
+			indent, w, expr, indent, elem, expr, inner, indent), nil
+
+	case isArrayType(typeStr):
+		// Fixed-size arrays have no length prefix.
+		_, elemType := splitArrayType(typeStr)
+		elem := g.nextVar("elem")
+		inner, err := g.encode(elemType, elem, w, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%sfor _, %s := range %s {\n%s%s}\n", indent, elem, expr, inner, indent), nil
+
+	case strings.HasPrefix(typeStr, "map["):
+		key, value := splitMapType(typeStr)
+		if err := checkBorshMapKey(key); err != nil {
+			return "", err
+		}
+		g.usesSort = true
+		keys, k := g.nextVar("keys"), g.nextVar("key")
+		keyCode, err := g.encode(key, k, w, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		valueCode, err := g.encode(value, expr+"["+k+"]", w, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s%s := make([]%s, 0, len(%s))\n", indent, keys, key, expr))
+		sb.WriteString(fmt.Sprintf("%sfor %s := range %s {\n%s\t%s = append(%s, %s)\n%s}\n", indent, k, expr, indent, keys, keys, k, indent))
+		sb.WriteString(fmt.Sprintf("%ssort.Slice(%s, func(i, j int) bool { return %s[i] < %s[j] })\n", indent, keys, keys, keys))
+		sb.WriteString(fmt.Sprintf("%s%s.writeU32(uint32(len(%s)))\n", indent, w, keys))
+		sb.WriteString(fmt.Sprintf("%sfor _, %s := range %s {\n%s%s%s}\n", indent, k, keys, keyCode, valueCode, indent))
+		return sb.String(), nil
+	}
+
+	if _, ok := g.structs[typeStr]; ok {
+		g.queue(typeStr)
+		return fmt.Sprintf("%sborshEncode%s(%s, %s)\n", indent, borshFuncSuffix(typeStr), w, expr), nil
+	}
+
+	return "", fmt.Errorf("type '%s' is not supported by the borsh serializer", typeStr)
+}
+
+// decode reads a value of type typeStr from the reader variable r into the addressable target.
+func (g *borshGenerator) decode(typeStr, target, r, indent string) (string, error) {
+	g.used = true
+
+	if prim, ok := borshPrimitives[typeStr]; ok {
+		if prim.method == "F32" || prim.method == "F64" {
+			g.usesMath = true
+		}
+		return fmt.Sprintf("%s%s = %s(%s.read%s())\n", indent, target, typeStr, r, prim.method), nil
+	}
+
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		inner, err := g.decode(typeStr[1:], "(*"+target+")", r, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%sif %s.readOption() {\n%s\t%s = new(%s)\n%s%s}\n",
+			indent, r, indent, target, typeStr[1:], inner, indent), nil
+
+	case strings.HasPrefix(typeStr, "[]"):
+		i := g.nextVar("i")
+		inner, err := g.decode(typeStr[2:], target+"["+i+"]", r, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s%s = make(%s, %s.readU32())\n%sfor %s := range %s {\n%s%s}\n",
+			indent, target, typeStr, r, indent, i, target, inner, indent), nil
+
+	case isArrayType(typeStr):
+		_, elemType := splitArrayType(typeStr)
+		i := g.nextVar("i")
+		inner, err := g.decode(elemType, target+"["+i+"]", r, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%sfor %s := range %s {\n%s%s}\n", indent, i, target, inner, indent), nil
+
+	case strings.HasPrefix(typeStr, "map["):
+		key, value := splitMapType(typeStr)
+		if err := checkBorshMapKey(key); err != nil {
+			return "", err
+		}
+		n, i, k, v := g.nextVar("n"), g.nextVar("i"), g.nextVar("key"), g.nextVar("value")
+		keyCode, err := g.decode(key, k, r, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		valueCode, err := g.decode(value, v, r, indent+"\t")
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("%s%s := %s.readU32()\n", indent, n, r))
+		sb.WriteString(fmt.Sprintf("%s%s = make(%s, %s)\n", indent, target, typeStr, n))
+		sb.WriteString(fmt.Sprintf("%sfor %s := uint32(0); %s < %s; %s++ {\n", indent, i, i, n, i))
+		sb.WriteString(fmt.Sprintf("%s\tvar %s %s\n%s%s\tvar %s %s\n%s", indent, k, key, keyCode, indent, v, value, valueCode))
+		sb.WriteString(fmt.Sprintf("%s\t%s[%s] = %s\n%s}\n", indent, target, k, v, indent))
+		return sb.String(), nil
+	}
+
+	if _, ok := g.structs[typeStr]; ok {
+		g.queue(typeStr)
+		return fmt.Sprintf("%s%s = borshDecode%s(%s)\n", indent, target, borshFuncSuffix(typeStr), r), nil
+	}
+
+	return "", fmt.Errorf("type '%s' is not supported by the borsh serializer", typeStr)
+}
+
+// checkBorshMapKey only allows keys the generated code can sort with '<', since Borsh
+// requires map entries in ascending key order.
+func checkBorshMapKey(key string) error {
+	if prim, ok := borshPrimitives[key]; !ok || prim.method == "Bool" || prim.method == "Bytes" || prim.method == "U128" {
+		return fmt.Errorf("map key type '%s' is not supported by the borsh serializer (keys must be ordered basic types)", key)
+	}
+	return nil
+}
+
+func (g *borshGenerator) queue(name string) {
+	if !g.queued[name] {
+		g.queued[name] = true
+		g.pending = append(g.pending, name)
+	}
+}
+
+// structFunctions renders encode/decode functions for every queued struct. Borsh serializes
+// all fields in declaration order, including unexported ones.
+func (g *borshGenerator) structFunctions() (string, error) {
+	for len(g.pending) > 0 {
+		name := g.pending[0]
+		g.pending = g.pending[1:]
+		st := g.structs[name]
+
+		var enc, dec strings.Builder
+		suffix := borshFuncSuffix(name)
+		enc.WriteString(fmt.Sprintf("func borshEncode%s(w *borshWriter, v %s) {\n", suffix, name))
+		dec.WriteString(fmt.Sprintf("func borshDecode%s(r *borshReader) %s {\n\tvar v %s\n", suffix, name, name))
+		for _, field := range st.Fields {
+			if strings.Contains(name, ".") && !token.IsExported(field.Name) {
+				return "", fmt.Errorf("field %s.%s: unexported fields of imported types can't be serialized with borsh", name, field.Name)
+			}
+			code, err := g.encode(field.Type, "v."+field.Name, "w", "\t")
+			if err != nil {
+				return "", fmt.Errorf("field %s.%s: %w", name, field.Name, err)
+			}
+			enc.WriteString(code)
+			code, err = g.decode(field.Type, "v."+field.Name, "r", "\t")
+			if err != nil {
+				return "", fmt.Errorf("field %s.%s: %w", name, field.Name, err)
+			}
+			dec.WriteString(code)
+		}
+		enc.WriteString("}\n\n")
+		dec.WriteString("\treturn v\n}\n\n")
+
+		g.generated.WriteString(enc.String())
+		g.generated.WriteString(dec.String())
+	}
+	return g.generated.String(), nil
+}
+
+// borshFuncSuffix turns a possibly package-qualified struct name into the suffix of its
+// generated function names.
+func borshFuncSuffix(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+func (g *borshGenerator) helpers() string {
+	var sb strings.Builder
+	sb.WriteString(`type borshWriter struct {
+	buf []byte
+}
+
+func (w *borshWriter) writeU8(v uint8) { w.buf = append(w.buf, v) }
+
+func (w *borshWriter) writeU16(v uint16) { w.buf = append(w.buf, byte(v), byte(v>>8)) }
+
+func (w *borshWriter) writeU32(v uint32) {
+	w.buf = append(w.buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (w *borshWriter) writeU64(v uint64) {
+	w.writeU32(uint32(v))
+	w.writeU32(uint32(v >> 32))
+}
+
+func (w *borshWriter) writeU128(v types.Uint128) {
+	w.writeU64(v.Lo)
+	w.writeU64(v.Hi)
+}
+
+func (w *borshWriter) writeBool(v bool) {
+	if v {
+		w.writeU8(1)
+	} else {
+		w.writeU8(0)
+	}
+}
+
+func (w *borshWriter) writeBytes(v []byte) {
+	w.writeU32(uint32(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *borshWriter) writeString(v string) {
+	w.writeU32(uint32(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+type borshReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *borshReader) take(n int) []byte {
+	if n < 0 || len(r.buf)-r.pos < n {
+		env.PanicStr("Failed to deserialize borsh: unexpected end of input")
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *borshReader) readU8() uint8 { return r.take(1)[0] }
+
+func (r *borshReader) readU16() uint16 {
+	b := r.take(2)
+	return uint16(b[0]) | uint16(b[1])<<8
+}
+
+func (r *borshReader) readU32() uint32 {
+	b := r.take(4)
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func (r *borshReader) readU64() uint64 {
+	lo := r.readU32()
+	hi := r.readU32()
+	return uint64(lo) | uint64(hi)<<32
+}
+
+func (r *borshReader) readU128() types.Uint128 {
+	lo := r.readU64()
+	hi := r.readU64()
+	return types.Uint128{Hi: hi, Lo: lo}
+}
+
+func (r *borshReader) readBool() bool {
+	switch r.readU8() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	env.PanicStr("Failed to deserialize borsh: invalid bool")
+	return false
+}
+
+func (r *borshReader) readOption() bool {
+	switch r.readU8() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	env.PanicStr("Failed to deserialize borsh: invalid option tag")
+	return false
+}
+
+func (r *borshReader) readBytes() []byte {
+	b := r.take(int(r.readU32()))
+	out := make([]byte, len(b))
+	copy(out, b)
+	return out
+}
+
+func (r *borshReader) readString() string { return string(r.take(int(r.readU32()))) }
+
+func (r *borshReader) finish() {
+	if r.pos != len(r.buf) {
+		env.PanicStr("Failed to deserialize borsh: unexpected trailing bytes")
+	}
+}
+`)
+	if g.usesMath {
+		sb.WriteString(`
+func (w *borshWriter) writeF32(v float32) { w.writeU32(math.Float32bits(v)) }
+
+func (w *borshWriter) writeF64(v float64) { w.writeU64(math.Float64bits(v)) }
+
+func (r *borshReader) readF32() float32 { return math.Float32frombits(r.readU32()) }
+
+func (r *borshReader) readF64() float64 { return math.Float64frombits(r.readU64()) }
+`)
+	}
+	return sb.String()
+}
+
+// borshSchema describes typeStr as a borsh-schema container for the ABI.
+func borshSchema(typeStr string, structs map[string]*StructInfo) (JSONSchema, error) {
+	definitions := JSONSchema{}
+	declaration, err := borshDeclaration(typeStr, structs, definitions)
+	if err != nil {
+		return nil, err
+	}
+	return JSONSchema{"declaration": declaration, "definitions": definitions}, nil
+}
+
+func borshDeclaration(typeStr string, structs map[string]*StructInfo, definitions JSONSchema) (string, error) {
+	if prim, ok := borshPrimitives[typeStr]; ok {
+		return prim.declared, nil
+	}
+
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		inner, err := borshDeclaration(typeStr[1:], structs, definitions)
+		return "Option<" + inner + ">", err
+	case strings.HasPrefix(typeStr, "[]"):
+		inner, err := borshDeclaration(typeStr[2:], structs, definitions)
+		return "Vec<" + inner + ">", err
+	case isArrayType(typeStr):
+		length, elem := splitArrayType(typeStr)
+		inner, err := borshDeclaration(elem, structs, definitions)
+		return "[" + inner + "; " + length + "]", err
+	case strings.HasPrefix(typeStr, "map["):
+		key, value := splitMapType(typeStr)
+		k, err := borshDeclaration(key, structs, definitions)
+		if err != nil {
+			return "", err
+		}
+		v, err := borshDeclaration(value, structs, definitions)
+		return "BTreeMap<" + k + ", " + v + ">", err
+	}
+
+	st, ok := structs[typeStr]
+	if !ok {
+		return "", fmt.Errorf("type '%s' is not supported by the borsh serializer", typeStr)
+	}
+	if _, done := definitions[st.Name]; done {
+		return st.Name, nil
+	}
+	definitions[st.Name] = nil
+
+	fields := [][]string{}
+	for _, field := range st.Fields {
+		decl, err := borshDeclaration(field.Type, structs, definitions)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, []string{toSnakeCase(field.Name), decl})
+	}
+	definitions[st.Name] = JSONSchema{"Struct": fields}
+	return st.Name, nil
+}
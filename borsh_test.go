@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateCode_BorshState(t *testing.T) {
+	contractCode := `
+package main
+
+type Entry struct {
+	Key    string
+	Values []uint64
+}
+
+// @contract:state serializer=borsh
+type Contract struct {
+	Owner   string
+	Entries []Entry
+	Index   map[string]*Entry
+}
+
+// @contract:mutating args=borsh
+func (c *Contract) Add(entry Entry) {}
+`
+	dir := setupTestProject(t, contractCode)
+
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	if strings.Contains(generated, "encoding/json") {
+		t.Errorf("encoding/json must not be imported when nothing uses JSON")
+	}
+	if !strings.Contains(generated, "state = borshDecodeContract(reader)") {
+		t.Errorf("Expected borsh decoding of state in getState()")
+	}
+	if !strings.Contains(generated, "borshEncodeContract(writer, *state)") {
+		t.Errorf("Expected borsh encoding of state in setState()")
+	}
+	if !strings.Contains(generated, "func borshEncodeEntry(w *borshWriter, v Entry)") {
+		t.Errorf("Expected encoder for nested struct Entry")
+	}
+	if !strings.Contains(generated, "sort.Slice(") || !strings.Contains(generated, "\"sort\"") {
+		t.Errorf("Expected map keys to be sorted before encoding")
+	}
+	if !strings.Contains(generated, "contractBuilder.HandleClientRawBytesInput") {
+		t.Errorf("Borsh args must be read as raw bytes")
+	}
+	if !strings.Contains(generated, "params.Entry = borshDecodeEntry(argsReader)") {
+		t.Errorf("Expected borsh decoding of the entry parameter")
+	}
+	if !strings.Contains(generated, "state.Add(params.Entry)") {
+		t.Errorf("Borsh params must always be passed as struct fields")
+	}
+}
+
+func TestGenerateCode_BorshResult(t *testing.T) {
+	contractCode := `
+package main
+
+import "github.com/vlmoon99/near-sdk-go/types"
+
+// @contract:state
+type Contract struct {
+	Total types.Uint128
+}
+
+// @contract:view result=borsh
+func (c *Contract) GetTotal() (types.Uint128, error) { return c.Total, nil }
+
+// @contract:view
+func (c *Contract) GetTotalJSON() types.Uint128 { return c.Total }
+`
+	dir := setupTestProject(t, contractCode)
+
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	if !strings.Contains(generated, "resultWriter.writeU128(types.Uint128(result))") {
+		t.Errorf("Expected u128 borsh encoding of the result")
+	}
+	if !strings.Contains(generated, "contractBuilder.ReturnValue(resultWriter.buf)") {
+		t.Errorf("Expected raw borsh bytes to be returned")
+	}
+	if !strings.Contains(generated, "encodingJson.Unmarshal(val, &state)") {
+		t.Errorf("State should stay JSON by default")
+	}
+}
+
+func TestGenerateCode_BorshErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name: "unsupported field type",
+			code: `
+package main
+
+// @contract:state serializer=borsh
+type Contract struct {
+	Handler interface{}
+}
+
+// @contract:view
+func (c *Contract) Get() {}
+`,
+			expected: "type 'interface{}' is not supported by the borsh serializer",
+		},
+		{
+			name: "unknown serializer",
+			code: `
+package main
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating args=xml
+func (c *Contract) Set(v string) {}
+`,
+			expected: "unknown args serializer 'xml'",
+		},
+		{
+			name: "unordered map key",
+			code: `
+package main
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating args=borsh
+func (c *Contract) Set(v map[bool]string) {}
+`,
+			expected: "map key type 'bool' is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupTestProject(t, tt.code)
+			_, err := GenerateCode(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestGenerateABI_BorshFunctions(t *testing.T) {
+	contractCode := `
+package main
+
+type Entry struct {
+	Key string
+}
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating args=borsh result=borsh
+func (c *Contract) Add(entry Entry, tags []string) *Entry { return nil }
+`
+	abi := generateTestABI(t, contractCode)
+
+	fn := findAbiFunction(abi, "add")
+	if fn == nil || fn.Params == nil || fn.Params.SerializationType != SerializerBorsh {
+		t.Fatalf("Expected borsh params for add, got %+v", fn)
+	}
+	if fn.Params.Args[1].TypeSchema["declaration"] != "Vec<String>" {
+		t.Errorf("Unexpected borsh declaration for tags: %+v", fn.Params.Args[1].TypeSchema)
+	}
+	if fn.Result == nil || fn.Result.SerializationType != SerializerBorsh || fn.Result.TypeSchema["declaration"] != "Option<Entry>" {
+		t.Errorf("Unexpected borsh result: %+v", fn.Result)
+	}
+}
+
+func TestGenerateCode_BorshFixedArray(t *testing.T) {
+	contractCode := `
+package main
+
+// @contract:state serializer=borsh
+type Contract struct {
+	Hash [32]byte
+}
+
+// @contract:mutating args=borsh
+func (c *Contract) SetHash(hash [32]byte) {}
+`
+	dir := setupTestProject(t, contractCode)
+
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+	if !strings.Contains(generated, "] = byte(r.readU8())") {
+		t.Errorf("Expected fixed array to be decoded in place")
+	}
+	if strings.Contains(generated, "writeU32(uint32(len(v.Hash)))") {
+		t.Errorf("Fixed arrays must be encoded without a length prefix")
+	}
+
+	abi := generateTestABI(t, contractCode)
+	fn := findAbiFunction(abi, "set_hash")
+	if fn == nil || fn.Params == nil || fn.Params.Args[0].TypeSchema["declaration"] != "[u8; 32]" {
+		t.Errorf("Unexpected borsh declaration for hash: %+v", fn)
+	}
+}
@@ -5,9 +5,29 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/vlmoon99/near-cli-go/sim"
 )
 
-func HandleBuild(sourceDir, outputName string, keepGenerated bool) error {
+// BuildOptions controls the build pipeline.
+type BuildOptions struct {
+	KeepGenerated bool
+	// NoCache always runs TinyGo and leaves the build cache untouched.
+	NoCache bool
+	// Debug keeps the DWARF and name sections, which 'near-go profile' needs to
+	// attribute gas to functions.
+	Debug bool
+	// Reproducible compiles with the bundled TinyGo from a canonical copy of the
+	// module, embeds the NEP-330 source metadata and writes build-info.json.
+	Reproducible bool
+	// Optimize drops unannotated exports and the code only they use, runs wasm-opt
+	// when available (not for reproducible builds) and strips custom sections.
+	Optimize bool
+	// TinyGo holds the compiler settings from near-go.toml.
+	TinyGo TinyGoOptions
+}
+
+func HandleBuild(sourceDir, outputName string, opts BuildOptions) error {
 	absSourceDir, err := filepath.Abs(sourceDir)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute path of source: %w", err)
@@ -27,15 +47,155 @@ func HandleBuild(sourceDir, outputName string, keepGenerated bool) error {
 
 	fmt.Printf("DEBUG: HandleBuild context\n  Source: %s\n  Output: %s\n", absSourceDir, absOutputName)
 
+	if opts.Reproducible {
+		opts.Debug = false
+		if err := ensureBundledTinyGo(); err != nil {
+			return err
+		}
+	}
+
 	fmt.Printf("🔍 Scanning project in: %s\n", absSourceDir)
-	generatedCode, err := GenerateCode(absSourceDir)
+	contract, err := ScanContract(absSourceDir)
 	if err != nil {
 		fmt.Printf("DEBUG: GenerateCode returned error: %v\n", err)
 		return fmt.Errorf("code generation failed: %w", err)
 	}
+	generatedCode, err := generateCode(contract.Methods, []*StateInfo{contract.State}, contract.Files)
+	if err != nil {
+		return fmt.Errorf("code generation failed: %w", err)
+	}
+
+	flags := append([]string{"build", "-size", "short", "-target", "wasm-unknown"}, opts.TinyGo.args()...)
+	if !opts.Debug {
+		flags = append(flags, "-no-debug")
+	}
+
+	var env []string
+	tinyGoVersion := ""
+	if opts.Reproducible {
+		env = reproducibleEnv
+		version, err := ExecuteCommand(GetTinyGoPath(), "version")
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrReproducibleBuild, err)
+		}
+		tinyGoVersion = strings.TrimSpace(string(version))
+
+		if hasExport(contract, SourceMetadataExport) {
+			fmt.Printf("ℹ️  The contract defines '%s' itself, not generating NEP-330 metadata\n", SourceMetadataExport)
+		} else {
+			meta := detectSourceMetadata(absSourceDir, tinyGoVersion, reproducibleBuildCommand(opts))
+			export, err := generateSourceMetadataExport(meta)
+			if err != nil {
+				return fmt.Errorf("code generation failed: %w", err)
+			}
+			generatedCode += export
+		}
+	}
+
+	cacheKey := ""
+	if !opts.NoCache {
+		cacheKey, err = tinyGoCacheKey(absSourceDir, generatedCode, append(flags, env...))
+		if err != nil {
+			fmt.Printf("⚠️ Warning: build cache disabled: %v\n", err)
+		}
+	}
+
+	if cacheKey != "" && restoreCachedBuild(cacheKey, absOutputName) {
+		fmt.Printf("⚡ Sources unchanged, using cached build %s\n", cacheKey[:12])
+		if opts.KeepGenerated {
+			if err := keepGeneratedFile(absSourceDir, generatedCode); err != nil {
+				return err
+			}
+		}
+	} else {
+		if err := compileContract(absSourceDir, absOutputName, generatedCode, flags, env, opts); err != nil {
+			return err
+		}
+		if cacheKey != "" {
+			if err := storeCachedBuild(cacheKey, absOutputName); err != nil {
+				fmt.Printf("⚠️ Warning: Failed to cache the build: %v\n", err)
+			}
+		}
+	}
+
+	if opts.Optimize {
+		if opts.Reproducible {
+			fmt.Println("ℹ️  wasm-opt is not used in reproducible builds, its version is not pinned")
+		}
+		if err := optimizeWasm(absOutputName, generatedExports(generatedCode), !opts.Reproducible); err != nil {
+			return err
+		}
+	}
+
+	abiPath, err := writeABI(contract, absSourceDir, absOutputName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📄 ABI written to: %s\n", abiPath)
+
+	if opts.Reproducible {
+		infoPath, info, err := writeBuildInfo(absSourceDir, absOutputName, tinyGoVersion, flags, opts.Optimize)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🧾 Build info written to: %s\n", infoPath)
+		fmt.Printf("   Code hash: %s\n", info.CodeHash)
+		if info.GitDirty {
+			fmt.Println("⚠️ Warning: the git tree has uncommitted changes, the build cannot be traced to a commit")
+		}
+	}
+
+	fmt.Printf("✅ Build completed successfully: %s\n", outputName)
+	return nil
+}
+
+func tinyGoCacheKey(sourceDir, generatedCode string, flags []string) (string, error) {
+	version, err := ExecuteCommand(GetTinyGoPath(), "version")
+	if err != nil {
+		return "", err
+	}
+	return buildCacheKey(sourceDir, generatedCode, string(version), flags)
+}
+
+// compileContract compiles in place, or for reproducible builds from a canonical
+// copy of the module that is removed afterwards.
+func compileContract(absSourceDir, absOutputName, generatedCode string, flags, env []string, opts BuildOptions) error {
+	if !opts.Reproducible {
+		return compileWasm(absSourceDir, absOutputName, generatedCode, flags, env, opts.KeepGenerated)
+	}
 
-	tmpFileName := "generated_build.go"
-	tmpFilePath := filepath.Join(absSourceDir, tmpFileName)
+	hash, err := sourceHash(absSourceDir)
+	if err != nil {
+		return err
+	}
+	buildDir, cleanup, err := prepareReproducibleDir(absSourceDir, hash)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	fmt.Printf("📦 Building from canonical copy: %s\n", buildDir)
+	if err := compileWasm(buildDir, absOutputName, generatedCode, flags, env, false); err != nil {
+		return err
+	}
+	if opts.KeepGenerated {
+		return keepGeneratedFile(absSourceDir, generatedCode)
+	}
+	return nil
+}
+
+func keepGeneratedFile(absSourceDir, generatedCode string) error {
+	tmpFilePath := filepath.Join(absSourceDir, GeneratedBuildFileName)
+	if err := WriteToFile(tmpFilePath, generatedCode); err != nil {
+		return fmt.Errorf("failed to write generated file '%s': %w", tmpFilePath, err)
+	}
+	fmt.Printf("💾 Kept generated file: %s\n", tmpFilePath)
+	return nil
+}
+
+// compileWasm writes the generated glue next to the sources and runs TinyGo on it.
+func compileWasm(absSourceDir, absOutputName, generatedCode string, flags, env []string, keepGenerated bool) error {
+	tmpFilePath := filepath.Join(absSourceDir, GeneratedBuildFileName)
 
 	fmt.Println("📝 Writing intermediate build file...")
 	if err := WriteToFile(tmpFilePath, generatedCode); err != nil {
@@ -53,16 +213,14 @@ func HandleBuild(sourceDir, outputName string, keepGenerated bool) error {
 		}
 	}()
 
-	args := []string{
-		"build", "-size", "short", "-no-debug",
-		"-o", absOutputName,
-		"-target", "wasm-unknown",
-		tmpFileName,
-	}
+	// The whole package is built, so the contract files and the packages they import
+	// are compiled as written, next to the generated glue.
+	args := append(append([]string{}, flags...), "-o", absOutputName, ".")
 
-	fmt.Printf("🔨 Compiling to %s...\n", outputName)
+	fmt.Printf("🔨 Compiling to %s...\n", filepath.Base(absOutputName))
 
-	if err := ExecuteWithRetry(GetTinyGoPath(), args, absSourceDir, 2, os.Getenv("DEBUG") != ""); err != nil {
+	mapOutput := func(output string) string { return mapGeneratedDiagnostics(output, generatedCode, keepGenerated) }
+	if err := executeWithRetryEnv(GetTinyGoPath(), args, absSourceDir, env, 2, os.Getenv("DEBUG") != "", mapOutput); err != nil {
 		fmt.Printf("DEBUG: TinyGo compilation failed: %v\n", err)
 		return err
 	}
@@ -70,22 +228,76 @@ func HandleBuild(sourceDir, outputName string, keepGenerated bool) error {
 	if _, err := os.Stat(absOutputName); os.IsNotExist(err) {
 		return fmt.Errorf("%s: output file '%s' not found after build", ErrWasmNotFound, absOutputName)
 	}
-
-	fmt.Printf("✅ Build completed successfully: %s\n", outputName)
 	return nil
 }
 
-func HandleTests(testType string) error {
+// HandleTests runs the TinyGo unit tests, or builds the contract and runs the
+// simulation tests, with the settings of the project config.
+func HandleTests(testType string, config *ProjectConfig) error {
+	if testType == "sim" {
+		return handleSimTests(config)
+	}
+
 	target := "./..."
 	if testType == "package" {
 		target = "./"
 	} else if testType != "project" {
-		return fmt.Errorf("invalid test type provided: '%s'. Use 'project' or 'package'.", testType)
+		return fmt.Errorf("invalid test type provided: '%s'. Use 'project', 'package' or 'sim'.", testType)
+	}
+
+	// Emit methods of @contract:event structs are generated; TinyGo compiles the
+	// unit tests without the build glue, so they get a test file of their own.
+	source := "."
+	if config.Build.Source != "" {
+		source = config.Build.Source
+	}
+	eventsFile, err := writeEventsTestFile(source)
+	if err != nil {
+		return err
+	}
+	if eventsFile != "" {
+		defer os.Remove(eventsFile)
 	}
 
 	fmt.Printf("🧪 Running %s tests...\n", testType)
 
-	if err := ExecuteWithRetry(GetTinyGoPath(), append([]string{"test"}, target), "", 2, true); err != nil {
+	args := append(append([]string{"test"}, config.Build.TinyGoOptions.args()...), target)
+	if err := ExecuteWithRetry(GetTinyGoPath(), args, "", 2, true); err != nil {
+		return fmt.Errorf("tests failed: %w", err)
+	}
+
+	fmt.Println("✅ Tests passed!")
+	return nil
+}
+
+// handleSimTests builds the contract and runs the simulation tests in ./sim with the
+// regular Go toolchain, since they execute main.wasm through the sim package.
+func handleSimTests(config *ProjectConfig) error {
+	if _, err := os.Stat(SimTestsDir); os.IsNotExist(err) {
+		return fmt.Errorf("%s: '%s' folder not found", ErrSimTestsNotFound, SimTestsDir)
+	}
+
+	source, output := ".", "main.wasm"
+	if config.Build.Source != "" {
+		source = config.Build.Source
+	}
+	if config.Build.Output != "" {
+		output = config.Build.Output
+	}
+	if err := HandleBuild(source, output, config.BuildOptions()); err != nil {
+		return err
+	}
+	wasmPath, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path of output: %w", err)
+	}
+	if err := os.Setenv(sim.WasmEnv, wasmPath); err != nil {
+		return err
+	}
+
+	fmt.Println("🧪 Running simulation tests...")
+
+	if err := ExecuteWithRetry("go", []string{"test", "-tags", SimBuildTag, "./" + SimTestsDir + "/..."}, "", 1, true); err != nil {
 		return fmt.Errorf("tests failed: %w", err)
 	}
 
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func buildCacheDir() string {
+	return filepath.Join(getToolHome(), BuildCacheDirName)
+}
+
+func cachedBuildPath(key string) string {
+	return filepath.Join(buildCacheDir(), key+".wasm")
+}
+
+// inputHash hashes labelled build inputs, so that moving bytes between inputs
+// changes the digest.
+type inputHash struct {
+	hash.Hash
+}
+
+func newInputHash() *inputHash {
+	return &inputHash{sha256.New()}
+}
+
+func (h *inputHash) add(label string, data []byte) {
+	fmt.Fprintf(h, "%s %d\n", label, len(data))
+	h.Write(data)
+}
+
+// addSources hashes every Go file of the module holding sourceDir, so that
+// helpers without annotations and packages shared by several contracts count
+// too, the files they embed, and go.mod/go.sum. Local 'replace' targets of
+// go.mod, e.g. a package shared with other modules of a monorepo, are compiled
+// in as well and hashed the same way.
+func (h *inputHash) addSources(sourceDir string) error {
+	moduleDir := findModuleDir(sourceDir)
+	if err := h.addModule("", moduleDir); err != nil {
+		return err
+	}
+	replaces, err := localReplaces(moduleDir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	for _, target := range replaces {
+		dir := target
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(moduleDir, dir)
+		}
+		if err := h.addModule("replace "+target+" ", dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addModule hashes the Go files of the module in moduleDir, the files they
+// embed and its go.mod/go.sum, with labels starting with prefix.
+func (h *inputHash) addModule(prefix, moduleDir string) error {
+	files, err := moduleGoFiles(moduleDir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	embedded := map[string]bool{}
+	for _, rel := range files {
+		data, err := os.ReadFile(filepath.Join(moduleDir, rel))
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrToReadFile, err)
+		}
+		h.add(prefix+"file "+filepath.ToSlash(rel), data)
+
+		embeds, err := embeddedFiles(moduleDir, rel, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrToReadFile, err)
+		}
+		for _, embed := range embeds {
+			embedded[embed] = true
+		}
+	}
+
+	embeds := make([]string, 0, len(embedded))
+	for embed := range embedded {
+		embeds = append(embeds, embed)
+	}
+	sort.Strings(embeds)
+	for _, rel := range embeds {
+		data, err := os.ReadFile(filepath.Join(moduleDir, rel))
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrToReadFile, err)
+		}
+		h.add(prefix+"embed "+filepath.ToSlash(rel), data)
+	}
+
+	for _, name := range []string{"go.mod", "go.sum"} {
+		data, err := os.ReadFile(filepath.Join(moduleDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("%s: %w", ErrToReadFile, err)
+		}
+		h.add(prefix+name, data)
+	}
+	return nil
+}
+
+func (h *inputHash) hex() string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addContractDir hashes the folder of the contract relative to its module, since
+// the module sources are the same for every contract of the module.
+func (h *inputHash) addContractDir(sourceDir string) error {
+	absSourceDir, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	rel, err := filepath.Rel(findModuleDir(absSourceDir), absSourceDir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	h.add("contract", []byte(filepath.ToSlash(rel)))
+	return nil
+}
+
+// buildCacheKey hashes everything that determines the compiled WASM: the scanned
+// sources, which contract of the module is built, the generated glue code,
+// go.mod/go.sum of the module, the toolchain versions and the build flags.
+func buildCacheKey(sourceDir, generatedCode, toolchain string, flags []string) (string, error) {
+	h := newInputHash()
+	if err := h.addSources(sourceDir); err != nil {
+		return "", err
+	}
+	if err := h.addContractDir(sourceDir); err != nil {
+		return "", err
+	}
+	h.add("generated", []byte(generatedCode))
+	h.add("toolchain", []byte(toolchain))
+	h.add("flags", []byte(strings.Join(flags, "\x00")))
+	return h.hex(), nil
+}
+
+// sourceHash identifies the contract sources, and which contract of the module
+// they build, independently of how they are built.
+func sourceHash(sourceDir string) (string, error) {
+	h := newInputHash()
+	if err := h.addSources(sourceDir); err != nil {
+		return "", err
+	}
+	if err := h.addContractDir(sourceDir); err != nil {
+		return "", err
+	}
+	return h.hex(), nil
+}
+
+// moduleGoFiles lists the non-test Go files under moduleDir, sorted, skipping
+// the folders ScanContract skips except vendor, and generated build files.
+func moduleGoFiles(moduleDir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(moduleDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != moduleDir && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || strings.HasPrefix(name, "generated_") {
+			return nil
+		}
+		rel, err := filepath.Rel(moduleDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// localReplaces returns the targets of the 'replace' directives in the go.mod
+// of moduleDir that are folders rather than module versions, as written.
+func localReplaces(moduleDir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(moduleDir, "go.mod"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case line == "replace (":
+			inBlock = true
+			continue
+		case strings.HasPrefix(line, "replace "):
+			line = strings.TrimPrefix(line, "replace ")
+		case !inBlock:
+			continue
+		}
+
+		_, target, ok := strings.Cut(line, "=>")
+		fields := strings.Fields(target)
+		if !ok || len(fields) == 0 {
+			continue
+		}
+		path := strings.Trim(fields[0], "\"`")
+		if strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || filepath.IsAbs(path) {
+			targets = append(targets, path)
+		}
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// embeddedFiles lists the files the //go:embed directives of the Go file rel
+// pull in, relative to moduleDir. A pattern naming a folder embeds its files.
+func embeddedFiles(moduleDir, rel string, data []byte) ([]string, error) {
+	var files []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "//go:embed ") {
+			continue
+		}
+		for _, pattern := range embedPatterns(strings.TrimPrefix(line, "//go:embed ")) {
+			pattern = filepath.Join(moduleDir, filepath.Dir(rel), filepath.FromSlash(strings.TrimPrefix(pattern, "all:")))
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				err := filepath.WalkDir(match, func(path string, d os.DirEntry, err error) error {
+					if err != nil || !d.Type().IsRegular() {
+						return err
+					}
+					file, err := filepath.Rel(moduleDir, path)
+					if err == nil {
+						files = append(files, file)
+					}
+					return err
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// embedPatterns splits the patterns of a //go:embed line, which may be quoted.
+func embedPatterns(line string) []string {
+	var patterns []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if line[0] == '"' || line[0] == '`' {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return patterns
+			}
+			pattern, _ := strconv.Unquote(quoted)
+			patterns = append(patterns, pattern)
+			line = line[len(quoted):]
+			continue
+		}
+		pattern, rest, _ := strings.Cut(line, " ")
+		patterns = append(patterns, pattern)
+		line = rest
+	}
+	return patterns
+}
+
+// findModuleDir returns the nearest directory containing go.mod, or dir itself.
+func findModuleDir(dir string) string {
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+			return current
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return dir
+		}
+		current = parent
+	}
+}
+
+// restoreCachedBuild copies the cached WASM for key to outputPath. It reports
+// false when there is no usable entry.
+func restoreCachedBuild(key, outputPath string) bool {
+	data, err := os.ReadFile(cachedBuildPath(key))
+	if err != nil {
+		return false
+	}
+	return os.WriteFile(outputPath, data, 0644) == nil
+}
+
+// storeCachedBuild adds the WASM at wasmPath to the cache. The entry is renamed
+// into place so concurrent builds never read a partial file.
+func storeCachedBuild(key, wasmPath string) error {
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(buildCacheDir(), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(buildCacheDir(), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachedBuildPath(key))
+}
+
+// HandleCacheClean removes every cached build.
+func HandleCacheClean() error {
+	dir := buildCacheDir()
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		fmt.Println("✅ Build cache is already empty")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrCacheClean, err)
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("%s: %w", ErrCacheClean, err)
+	}
+	fmt.Printf("🧹 Removed %d cached build(s), %.1f KB from %s\n", len(entries), float64(size)/1024, dir)
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildCacheKey(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeTemplate(ProjectTemplates[SmartContractTypeProject], dir); err != nil {
+		t.Fatalf("writeTemplate() failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	flags := []string{"build", "-no-debug"}
+
+	key := func() string {
+		t.Helper()
+		key, err := buildCacheKey(dir, "generated", "tinygo 0.37.0", flags)
+		if err != nil {
+			t.Fatalf("buildCacheKey() failed: %v", err)
+		}
+		return key
+	}
+
+	base := key()
+	if key() != base {
+		t.Fatal("buildCacheKey() is not stable for unchanged inputs")
+	}
+
+	changes := map[string]func() (string, error){
+		"toolchain": func() (string, error) {
+			return buildCacheKey(dir, "generated", "tinygo 0.38.0", flags)
+		},
+		"flags": func() (string, error) {
+			return buildCacheKey(dir, "generated", "tinygo 0.37.0", []string{"build"})
+		},
+		"generated code": func() (string, error) {
+			return buildCacheKey(dir, "generated v2", "tinygo 0.37.0", flags)
+		},
+	}
+	for name, change := range changes {
+		if changed, err := change(); err != nil || changed == base {
+			t.Errorf("Changing the %s did not change the key (%v)", name, err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), []byte("example.com/dep v1.0.0 h1:x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	withSum := key()
+	if withSum == base {
+		t.Error("Adding go.sum did not change the key")
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), append(source, "\n// edited\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	edited := key()
+	if edited == withSum {
+		t.Error("Editing a source file did not change the key")
+	}
+
+	// Packages shared with other contracts of the module count, test files do not.
+	shared := filepath.Join(dir, "internal", "math")
+	if err := os.MkdirAll(shared, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shared, "math_test.go"), []byte("package math\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if key() != edited {
+		t.Error("Adding a test file changed the key")
+	}
+	if err := os.WriteFile(filepath.Join(shared, "math.go"), []byte("package math\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if key() == edited {
+		t.Error("Adding a shared package did not change the key")
+	}
+}
+
+func TestBuildCacheKey_ContractsOfOneModule(t *testing.T) {
+	module := t.TempDir()
+	if err := os.WriteFile(filepath.Join(module, "go.mod"), []byte("module example.com/c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Same signatures, so the same glue, but different bodies.
+	for name, greeting := range map[string]string{"a": "hello from a", "b": "hello from b"} {
+		dir := filepath.Join(module, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		source := "package main\n\n// @contract:state\ntype Contract struct{}\n\n// @contract:view\nfunc (c *Contract) Hello() string { return \"" + greeting + "\" }\n"
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := map[string]string{}
+	for _, name := range []string{"a", "b"} {
+		dir := filepath.Join(module, name)
+		generated, err := GenerateCode(dir)
+		if err != nil {
+			t.Fatalf("GenerateCode(%s) failed: %v", name, err)
+		}
+		if keys[name], err = buildCacheKey(dir, generated, "tinygo 0.37.0", []string{"build"}); err != nil {
+			t.Fatalf("buildCacheKey(%s) failed: %v", name, err)
+		}
+	}
+	if keys["a"] == keys["b"] {
+		t.Error("Two contracts of one module got the same key")
+	}
+}
+
+func TestBuildCacheKey_ReplacedModulesAndEmbeds(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"contract/go.mod":         "module example.com/c\n\nrequire example.com/shared v0.0.0\n\nreplace (\n\texample.com/shared => ../shared // local copy\n\texample.com/remote => example.com/fork v1.0.0\n)\n",
+		"contract/main.go":        "package main\n\nimport _ \"embed\"\n\n//go:embed data/names.txt \"data/logo.svg\"\nvar names string\n",
+		"contract/data/names.txt": "alice\n",
+		"contract/data/logo.svg":  "<svg/>",
+		"shared/go.mod":           "module example.com/shared\n",
+		"shared/math.go":          "package shared\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join(root, "contract")
+	if replaces, err := localReplaces(dir); err != nil || len(replaces) != 1 || replaces[0] != "../shared" {
+		t.Fatalf("localReplaces() = %v, %v", replaces, err)
+	}
+
+	key := func() string {
+		t.Helper()
+		key, err := buildCacheKey(dir, "generated", "tinygo 0.37.0", []string{"build"})
+		if err != nil {
+			t.Fatalf("buildCacheKey() failed: %v", err)
+		}
+		return key
+	}
+	base := key()
+	edits := []string{"shared/math.go", "contract/data/names.txt", "contract/data/logo.svg"}
+	for _, name := range edits {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte("edited"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		edited := key()
+		if edited == base {
+			t.Errorf("Editing %s did not change the key", name)
+		}
+		base = edited
+	}
+}
+
+func TestBuildCacheStoreAndClean(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	wasmPath := filepath.Join(dir, "main.wasm")
+	if err := os.WriteFile(wasmPath, noopWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputPath := filepath.Join(dir, "restored.wasm")
+	if restoreCachedBuild("abc", outputPath) {
+		t.Fatal("restoreCachedBuild() hit an empty cache")
+	}
+	if err := storeCachedBuild("abc", wasmPath); err != nil {
+		t.Fatalf("storeCachedBuild() failed: %v", err)
+	}
+	if !restoreCachedBuild("abc", outputPath) {
+		t.Fatal("restoreCachedBuild() missed a stored build")
+	}
+	if data, err := os.ReadFile(outputPath); err != nil || string(data) != string(noopWasm) {
+		t.Errorf("Restored build differs: %v", err)
+	}
+
+	if err := HandleCacheClean(); err != nil {
+		t.Fatalf("HandleCacheClean() failed: %v", err)
+	}
+	if restoreCachedBuild("abc", outputPath) {
+		t.Error("restoreCachedBuild() hit after the cache was cleaned")
+	}
+	if err := HandleCacheClean(); err != nil {
+		t.Errorf("HandleCacheClean() on an empty cache failed: %v", err)
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
-	"math/big"
+	"go/types"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 )
@@ -24,82 +27,263 @@ type MethodInfo struct {
 	IsPayable         bool
 	IsInit            bool
 	IsPromiseCallback bool
-	MinDeposit        string
-	FilePath          string
-	RelativePath      string
-	SourceCode        string
+	IsMigrate         bool
+	MigrateFrom       string
+	IsOwnerOnly       bool
+	// OwnerField is the state field holding the owner for @contract:owner_only.
+	OwnerField string
+	// Roles are the roles of @contract:roles, any of which may call the method.
+	Roles []string
+	// OneYocto, MinDeposit, MaxDeposit and ExactDeposit are the deposit options
+	// of @contract:payable, amounts as written.
+	OneYocto         bool
+	MinDeposit       string
+	MaxDeposit       string
+	ExactDeposit     string
+	ArgsSerializer   string
+	ResultSerializer string
+	Doc              string
+	FilePath         string
+	RelativePath     string
+	// Line is the line of the func keyword in FilePath.
+	Line       int
+	SourceCode string
 }
 
 type Param struct {
 	Name string
 	Type string
+	// Struct reports whether Type is, or points to, a struct declared in the
+	// module. A single such parameter is decoded from the whole JSON input.
+	Struct bool
 }
 
 type StateInfo struct {
 	Name         string
+	Serializer   string
 	Fields       []FieldInfo
 	FilePath     string
 	RelativePath string
+	Line         int
 	SourceCode   string
 }
 
 type FieldInfo struct {
-	Name string
-	Type string
+	Name     string
+	Type     string
+	Tag      string
+	Embedded bool
+}
+
+type StructInfo struct {
+	Name   string
+	Fields []FieldInfo
 }
 
+// FileContent is a scanned Go file. Files of the contract package have an empty
+// Qualifier; files of imported module packages carry the name the contract
+// uses for that package, which prefixes their struct names.
 type FileContent struct {
 	FilePath     string
 	RelativePath string
-	Declarations []string
+	Package      string
+	Qualifier    string
 	Imports      []string
-	IsStateFile  bool
+	// ImportNames maps the name each import is referred to by to its spec.
+	ImportNames map[string]string
+	Structs     []*StructInfo
+	Events      []*EventInfo
+	IsStateFile bool
+}
+
+// ContractInfo is the validated result of scanning a contract source tree.
+type ContractInfo struct {
+	Methods []*MethodInfo
+	State   *StateInfo
+	Files   []*FileContent
 }
 
 func GenerateCode(rootDir string) (string, error) {
+	contract, err := ScanContract(rootDir)
+	if err != nil {
+		return "", err
+	}
+
+	return generateCode(contract.Methods, []*StateInfo{contract.State}, contract.Files)
+}
+
+// ScanContract parses the contract package in rootDir and the module packages it
+// imports, and validates the @contract annotations.
+func ScanContract(rootDir string) (*ContractInfo, error) {
 	fmt.Printf("DEBUG: CodeGen scanning directory: %s\n", rootDir)
 
-	allMethods, stateStructs, fileContents, err := parseAllFilesRecursive(rootDir)
+	allMethods, stateStructs, fileContents, err := parsePackageDir(rootDir)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	if len(fileContents) == 0 {
+		return nil, fmt.Errorf("no Go files of package main found in %s", rootDir)
+	}
+	imported, err := resolveModuleImports(rootDir, fileContents)
+	if err != nil {
+		return nil, err
+	}
+	fileContents = append(fileContents, imported...)
 
 	if len(stateStructs) == 0 {
-		return "", fmt.Errorf("no struct with @contract:state found")
+		return nil, fmt.Errorf("no struct with @contract:state found")
 	}
 	if len(stateStructs) > 1 {
-		return "", fmt.Errorf("found %d structs with @contract:state, only 1 is allowed", len(stateStructs))
+		extra := stateStructs[1]
+		return nil, sourceError(extra.FilePath, extra.RelativePath, extra.Line,
+			fmt.Errorf("found %d structs with @contract:state, only 1 is allowed", len(stateStructs)))
+	}
+	state := stateStructs[0]
+	if !isValidSerializer(state.Serializer) {
+		return nil, sourceError(state.FilePath, state.RelativePath, state.Line,
+			fmt.Errorf("struct '%s' has unknown serializer '%s' (use '%s' or '%s')", state.Name, state.Serializer, SerializerJSON, SerializerBorsh))
+	}
+
+	if err := validateEvents(contractEvents(fileContents)); err != nil {
+		return nil, err
 	}
 
-	initMethods := 0
+	for _, m := range allMethods {
+		if err := validateMethodCompatibility(m); err != nil {
+			return nil, sourceError(m.FilePath, m.RelativePath, m.Line, err)
+		}
+	}
+
+	for _, m := range allMethods {
+		if err := validateDeposit(m); err != nil {
+			return nil, sourceError(m.FilePath, m.RelativePath, m.Line, err)
+		}
+	}
+
+	for _, m := range allMethods {
+		if err := validateAccessControl(m, state); err != nil {
+			return nil, sourceError(m.FilePath, m.RelativePath, m.Line, err)
+		}
+	}
+	if err := validateOwnerFields(allMethods); err != nil {
+		return nil, err
+	}
+	if len(contractRoles(allMethods)) > 0 {
+		for _, m := range allMethods {
+			for _, export := range roleExports {
+				if m.IsExported() && toSnakeCase(m.Name) == export {
+					return nil, sourceError(m.FilePath, m.RelativePath, m.Line,
+						fmt.Errorf("method '%s' clashes with the '%s' export generated for @contract:roles", m.Name, export))
+				}
+			}
+		}
+	}
+
+	var initMethods []*MethodInfo
 	for _, m := range allMethods {
 		if m.IsInit {
-			initMethods++
+			initMethods = append(initMethods, m)
 		}
 	}
-	if initMethods > 1 {
-		return "", fmt.Errorf("found %d methods with @contract:init, only 1 is allowed", initMethods)
+	if len(initMethods) > 1 {
+		extra := initMethods[1]
+		return nil, sourceError(extra.FilePath, extra.RelativePath, extra.Line,
+			fmt.Errorf("found %d methods with @contract:init, only 1 is allowed", len(initMethods)))
 	}
 
+	var migrateMethods []*MethodInfo
 	for _, m := range allMethods {
-		if err := validateMethodCompatibility(m); err != nil {
-			return "", err
+		if !m.IsMigrate {
+			continue
+		}
+		migrateMethods = append(migrateMethods, m)
+		oldType := strings.TrimPrefix(m.Params[0].Type, "*")
+		if oldType == stateStructs[0].Name {
+			return nil, sourceError(m.FilePath, m.RelativePath, m.Line,
+				fmt.Errorf("method '%s' must take the previous state type, not the current state '%s'", m.Name, oldType))
+		}
+		if m.MigrateFrom == "" {
+			m.MigrateFrom = stateStructs[0].Serializer
 		}
 	}
+	if len(migrateMethods) > 1 {
+		extra := migrateMethods[1]
+		return nil, sourceError(extra.FilePath, extra.RelativePath, extra.Line,
+			fmt.Errorf("found %d methods with @contract:migrate, only 1 is allowed", len(migrateMethods)))
+	}
 
 	if len(allMethods) == 0 {
-		return "", fmt.Errorf("no methods with @contract annotations found")
+		return nil, fmt.Errorf("no methods with @contract annotations found")
+	}
+
+	structs := collectStructs(fileContents)
+	for _, m := range allMethods {
+		for i, p := range m.Params {
+			_, m.Params[i].Struct = structs[strings.TrimPrefix(p.Type, "*")]
+		}
+	}
+
+	contract := &ContractInfo{Methods: allMethods, State: stateStructs[0], Files: fileContents}
+	external, err := checkContractTypes(contract)
+	if err != nil {
+		return nil, err
 	}
+	contract.Files = append(contract.Files, external...)
 
 	fmt.Printf("DEBUG: Found State Struct '%s' and %d Public Methods\n", stateStructs[0].Name, countPublicMethods(allMethods))
 
-	return generateCode(allMethods, stateStructs, fileContents), nil
+	return contract, nil
+}
+
+// scanContractIfPresent scans the contract in sourceDir for commands that also
+// work on a prebuilt WASM. It returns nil when sourceDir holds no contract, and
+// the errors of the contract when there is one.
+func scanContractIfPresent(sourceDir string) (*ContractInfo, error) {
+	_, stateStructs, fileContents, err := parsePackageDir(sourceDir)
+	if err != nil || len(fileContents) == 0 || len(stateStructs) == 0 {
+		return nil, nil
+	}
+	return ScanContract(sourceDir)
+}
+
+// Structs returns every struct type declared in the scanned files, keyed by name.
+func (c *ContractInfo) Structs() map[string]*StructInfo {
+	return collectStructs(c.Files)
+}
+
+func collectStructs(files []*FileContent) map[string]*StructInfo {
+	structs := make(map[string]*StructInfo)
+	for _, content := range files {
+		for _, st := range content.Structs {
+			structs[st.Name] = st
+		}
+	}
+	return structs
+}
+
+// ExportNames returns the WASM export names the generated code defines.
+func (c *ContractInfo) ExportNames() []string {
+	var names []string
+	for _, m := range c.Methods {
+		if m.IsExported() {
+			names = append(names, toSnakeCase(m.Name))
+		}
+	}
+	if len(c.Roles()) > 0 {
+		names = append(names, roleExports...)
+	}
+	return names
+}
+
+// IsExported reports whether the method gets a WASM export in the generated code.
+func (m *MethodInfo) IsExported() bool {
+	return m.IsPublic || m.IsInit
 }
 
 func countPublicMethods(methods []*MethodInfo) int {
 	count := 0
 	for _, m := range methods {
-		if (m.IsPublic || m.IsInit) && !m.IsPrivate {
+		if m.IsExported() {
 			count++
 		}
 	}
@@ -116,47 +300,67 @@ func validateMethodCompatibility(m *MethodInfo) error {
 	if m.IsInit && m.IsView {
 		return fmt.Errorf("method '%s' cannot be both @contract:init and @contract:view", m.Name)
 	}
+	if m.IsMigrate {
+		if m.IsView || m.IsInit || m.IsPayable || m.IsPromiseCallback {
+			return fmt.Errorf("method '%s' with @contract:migrate cannot be combined with view, init, payable or promise_callback", m.Name)
+		}
+		if len(m.Params) != 1 {
+			return fmt.Errorf("method '%s' with @contract:migrate must take exactly one parameter: the previous state", m.Name)
+		}
+		if len(m.Returns) > 1 || (len(m.Returns) == 1 && m.Returns[0] != "error") {
+			return fmt.Errorf("method '%s' with @contract:migrate may only return an error", m.Name)
+		}
+		if m.MigrateFrom != "" && !isValidSerializer(m.MigrateFrom) {
+			return fmt.Errorf("method '%s' has unknown previous state serializer '%s' (use '%s' or '%s')", m.Name, m.MigrateFrom, SerializerJSON, SerializerBorsh)
+		}
+	}
+	results := m.Returns
+	if len(results) > 0 && results[len(results)-1] == "error" {
+		results = results[:len(results)-1]
+	}
+	if len(results) > 1 {
+		return fmt.Errorf("method '%s' returns %d values, but a contract method returns at most one value and an error; return a struct instead", m.Name, len(results))
+	}
+	if !isValidSerializer(m.ArgsSerializer) {
+		return fmt.Errorf("method '%s' has unknown args serializer '%s' (use '%s' or '%s')", m.Name, m.ArgsSerializer, SerializerJSON, SerializerBorsh)
+	}
+	if !isValidSerializer(m.ResultSerializer) {
+		return fmt.Errorf("method '%s' has unknown result serializer '%s' (use '%s' or '%s')", m.Name, m.ResultSerializer, SerializerJSON, SerializerBorsh)
+	}
 	return nil
 }
 
-func parseAllFilesRecursive(rootDir string) ([]*MethodInfo, []*StateInfo, []*FileContent, error) {
+func isValidSerializer(serializer string) bool {
+	return serializer == SerializerJSON || serializer == SerializerBorsh
+}
+
+// parsePackageDir parses the Go files of the contract package in rootDir. Other
+// packages, including those in sub-folders, are compiled by TinyGo from their
+// imports and are only read by resolveModuleImports.
+func parsePackageDir(rootDir string) ([]*MethodInfo, []*StateInfo, []*FileContent, error) {
 	var allMethods []*MethodInfo
 	var stateStructs []*StateInfo
 	var fileContents []*FileContent
 
-	err := filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() {
-			name := d.Name()
-			if strings.HasPrefix(name, ".") && name != "." && name != "./" {
-				return filepath.SkipDir
-			}
-			if name == "vendor" || name == "node_modules" || name == "testdata" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
-			return nil
-		}
-		if strings.HasPrefix(filepath.Base(path), "generated_") {
-			return nil
+	entries, err := os.ReadDir(rootDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") ||
+			strings.HasPrefix(name, "generated_") {
+			continue
 		}
 
-		relPath, _ := filepath.Rel(rootDir, path)
-
-		methods, states, content, err := parseContract(path, relPath)
+		methods, states, content, err := parseContract(filepath.Join(rootDir, name), name)
 		if err != nil {
-			fmt.Printf("DEBUG: Failed to parse %s: %v\n", relPath, err)
-			return nil
+			fmt.Printf("DEBUG: Failed to parse %s: %v\n", name, err)
+			continue
 		}
 
 		if content == nil {
-			return nil
+			continue
 		}
 
 		if len(methods) > 0 {
@@ -169,10 +373,157 @@ func parseAllFilesRecursive(rootDir string) ([]*MethodInfo, []*StateInfo, []*Fil
 		}
 
 		fileContents = append(fileContents, content)
-		return nil
-	})
+	}
 
-	return allMethods, stateStructs, fileContents, err
+	return allMethods, stateStructs, fileContents, nil
+}
+
+// resolveModuleImports parses the packages of the contract's module that the
+// contract files import, so their struct types can be used in method
+// signatures. It returns one FileContent per imported file.
+func resolveModuleImports(rootDir string, files []*FileContent) ([]*FileContent, error) {
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	modulePath, moduleDir := readModulePath(absRootDir)
+	if modulePath == "" {
+		return nil, nil
+	}
+
+	var imported []*FileContent
+	seen := map[string]bool{}
+	for _, content := range files {
+		renamed := map[string]string{}
+		for name, spec := range content.ImportNames {
+			alias, path := parseImportSpec(spec)
+			if !strings.HasPrefix(path, modulePath+"/") {
+				continue
+			}
+			dir := filepath.Join(moduleDir, filepath.FromSlash(strings.TrimPrefix(path, modulePath+"/")))
+			pkgFiles, pkgName, err := parseImportedPackage(dir, moduleDir, alias)
+			if err != nil {
+				return nil, fmt.Errorf("package '%s': %w", path, err)
+			}
+			// The package name, not the last path element, is what the file refers to.
+			if pkgName != name {
+				renamed[name] = pkgName
+			}
+			if !seen[pkgName+" "+path] {
+				seen[pkgName+" "+path] = true
+				imported = append(imported, pkgFiles...)
+			}
+		}
+		for from, to := range renamed {
+			content.ImportNames[to] = content.ImportNames[from]
+			delete(content.ImportNames, from)
+		}
+	}
+	return imported, nil
+}
+
+// parseImportedPackage reads the struct types declared by the package in dir and
+// qualifies them, and the field types referring to them, with the name the
+// contract uses for the package: alias, or the package name when it is empty.
+func parseImportedPackage(dir, moduleDir, alias string) ([]*FileContent, string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	var files []*ast.File
+	var paths []string
+	local := map[string]bool{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, decl := range file.Decls {
+			if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+				for _, spec := range d.Specs {
+					local[spec.(*ast.TypeSpec).Name.Name] = true
+				}
+			}
+		}
+		files = append(files, file)
+		paths = append(paths, path)
+	}
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("no Go files in %s", dir)
+	}
+
+	qualifier := alias
+	if qualifier == "" {
+		qualifier = files[0].Name.Name
+	}
+	var contents []*FileContent
+	for i, file := range files {
+		relPath, _ := filepath.Rel(moduleDir, paths[i])
+		content := &FileContent{
+			FilePath:     paths[i],
+			RelativePath: filepath.ToSlash(relPath),
+			Package:      file.Name.Name,
+			Qualifier:    qualifier,
+		}
+		for _, decl := range file.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok || !typeSpec.Name.IsExported() {
+					continue
+				}
+				info := &StructInfo{Name: qualifier + "." + typeSpec.Name.Name}
+				for _, field := range extractFields(structType) {
+					field.Type = qualifyType(field.Type, qualifier, local)
+					info.Fields = append(info.Fields, field)
+				}
+				content.Structs = append(content.Structs, info)
+			}
+		}
+		contents = append(contents, content)
+	}
+	return contents, qualifier, nil
+}
+
+func qualifyType(typeStr, qualifier string, local map[string]bool) string {
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		return "*" + qualifyType(typeStr[1:], qualifier, local)
+	case strings.HasPrefix(typeStr, "[]"):
+		return "[]" + qualifyType(typeStr[2:], qualifier, local)
+	case strings.HasPrefix(typeStr, "map["):
+		key, value := splitMapType(typeStr)
+		return "map[" + qualifyType(key, qualifier, local) + "]" + qualifyType(value, qualifier, local)
+	case local[typeStr]:
+		return qualifier + "." + typeStr
+	}
+	return typeStr
+}
+
+// parseImportSpec splits an import spec such as 'foo "example.com/bar"' into its path and
+// explicit name, which is empty when the import has none.
+func parseImportSpec(spec string) (name string, path string) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	path, err := strconv.Unquote(fields[len(fields)-1])
+	if err != nil {
+		path = strings.Trim(fields[len(fields)-1], "\"`")
+	}
+	if len(fields) > 1 {
+		name = fields[0]
+	}
+	return name, path
 }
 
 func parseContract(filePath string, relativePath string) ([]*MethodInfo, []*StateInfo, *FileContent, error) {
@@ -196,31 +547,29 @@ func parseContract(filePath string, relativePath string) ([]*MethodInfo, []*Stat
 	content := &FileContent{
 		FilePath:     filePath,
 		RelativePath: relativePath,
-		Declarations: []string{},
+		Package:      file.Name.Name,
 		Imports:      []string{},
+		ImportNames:  map[string]string{},
 	}
 
 	for _, imp := range file.Imports {
 		startPos := fset.Position(imp.Pos()).Offset
 		endPos := fset.Position(imp.End()).Offset
-		content.Imports = append(content.Imports, string(fileContentBytes[startPos:endPos]))
+		spec := string(fileContentBytes[startPos:endPos])
+		content.Imports = append(content.Imports, spec)
+
+		name, path := parseImportSpec(spec)
+		if name == "" {
+			name = path[strings.LastIndex(path, "/")+1:]
+		}
+		if name != "_" && name != "." {
+			content.ImportNames[name] = spec
+		}
 	}
 
 	for _, decl := range file.Decls {
 		switch d := decl.(type) {
 		case *ast.GenDecl:
-			if d.Tok == token.IMPORT {
-				continue
-			}
-
-			startPos := fset.Position(d.Pos()).Offset
-			if d.Doc != nil {
-				startPos = fset.Position(d.Doc.Pos()).Offset
-			}
-
-			endPos := fset.Position(d.End()).Offset
-			declCode := string(fileContentBytes[startPos:endPos])
-
 			if d.Tok == token.TYPE {
 				for _, spec := range d.Specs {
 					typeSpec, ok := spec.(*ast.TypeSpec)
@@ -228,6 +577,25 @@ func parseContract(filePath string, relativePath string) ([]*MethodInfo, []*Stat
 						continue
 					}
 
+					if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+						content.Structs = append(content.Structs, &StructInfo{
+							Name:   typeSpec.Name.Name,
+							Fields: extractFields(structType),
+						})
+					}
+
+					// The doc of a 'type (...)' group is not the doc of its types.
+					eventDoc := typeSpec.Doc
+					if eventDoc == nil && !d.Lparen.IsValid() {
+						eventDoc = d.Doc
+					}
+					if event := extractEventInfo(typeSpec, eventDoc); event != nil {
+						event.FilePath = filePath
+						event.RelativePath = relativePath
+						event.Line = fset.Position(typeSpec.Pos()).Line
+						content.Events = append(content.Events, event)
+					}
+
 					isState := false
 					if d.Doc != nil && hasStateAnnotation(d.Doc) {
 						isState = true
@@ -241,23 +609,18 @@ func parseContract(filePath string, relativePath string) ([]*MethodInfo, []*Stat
 							continue
 						}
 						state := extractStateInfo(typeSpec, structType, fset, fileContentBytes)
+						state.Serializer = stateSerializer(d.Doc)
+						if typeSpec.Doc != nil {
+							state.Serializer = stateSerializer(typeSpec.Doc)
+						}
 						state.FilePath = filePath
 						state.RelativePath = relativePath
 						stateStructs = append(stateStructs, state)
 					}
 				}
 			}
-			content.Declarations = append(content.Declarations, declCode)
 
 		case *ast.FuncDecl:
-			startPos := fset.Position(d.Pos()).Offset
-			if d.Doc != nil {
-				startPos = fset.Position(d.Doc.Pos()).Offset
-			}
-
-			endPos := fset.Position(d.End()).Offset
-			declCode := string(fileContentBytes[startPos:endPos])
-
 			if d.Recv != nil && len(d.Recv.List) > 0 {
 				method := extractMethodWithSource(d, fset, fileContentBytes)
 				method.FilePath = filePath
@@ -267,7 +630,6 @@ func parseContract(filePath string, relativePath string) ([]*MethodInfo, []*Stat
 					methods = append(methods, method)
 				}
 			}
-			content.Declarations = append(content.Declarations, declCode)
 		}
 	}
 
@@ -287,31 +649,81 @@ func hasStateAnnotation(doc *ast.CommentGroup) bool {
 	return false
 }
 
+// stateSerializer reads the optional 'serializer=' option of @contract:state, defaulting to JSON.
+func stateSerializer(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return SerializerJSON
+	}
+	for _, comment := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment.Text), "//"))
+		if !strings.HasPrefix(text, "@contract:state") {
+			continue
+		}
+		for _, part := range strings.Fields(text)[1:] {
+			if strings.HasPrefix(part, "serializer=") {
+				return strings.TrimPrefix(part, "serializer=")
+			}
+		}
+	}
+	return SerializerJSON
+}
+
 func extractStateInfo(typeSpec *ast.TypeSpec, structType *ast.StructType, fset *token.FileSet, fileContent []byte) *StateInfo {
 	state := &StateInfo{Name: typeSpec.Name.Name}
-	if structType.Fields != nil {
-		for _, field := range structType.Fields.List {
-			fieldType := typeToString(field.Type)
-			for _, name := range field.Names {
-				state.Fields = append(state.Fields, FieldInfo{Name: name.Name, Type: fieldType})
-			}
+	for _, field := range extractFields(structType) {
+		if !field.Embedded {
+			state.Fields = append(state.Fields, field)
 		}
 	}
 	startPos := fset.Position(typeSpec.Pos()).Offset
 	endPos := fset.Position(structType.End()).Offset
 	state.SourceCode = string(fileContent[startPos:endPos])
+	state.Line = fset.Position(typeSpec.Pos()).Line
 	return state
 }
 
+func extractFields(structType *ast.StructType) []FieldInfo {
+	var fields []FieldInfo
+	if structType.Fields == nil {
+		return fields
+	}
+	for _, field := range structType.Fields.List {
+		fieldType := typeToString(field.Type)
+		tag := ""
+		if field.Tag != nil {
+			tag = strings.Trim(field.Tag.Value, "`")
+		}
+		if len(field.Names) == 0 {
+			fields = append(fields, FieldInfo{
+				Name:     embeddedFieldName(field.Type),
+				Type:     fieldType,
+				Tag:      tag,
+				Embedded: true,
+			})
+			continue
+		}
+		for _, name := range field.Names {
+			fields = append(fields, FieldInfo{Name: name.Name, Type: fieldType, Tag: tag})
+		}
+	}
+	return fields
+}
+
 func extractMethodWithSource(fn *ast.FuncDecl, fset *token.FileSet, fileContent []byte) *MethodInfo {
-	method := &MethodInfo{Name: fn.Name.Name}
+	method := &MethodInfo{Name: fn.Name.Name, ArgsSerializer: SerializerJSON, ResultSerializer: SerializerJSON}
 	if fn.Recv != nil && len(fn.Recv.List) > 0 {
 		method.ReceiverType = extractReceiverType(fn.Recv.List[0].Type)
 	}
 	if fn.Doc != nil {
+		var docLines []string
 		for _, comment := range fn.Doc.List {
 			parseAnnotation(comment.Text, method)
+			line := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment.Text), "//"))
+			if line != "" && !strings.HasPrefix(line, "@contract:") {
+				docLines = append(docLines, line)
+			}
 		}
+		method.Doc = strings.Join(docLines, "\n")
 	}
 	if fn.Type.Params != nil {
 		for _, field := range fn.Type.Params.List {
@@ -329,9 +741,21 @@ func extractMethodWithSource(fn *ast.FuncDecl, fset *token.FileSet, fileContent
 	startPos := fset.Position(fn.Pos()).Offset
 	endPos := fset.Position(fn.End()).Offset
 	method.SourceCode = string(fileContent[startPos:endPos])
+	method.Line = fset.Position(fn.Pos()).Line
 	return method
 }
 
+func embeddedFieldName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedFieldName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	default:
+		return extractReceiverType(expr)
+	}
+}
+
 func extractReceiverType(expr ast.Expr) string {
 	switch t := expr.(type) {
 	case *ast.Ident:
@@ -361,6 +785,31 @@ func parseAnnotation(text string, method *MethodInfo) {
 		method.IsPublic = true
 	case "private":
 		method.IsPrivate = true
+		method.IsPublic = true
+	case "owner_only":
+		method.IsOwnerOnly = true
+		method.IsPublic = true
+		for _, part := range parts[1:] {
+			if strings.HasPrefix(part, "field=") {
+				method.OwnerField = strings.TrimPrefix(part, "field=")
+			}
+		}
+	case "roles":
+		method.IsPublic = true
+		for _, part := range parts[1:] {
+			if strings.Contains(part, "=") {
+				continue
+			}
+			for _, role := range strings.Split(part, ",") {
+				if role != "" {
+					method.Roles = append(method.Roles, role)
+				}
+			}
+		}
+		if len(method.Roles) == 0 {
+			// Reported by validateAccessControl.
+			method.Roles = []string{""}
+		}
 	case "view":
 		method.IsView = true
 		method.IsPublic = true
@@ -370,48 +819,167 @@ func parseAnnotation(text string, method *MethodInfo) {
 	case "promise_callback":
 		method.IsPromiseCallback = true
 		method.IsPublic = true
+	case "migrate":
+		method.IsMigrate = true
+		method.IsMutating = true
+		method.IsPublic = true
+		for _, part := range parts[1:] {
+			if strings.HasPrefix(part, "from=") {
+				method.MigrateFrom = strings.TrimPrefix(part, "from=")
+			}
+		}
 	case "payable":
 		method.IsPayable = true
 		method.IsPublic = true
 		for _, part := range parts[1:] {
-			if strings.HasPrefix(part, "min_deposit=") {
+			switch {
+			case part == "one_yocto":
+				method.OneYocto = true
+			case strings.HasPrefix(part, "min_deposit="):
 				method.MinDeposit = strings.TrimPrefix(part, "min_deposit=")
+			case strings.HasPrefix(part, "max_deposit="):
+				method.MaxDeposit = strings.TrimPrefix(part, "max_deposit=")
+			case strings.HasPrefix(part, "exact_deposit="):
+				method.ExactDeposit = strings.TrimPrefix(part, "exact_deposit=")
 			}
 		}
 	}
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, "args=") {
+			method.ArgsSerializer = strings.TrimPrefix(part, "args=")
+		} else if strings.HasPrefix(part, "result=") {
+			method.ResultSerializer = strings.TrimPrefix(part, "result=")
+		}
+	}
 }
 
+// typeToString renders a type expression as written, e.g. '[32]byte' or
+// 'collections.UnorderedMap[string, string]'.
 func typeToString(expr ast.Expr) string {
-	switch t := expr.(type) {
-	case *ast.Ident:
-		return t.Name
-	case *ast.StarExpr:
-		return "*" + typeToString(t.X)
-	case *ast.ArrayType:
-		return "[]" + typeToString(t.Elt)
-	case *ast.SelectorExpr:
-		return typeToString(t.X) + "." + t.Sel.Name
-	case *ast.MapType:
-		return "map[" + typeToString(t.Key) + "]" + typeToString(t.Value)
-	case *ast.InterfaceType:
-		return "interface{}"
-	default:
-		return "unknown"
-	}
+	return types.ExprString(expr)
 }
 
-func generateCode(methods []*MethodInfo, stateStructs []*StateInfo, fileContents []*FileContent) string {
+func generateCode(methods []*MethodInfo, stateStructs []*StateInfo, fileContents []*FileContent) (string, error) {
+	structs := collectStructs(fileContents)
+	borsh := newBorshGenerator(structs)
+	wire := newNearWireGenerator(structs)
+
+	var body strings.Builder
+	if len(stateStructs) > 0 {
+		state := stateStructs[0]
+		body.WriteString(fmt.Sprintf("// State: %s (from %s:%d)\n", state.Name, state.RelativePath, state.Line))
+		body.WriteString(generateDefaultInit(state))
+		body.WriteString("\n")
+		getState, setState, err := generateStateAccessors(state, borsh)
+		if err != nil {
+			return "", sourceError(state.FilePath, state.RelativePath, state.Line, err)
+		}
+		body.WriteString(getState)
+		body.WriteString("\n")
+		body.WriteString(setState)
+		body.WriteString("\n")
+	}
+
+	body.WriteString("// ===== Generated Exports =====\n")
+	for _, m := range methods {
+		if !m.IsExported() {
+			continue
+		}
+		var export string
+		var err error
+		if m.IsMigrate {
+			export, err = generateMigrateFunction(m, borsh)
+		} else {
+			export, err = generateExportFunction(m, borsh, wire)
+		}
+		if err != nil {
+			return "", sourceError(m.FilePath, m.RelativePath, m.Line, err)
+		}
+		body.WriteString(export)
+		body.WriteString("\n")
+	}
+
+	if roles := contractRoles(methods); len(roles) > 0 {
+		body.WriteString(generateRoleRegistry(roles, stateStructs[0], contractOwnerField(methods)))
+		body.WriteString("\n")
+	}
+
+	if events := contractEvents(fileContents); len(events) > 0 {
+		body.WriteString("// ===== Events =====\n")
+		body.WriteString(generateEvents(events, wire))
+	}
+
+	wireFunctions, err := wire.structFunctions()
+	if err != nil {
+		return "", err
+	}
+	if wireFunctions != "" {
+		body.WriteString("// ===== NEAR Types in JSON =====\n")
+		body.WriteString(wireFunctions)
+	}
+
+	body.WriteString("// ===== Helper Functions =====\n")
+	body.WriteString(generateValidatePayment())
+	body.WriteString("\n")
+	if helpers := generateAccessHelpers(body.String()); helpers != "" {
+		body.WriteString("\n")
+		body.WriteString(helpers)
+	}
+	if helpers := generateDepositHelpers(body.String()); helpers != "" {
+		body.WriteString("\n")
+		body.WriteString(helpers)
+	}
+	if helpers := generateEventHelpers(body.String()); helpers != "" {
+		body.WriteString("\n")
+		body.WriteString(helpers)
+	}
+	if helpers := generateNearHelpers(body.String()); helpers != "" {
+		body.WriteString("\n// ===== NEAR Types =====\n")
+		body.WriteString(helpers)
+		body.WriteString("\n")
+	}
+
+	if borsh.used {
+		structFunctions, err := borsh.structFunctions()
+		if err != nil {
+			return "", err
+		}
+		body.WriteString("\n// ===== Borsh Serialization =====\n")
+		body.WriteString(borsh.helpers())
+		body.WriteString("\n")
+		body.WriteString(structFunctions)
+	}
+	generated := body.String()
+
 	var sb strings.Builder
 
 	sb.WriteString("// Code generated by NEAR contract generator. DO NOT EDIT.\n")
-	sb.WriteString("// This file uses encoding/json for both state serialization and parameter parsing.\n\n")
+	if borsh.used {
+		sb.WriteString("// This file uses JSON serialization by default and Borsh where requested by annotations.\n\n")
+	} else {
+		sb.WriteString("// This file uses encoding/json for both state serialization and parameter parsing.\n\n")
+	}
 	sb.WriteString("package main\n\n")
 
 	importMap := make(map[string]bool)
 	importMap["contractBuilder \"github.com/vlmoon99/near-sdk-go/contract\""] = true
 	importMap["\"github.com/vlmoon99/near-sdk-go/env\""] = true
 	importMap["\"github.com/vlmoon99/near-sdk-go/types\""] = true
-	importMap["encodingJson \"encoding/json\""] = true
+	if strings.Contains(generated, "encodingJson.") {
+		importMap["encodingJson \"encoding/json\""] = true
+	}
+	if strings.Contains(generated, "strconv.") {
+		importMap["\"strconv\""] = true
+	}
+	if strings.Contains(generated, "base64.") {
+		importMap["\"encoding/base64\""] = true
+	}
+	if borsh.usesMath {
+		importMap["\"math\""] = true
+	}
+	if borsh.usesSort {
+		importMap["\"sort\""] = true
+	}
 
 	hasCallback := false
 	for _, m := range methods {
@@ -424,56 +992,112 @@ func generateCode(methods []*MethodInfo, stateStructs []*StateInfo, fileContents
 		importMap["\"github.com/vlmoon99/near-sdk-go/promise\""] = true
 	}
 
-	for _, content := range fileContents {
-		for _, imp := range content.Imports {
-			cleanImp := strings.TrimSpace(imp)
-			if cleanImp != "" {
-				importMap[cleanImp] = true
-			}
-		}
+	userImports, err := glueImports(generated, methods, fileContents, importMap)
+	if err != nil {
+		return "", err
+	}
+	for _, imp := range userImports {
+		importMap[imp] = true
 	}
 
-	sb.WriteString("import (\n")
+	// Sorted so the generated file, and with it the build cache key and the WASM,
+	// are the same on every run.
+	imports := make([]string, 0, len(importMap))
 	for imp := range importMap {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	sb.WriteString("import (\n")
+	for _, imp := range imports {
 		sb.WriteString("\t" + imp + "\n")
 	}
 	sb.WriteString(")\n\n")
 
-	for _, content := range fileContents {
-		sb.WriteString(fmt.Sprintf("// ===== From: %s =====\n", content.RelativePath))
-		for _, decl := range content.Declarations {
-			sb.WriteString(decl)
-			sb.WriteString("\n\n")
-		}
+	sb.WriteString(generated)
+
+	return sb.String(), nil
+}
+
+var typeQualifierPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// glueImports returns the imports of the contract files that the generated code
+// refers to, such as the packages of parameter and result types. The generated
+// file sits next to the contract files in package main, so every name must
+// mean the same package in all of them.
+func glueImports(generated string, methods []*MethodInfo, fileContents []*FileContent, fixed map[string]bool) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), GeneratedBuildFileName, "package main\n\n"+generated, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %w", err)
 	}
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
 
-	if len(stateStructs) > 0 {
-		state := stateStructs[0]
-		sb.WriteString(generateDefaultInit(state))
-		sb.WriteString("\n")
-		sb.WriteString(generateGetState(state))
-		sb.WriteString("\n")
-		sb.WriteString(generateSetState(state))
-		sb.WriteString("\n")
+	fixedNames := map[string]string{}
+	for spec := range fixed {
+		name, path := parseImportSpec(spec)
+		if name == "" {
+			name = path[strings.LastIndex(path, "/")+1:]
+		}
+		fixedNames[name] = spec
 	}
 
-	sb.WriteString("// ===== Generated Exports =====\n")
+	// Names the method signatures refer to; a clash with the generated code's own imports
+	// can't be resolved by skipping the import.
+	inSignatures := map[string]bool{}
 	for _, m := range methods {
-		if m.IsPrivate {
+		if !m.IsExported() {
 			continue
 		}
-		if !m.IsPublic && !m.IsInit {
-			continue
+		types := append([]string{}, m.Returns...)
+		for _, p := range m.Params {
+			types = append(types, p.Type)
+		}
+		for _, t := range types {
+			for _, match := range typeQualifierPattern.FindAllStringSubmatch(t, -1) {
+				inSignatures[match[1]] = true
+			}
 		}
-		sb.WriteString(generateExportFunction(m))
-		sb.WriteString("\n")
 	}
 
-	sb.WriteString("// ===== Helper Functions =====\n")
-	sb.WriteString(generateValidatePayment())
-	sb.WriteString("\n")
+	chosen := map[string]string{}
+	for _, content := range fileContents {
+		if content.Qualifier != "" {
+			continue
+		}
+		for name, spec := range content.ImportNames {
+			if !used[name] {
+				continue
+			}
+			_, path := parseImportSpec(spec)
+			if fixedSpec, ok := fixedNames[name]; ok {
+				if _, fixedPath := parseImportSpec(fixedSpec); fixedPath != path && inSignatures[name] {
+					return nil, fmt.Errorf("%s: import name '%s' of '%s' is also used by the generated code for '%s'; import it under another name", content.RelativePath, name, path, fixedPath)
+				}
+				continue
+			}
+			if other, ok := chosen[name]; ok {
+				if _, otherPath := parseImportSpec(other); otherPath != path {
+					return nil, fmt.Errorf("%s: import name '%s' refers to '%s' here and to '%s' in another contract file; use one name per package", content.RelativePath, name, path, otherPath)
+				}
+				continue
+			}
+			chosen[name] = spec
+		}
+	}
 
-	return sb.String()
+	imports := make([]string, 0, len(chosen))
+	for _, spec := range chosen {
+		imports = append(imports, strings.TrimSpace(spec))
+	}
+	return imports, nil
 }
 
 func generateDefaultInit(state *StateInfo) string {
@@ -482,6 +1106,42 @@ func generateDefaultInit(state *StateInfo) string {
 }`, state.Name, state.Name)
 }
 
+func generateStateAccessors(state *StateInfo, borsh *borshGenerator) (string, string, error) {
+	if state.Serializer != SerializerBorsh {
+		return generateGetState(state), generateSetState(state), nil
+	}
+
+	decode, err := borsh.decode(state.Name, "state", "reader", "\t")
+	if err != nil {
+		return "", "", fmt.Errorf("state '%s': %w", state.Name, err)
+	}
+	encode, err := borsh.encode(state.Name, "*state", "writer", "\t")
+	if err != nil {
+		return "", "", fmt.Errorf("state '%s': %w", state.Name, err)
+	}
+
+	getState := fmt.Sprintf(`func getState() *%s {
+	val, err := env.StateRead()
+	if err != nil || len(val) == 0 {
+		return defaultInit()
+	}
+	var state %s
+	reader := &borshReader{buf: val}
+%s	reader.finish()
+	return &state
+}`, state.Name, state.Name, decode)
+
+	setState := fmt.Sprintf(`func setState(state *%s) {
+	writer := &borshWriter{}
+%s	err := env.StateWrite(writer.buf)
+	if err != nil {
+		env.PanicStr("Failed to write state")
+	}
+}`, state.Name, encode)
+
+	return getState, setState, nil
+}
+
 func generateGetState(state *StateInfo) string {
 	return fmt.Sprintf(`func getState() *%s {
 	val, err := env.StateRead()
@@ -532,41 +1192,18 @@ func generateValidatePayment() string {
 }`
 }
 
-func parseAmountToYocto(amount string) string {
-	amount = strings.TrimSpace(amount)
-	if amount == "" {
-		return "0"
-	}
-
-	isNear := false
-	if strings.HasSuffix(amount, "NEAR") {
-		isNear = true
-		amount = strings.TrimSuffix(amount, "NEAR")
-	}
-
-	valFloat, _, err := big.ParseFloat(amount, 10, 256, big.ToNearestEven)
-	if err != nil {
-		fmt.Printf("⚠️ Warning: Failed to parse min_deposit '%s', defaulting to 0\n", amount)
-		return "0"
-	}
-
-	if isNear {
-		multiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil))
-		valFloat.Mul(valFloat, multiplier)
-	}
-
-	valInt, _ := valFloat.Int(nil)
-	return valInt.String()
-}
-
-func generateExportFunction(m *MethodInfo) string {
+func generateExportFunction(m *MethodInfo, borsh *borshGenerator, wire *nearWireGenerator) (string, error) {
 	var sb strings.Builder
 
 	exportName := toSnakeCase(m.Name)
-	sb.WriteString(fmt.Sprintf("// Export: %s (from %s)\n", exportName, m.RelativePath))
+	sb.WriteString(fmt.Sprintf("// Export: %s (from %s:%d)\n", exportName, m.RelativePath, m.Line))
 	sb.WriteString(fmt.Sprintf("//go:export %s\n", exportName))
 	sb.WriteString(fmt.Sprintf("func %s() {\n", exportName))
-	sb.WriteString("\tcontractBuilder.HandleClientJSONInput(func(input *contractBuilder.ContractInput) error {\n")
+	if m.ArgsSerializer == SerializerBorsh {
+		sb.WriteString("\tcontractBuilder.HandleClientRawBytesInput(func(input *contractBuilder.ContractInput) error {\n")
+	} else {
+		sb.WriteString("\tcontractBuilder.HandleClientJSONInput(func(input *contractBuilder.ContractInput) error {\n")
+	}
 
 	if m.IsInit {
 		sb.WriteString("\t\t// Initialization: Check if already initialized\n")
@@ -579,12 +1216,13 @@ func generateExportFunction(m *MethodInfo) string {
 		sb.WriteString("\t\tstate := getState()\n\n")
 	}
 
-	if m.IsPayable && m.MinDeposit != "" {
-		yoctoAmount := parseAmountToYocto(m.MinDeposit)
-		sb.WriteString(fmt.Sprintf("\t\tif !validatePayment(\"%s\") {\n", yoctoAmount))
-		sb.WriteString("\t\t\tenv.PanicStr(\"Insufficient payment\")\n")
-		sb.WriteString("\t\t}\n\n")
+	sb.WriteString(generateAccessCheck(m, "\t\t"))
+
+	depositCheck, err := generateDepositCheck(m, "\t\t")
+	if err != nil {
+		return "", err
 	}
+	sb.WriteString(depositCheck)
 
 	isPromiseSlice := false
 	if m.IsPromiseCallback {
@@ -617,7 +1255,15 @@ func generateExportFunction(m *MethodInfo) string {
 		indent = "\t\t\t"
 	}
 
-	sb.WriteString(generateParamParser(paramsToParse, indent))
+	if m.ArgsSerializer == SerializerBorsh {
+		parser, err := generateBorshParamParser(paramsToParse, indent, borsh)
+		if err != nil {
+			return "", fmt.Errorf("method '%s': %w", m.Name, err)
+		}
+		sb.WriteString(parser)
+	} else {
+		sb.WriteString(generateParamParser(paramsToParse, indent, wire))
+	}
 	sb.WriteString("\n")
 
 	returnsError := false
@@ -673,8 +1319,10 @@ func generateExportFunction(m *MethodInfo) string {
 			}
 		}
 
-		if len(paramsToParse) == 1 && !isBasicType(paramsToParse[0].Type) {
+		if paramsAreWholeInput(paramsToParse, m.ArgsSerializer) {
 			sb.WriteString("params")
+		} else if m.ArgsSerializer != SerializerBorsh && wire.needsWire(p.Type) {
+			sb.WriteString(nearParamArg(p))
 		} else {
 			sb.WriteString("params.")
 			sb.WriteString(capitalizeFirst(p.Name))
@@ -693,7 +1341,34 @@ func generateExportFunction(m *MethodInfo) string {
 		sb.WriteString(indent + "setState(state)\n\n")
 	}
 
+	// A result holding NEAR types is returned with them in their string form.
+	nearResult, wireResult := false, false
 	if hasDataResult {
+		_, _, _, nearResult = splitNearType(m.Returns[0])
+		wireResult = !nearResult && wire.needsWire(m.Returns[0])
+	}
+
+	if hasDataResult && m.ResultSerializer == SerializerBorsh {
+		encode, err := borsh.encode(m.Returns[0], "result", "resultWriter", indent)
+		if err != nil {
+			return "", fmt.Errorf("method '%s' result: %w", m.Name, err)
+		}
+		sb.WriteString(indent + "resultWriter := &borshWriter{}\n")
+		sb.WriteString(encode)
+		sb.WriteString(indent + "contractBuilder.ReturnValue(resultWriter.buf)\n")
+	} else if nearResult || wireResult {
+		if nearResult {
+			sb.WriteString(generateNearResult(m.Returns[0], indent))
+		} else {
+			sb.WriteString(fmt.Sprintf("%svar resultValue %s\n", indent, wire.wireType(m.Returns[0])))
+			sb.WriteString(wire.toWire(m.Returns[0], "result", "resultValue", indent))
+		}
+		sb.WriteString(indent + "resultJSON, err := encodingJson.Marshal(resultValue)\n")
+		sb.WriteString(indent + "if err != nil {\n")
+		sb.WriteString(indent + "\tenv.PanicStr(\"Failed to marshal result to JSON\")\n")
+		sb.WriteString(indent + "}\n")
+		sb.WriteString(indent + "contractBuilder.ReturnValue(string(resultJSON))\n")
+	} else if hasDataResult {
 		sb.WriteString(indent + "resultJSON, err := encodingJson.Marshal(result)\n")
 		sb.WriteString(indent + "if err != nil {\n")
 		sb.WriteString(indent + "\tenv.PanicStr(\"Failed to marshal result to JSON\")\n")
@@ -711,10 +1386,109 @@ func generateExportFunction(m *MethodInfo) string {
 	sb.WriteString("\t})\n")
 	sb.WriteString("}\n")
 
-	return sb.String()
+	return sb.String(), nil
 }
 
-func generateParamParser(params []Param, indent string) string {
+// generateMigrateFunction reads the stored state as the previous state type, hands it to the
+// migration method and persists the new state. Only the contract account itself may call it.
+func generateMigrateFunction(m *MethodInfo, borsh *borshGenerator) (string, error) {
+	var sb strings.Builder
+
+	oldParam := m.Params[0]
+	oldType := strings.TrimPrefix(oldParam.Type, "*")
+
+	exportName := toSnakeCase(m.Name)
+	sb.WriteString(fmt.Sprintf("// Export: %s (from %s:%d)\n", exportName, m.RelativePath, m.Line))
+	sb.WriteString(fmt.Sprintf("//go:export %s\n", exportName))
+	sb.WriteString(fmt.Sprintf("func %s() {\n", exportName))
+	sb.WriteString("\tcontractBuilder.HandleClientRawBytesInput(func(input *contractBuilder.ContractInput) error {\n")
+
+	sb.WriteString("\t\t// Migration: only the contract account may rewrite its own state\n")
+	sb.WriteString("\t\tcurrentAccountId, _ := env.GetCurrentAccountId()\n")
+	sb.WriteString("\t\tpredecessorAccountId, _ := env.GetPredecessorAccountID()\n")
+	sb.WriteString("\t\tif currentAccountId != predecessorAccountId {\n")
+	sb.WriteString("\t\t\tenv.PanicStr(\"Migration can only be called by the contract account\")\n")
+	sb.WriteString("\t\t}\n\n")
+	sb.WriteString(generateNoDepositCheck(exportName, "\t\t"))
+
+	sb.WriteString("\t\toldVal, err := env.StateRead()\n")
+	sb.WriteString("\t\tif err != nil || len(oldVal) == 0 {\n")
+	sb.WriteString("\t\t\tenv.PanicStr(\"Contract is not initialized, nothing to migrate\")\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tvar oldState %s\n", oldType))
+	if m.MigrateFrom == SerializerBorsh {
+		decode, err := borsh.decode(oldType, "oldState", "oldReader", "\t\t")
+		if err != nil {
+			return "", fmt.Errorf("method '%s' previous state: %w", m.Name, err)
+		}
+		sb.WriteString("\t\toldReader := &borshReader{buf: oldVal}\n")
+		sb.WriteString(decode)
+		sb.WriteString("\t\toldReader.finish()\n")
+	} else {
+		sb.WriteString("\t\terr = encodingJson.Unmarshal(oldVal, &oldState)\n")
+		sb.WriteString("\t\tif err != nil {\n")
+		sb.WriteString("\t\t\tenv.PanicStr(\"Failed to deserialize previous state\")\n")
+		sb.WriteString("\t\t}\n")
+	}
+	sb.WriteString("\t\tstate := defaultInit()\n\n")
+
+	oldArg := "oldState"
+	if strings.HasPrefix(oldParam.Type, "*") {
+		oldArg = "&oldState"
+	}
+	sb.WriteString("\t\t// Call method\n")
+	if len(m.Returns) == 1 {
+		sb.WriteString(fmt.Sprintf("\t\tcallErr := state.%s(%s)\n\n", m.Name, oldArg))
+		sb.WriteString("\t\tif callErr != nil {\n")
+		sb.WriteString("\t\t\tenv.PanicStr(callErr.Error())\n")
+		sb.WriteString("\t\t}\n\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("\t\tstate.%s(%s)\n\n", m.Name, oldArg))
+	}
+
+	sb.WriteString("\t\tsetState(state)\n\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n")
+
+	return sb.String(), nil
+}
+
+// paramsAreWholeInput reports whether a single parameter is decoded from the entire JSON input
+// instead of a wrapping object with one field per parameter: raw bytes, or a struct of the
+// module whose fields are the arguments.
+func paramsAreWholeInput(params []Param, serializer string) bool {
+	if serializer == SerializerBorsh || len(params) != 1 {
+		return false
+	}
+	return params[0].Type == "[]byte" || params[0].Struct
+}
+
+func generateBorshParamParser(params []Param, indent string, borsh *borshGenerator) (string, error) {
+	if len(params) == 0 {
+		return indent + "// No parameters to parse\n", nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(indent + "// Parse Borsh input parameters in declaration order\n")
+	sb.WriteString(indent + "var params struct {\n")
+	for _, p := range params {
+		sb.WriteString(fmt.Sprintf("%s\t%s %s\n", indent, capitalizeFirst(p.Name), p.Type))
+	}
+	sb.WriteString(indent + "}\n")
+	sb.WriteString(indent + "argsReader := &borshReader{buf: input.Data}\n")
+	for _, p := range params {
+		decode, err := borsh.decode(p.Type, "params."+capitalizeFirst(p.Name), "argsReader", indent)
+		if err != nil {
+			return "", fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		sb.WriteString(decode)
+	}
+	sb.WriteString(indent + "argsReader.finish()\n")
+	return sb.String(), nil
+}
+
+func generateParamParser(params []Param, indent string, wire *nearWireGenerator) string {
 	if len(params) == 0 {
 		return indent + "// No parameters to parse\n"
 	}
@@ -730,19 +1504,35 @@ func generateParamParser(params []Param, indent string) string {
 		return sb.String()
 	}
 
-	if len(params) == 1 && !isBasicType(params[0].Type) {
+	if len(params) == 1 && params[0].Struct {
 		p := params[0]
-		sb.WriteString(fmt.Sprintf("%svar params %s\n", indent, p.Type))
-		sb.WriteString(indent + "err := encodingJson.Unmarshal(input.Data, &params)\n")
+		target := "params"
+		if wire.needsWire(p.Type) {
+			target = "paramsWire"
+			sb.WriteString(fmt.Sprintf("%svar paramsWire %s\n", indent, wire.wireType(p.Type)))
+		} else {
+			sb.WriteString(fmt.Sprintf("%svar params %s\n", indent, p.Type))
+		}
+		sb.WriteString(indent + "err := encodingJson.Unmarshal(input.Data, &" + target + ")\n")
 		sb.WriteString(indent + "if err != nil {\n")
 		sb.WriteString(indent + "\tenv.PanicStr(\"Failed to parse " + p.Type + " parameter\")\n")
 		sb.WriteString(indent + "}\n")
+		if target == "paramsWire" {
+			sb.WriteString(fmt.Sprintf("%svar params %s\n", indent, p.Type))
+			sb.WriteString(wire.fromWire(p.Type, "paramsWire", "params", p.Name, indent))
+		}
 	} else {
 		sb.WriteString(indent + "var params struct {\n")
 		for _, p := range params {
 			fieldName := capitalizeFirst(p.Name)
+			fieldType := p.Type
+			if isNearParam(p) {
+				fieldType = nearWireType(p.Type)
+			} else {
+				fieldType = wire.wireType(p.Type)
+			}
 			jsonTag := fmt.Sprintf("`json:\"%s\"`", p.Name)
-			sb.WriteString(fmt.Sprintf("%s\t%s %s %s\n", indent, fieldName, p.Type, jsonTag))
+			sb.WriteString(fmt.Sprintf("%s\t%s %s %s\n", indent, fieldName, fieldType, jsonTag))
 		}
 		sb.WriteString(indent + "}\n")
 
@@ -750,59 +1540,19 @@ func generateParamParser(params []Param, indent string) string {
 		sb.WriteString(indent + "if err != nil {\n")
 		sb.WriteString(indent + "\tenv.PanicStr(\"Failed to parse input parameters\")\n")
 		sb.WriteString(indent + "}\n")
+		for _, p := range params {
+			if isNearParam(p) {
+				sb.WriteString(generateNearParamConversion(p, indent))
+			} else if wire.needsWire(p.Type) {
+				sb.WriteString(fmt.Sprintf("%svar %s %s\n", indent, nearParamArg(p), p.Type))
+				sb.WriteString(wire.fromWire(p.Type, "params."+capitalizeFirst(p.Name), nearParamArg(p), p.Name, indent))
+			}
+		}
 	}
 
 	return sb.String()
 }
 
-func isBasicType(typeStr string) bool {
-	basicTypes := map[string]bool{
-		"string":                 true,
-		"bool":                   true,
-		"int":                    true,
-		"int8":                   true,
-		"int16":                  true,
-		"int32":                  true,
-		"int64":                  true,
-		"uint":                   true,
-		"uint8":                  true,
-		"uint16":                 true,
-		"uint32":                 true,
-		"uint64":                 true,
-		"uintptr":                true,
-		"byte":                   true,
-		"rune":                   true,
-		"float32":                true,
-		"float64":                true,
-		"complex64":              true,
-		"complex128":             true,
-		"[]byte":                 true,
-		"[]string":               true,
-		"[]int":                  true,
-		"[]int64":                true,
-		"[]float64":              true,
-		"[]bool":                 true,
-		"map[string]string":      true,
-		"map[string]interface{}": true,
-	}
-
-	if basicTypes[typeStr] {
-		return true
-	}
-	if strings.HasPrefix(typeStr, "*") {
-		baseType := strings.TrimPrefix(typeStr, "*")
-		return basicTypes[baseType]
-	}
-	if strings.HasPrefix(typeStr, "[]") {
-		baseType := strings.TrimPrefix(typeStr, "[]")
-		return basicTypes[baseType] || isBasicType(baseType)
-	}
-	if strings.HasPrefix(typeStr, "map[") {
-		return true
-	}
-	return false
-}
-
 func toSnakeCase(s string) string {
 	var result strings.Builder
 	runes := []rune(s)
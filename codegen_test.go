@@ -165,8 +165,11 @@ func (c *Contract) ViewMethod() {}
 		t.Errorf("View method failed to export")
 	}
 
-	if strings.Contains(generated, "func internal_helper()") {
-		t.Errorf("SECURITY RISK: Private method was exported!")
+	if !strings.Contains(generated, "func internal_helper()") {
+		t.Errorf("Private method failed to export")
+	}
+	if !strings.Contains(generated, "if !accessIsContract(accessPredecessor()) {") {
+		t.Errorf("SECURITY RISK: Private method can be called by other accounts!")
 	}
 }
 
@@ -208,6 +211,7 @@ import (
 	"fmt"
 )
 
+// @contract:state
 type Contract struct {}
 
 // @contract:mutating
@@ -222,10 +226,134 @@ func (c *Contract) BigMath(num *big.Int) {
 	}
 
 	if !strings.Contains(generated, "\"math/big\"") {
-		t.Errorf("Failed to preserve 'math/big' import")
+		t.Errorf("Failed to preserve 'math/big' import used by a parameter type")
+	}
+	if strings.Contains(generated, "\"fmt\"") {
+		t.Errorf("Imports only the contract code uses must stay in the contract file")
+	}
+	if strings.Contains(generated, "fmt.Println") {
+		t.Errorf("Contract code must not be copied into the generated file")
+	}
+}
+
+// setupModule writes files, keyed by path relative to the module root, into a
+// module named example.com/split and returns the root.
+func setupModule(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/split\n"
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestGenerateCode_ImportedPackages(t *testing.T) {
+	dir := setupModule(t, map[string]string{
+		"internal/model/model.go": `package model
+
+type Item struct {
+	Name string
+	Tags []Tag
+}
+
+type Tag struct {
+	Value uint32
+}
+`,
+		"internal/units/units.go": "package quantity\n\ntype Amount struct {\n\tValue uint64\n}\n",
+		"contract/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/split/internal/model"
+	"example.com/split/internal/units"
+)
+
+// @contract:state serializer=borsh
+type Contract struct {
+	Items []model.Item
+}
+
+// @contract:mutating args=borsh
+func (c *Contract) Add(item model.Item) {
+	fmt.Println(item.Name)
+}
+
+// @contract:view result=borsh
+func (c *Contract) Total() quantity.Amount { return quantity.Amount{} }
+`,
+		"contract/helpers.go": `package main
+
+import "fmt"
+
+func describe(c *Contract) string { return fmt.Sprint(len(c.Items)) }
+`,
+	})
+
+	contract, err := ScanContract(filepath.Join(dir, "contract"))
+	if err != nil {
+		t.Fatalf("ScanContract failed: %v", err)
+	}
+	if _, ok := contract.Structs()["model.Tag"]; !ok {
+		t.Errorf("Struct types of imported module packages must be known by their qualified name")
+	}
+
+	generated, err := GenerateCode(filepath.Join(dir, "contract"))
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+	for _, want := range []string{
+		"\t\"example.com/split/internal/model\"\n",
+		"\t\"example.com/split/internal/units\"\n",
+		"func borshEncodemodel_Item(w *borshWriter, v model.Item)",
+		"func borshDecodemodel_Tag(r *borshReader) model.Tag",
+		"params.Item = borshDecodemodel_Item(argsReader)",
+		"borshEncodequantity_Amount(resultWriter, result)",
+	} {
+		if !strings.Contains(generated, want) {
+			t.Errorf("Generated code does not contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"\"fmt\"", "func describe", "func (c *Contract) Add"} {
+		if strings.Contains(generated, unwanted) {
+			t.Errorf("Generated code must only hold the glue, found %q", unwanted)
+		}
 	}
-	if !strings.Contains(generated, "\"fmt\"") {
-		t.Errorf("Failed to preserve 'fmt' import")
+}
+
+func TestGenerateCode_ImportNameConflict(t *testing.T) {
+	dir := setupModule(t, map[string]string{
+		"a/a.go": "package a\n\ntype Item struct{ Name string }\n",
+		"b/b.go": "package b\n\ntype Item struct{ ID uint64 }\n",
+		"main.go": `package main
+
+import pkg "example.com/split/a"
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating
+func (c *Contract) First(item pkg.Item) {}
+`,
+		"other.go": `package main
+
+import pkg "example.com/split/b"
+
+// @contract:mutating
+func (c *Contract) Second(item pkg.Item) {}
+`,
+	})
+
+	_, err := GenerateCode(dir)
+	if err == nil || !strings.Contains(err.Error(), "import name 'pkg'") {
+		t.Errorf("Expected an import name conflict error, got %v", err)
 	}
 }
 
@@ -345,3 +473,142 @@ func (c *Contract) RegularMethod() {}
 		t.Errorf("Expected specific error message about missing annotations, got: %v", err)
 	}
 }
+
+func TestGenerateCode_Migrate(t *testing.T) {
+	contractCode := `
+package main
+
+type ContractV1 struct {
+	Count int
+}
+
+// @contract:state
+type Contract struct {
+	Count int64
+	Label string
+}
+
+// @contract:migrate
+func (c *Contract) Migrate(old ContractV1) {
+	c.Count = int64(old.Count)
+}
+`
+	dir := setupTestProject(t, contractCode)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	if !strings.Contains(generated, "func migrate()") {
+		t.Errorf("Expected migrate export")
+	}
+	if !strings.Contains(generated, "if currentAccountId != predecessorAccountId {") {
+		t.Errorf("Migration must be restricted to the contract account")
+	}
+	if !strings.Contains(generated, "var oldState ContractV1") || !strings.Contains(generated, "encodingJson.Unmarshal(oldVal, &oldState)") {
+		t.Errorf("Expected previous state to be decoded into ContractV1")
+	}
+	if !strings.Contains(generated, "state.Migrate(oldState)") {
+		t.Errorf("Expected migrate method to receive the previous state")
+	}
+	if strings.Contains(generated, "Contract already initialized") {
+		t.Errorf("Migration must not refuse existing state")
+	}
+
+	abi := generateTestABI(t, contractCode)
+	fn := findAbiFunction(abi, "migrate")
+	if fn == nil || fn.Params != nil || len(fn.Modifiers) != 2 || fn.Modifiers[0] != "init" || fn.Modifiers[1] != "private" {
+		t.Errorf("Unexpected ABI for migrate: %+v", fn)
+	}
+}
+
+func TestGenerateCode_MigrateBorshPreviousState(t *testing.T) {
+	contractCode := `
+package main
+
+type ContractV1 struct {
+	Count uint32
+}
+
+// @contract:state serializer=borsh
+type Contract struct {
+	Count uint64
+}
+
+// @contract:migrate
+func (c *Contract) Migrate(old *ContractV1) error { return nil }
+`
+	dir := setupTestProject(t, contractCode)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	if !strings.Contains(generated, "oldState = borshDecodeContractV1(oldReader)") {
+		t.Errorf("Previous state should default to the state serializer")
+	}
+	if !strings.Contains(generated, "callErr := state.Migrate(&oldState)") {
+		t.Errorf("Expected pointer argument and error handling for Migrate")
+	}
+}
+
+func TestGenerateCode_MigrateValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		expected string
+	}{
+		{"no params", "func (c *Contract) Migrate() {}", "must take exactly one parameter"},
+		{"current state", "func (c *Contract) Migrate(old Contract) {}", "must take the previous state type"},
+		{"returns data", "func (c *Contract) Migrate(old ContractV1) string { return \"\" }", "may only return an error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contractCode := `
+package main
+
+type ContractV1 struct {}
+
+// @contract:state
+type Contract struct {}
+
+// @contract:migrate
+` + tt.method + `
+`
+			dir := setupTestProject(t, contractCode)
+			_, err := GenerateCode(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestScanContract_MultipleResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		returns  string
+		expected string
+	}{
+		{"value and error", "(int, error)", ""},
+		{"two values", "(int, string)", "method 'Get' returns 2 values"},
+		{"two values and error", "(int, string, error)", "method 'Get' returns 2 values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupTestProject(t, "package main\n\n// @contract:state\ntype Contract struct {}\n\n// @contract:view\nfunc (c *Contract) Get() "+tt.returns+" {\n\tpanic(\"\")\n}\n")
+			_, err := ScanContract(dir)
+			if tt.expected == "" {
+				if err != nil {
+					t.Errorf("ScanContract failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) || !strings.HasPrefix(err.Error(), "main.go:") {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
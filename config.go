@@ -1,24 +1,99 @@
 package main
 
-import "embed"
+import (
+	"embed"
+	"time"
+)
 
 //go:embed template/**/*
 var templates embed.FS
 
+// ProjectTemplates maps each project type to its folder in 'template/'. Every
+// '<name>.template' file in the folder is written to the contract folder as '<name>'.
+var ProjectTemplates = map[string]string{
+	SmartContractTypeProject: "template/contract",
+	FungibleTokenTypeProject: "template/ft",
+	NonFungibleTypeProject:   "template/nft",
+	MultisigTypeProject:      "template/multisig",
+	DaoTypeProject:           "template/dao",
+}
+
 const (
 	NearSdkGoVersion = "v0.1.1"
 
 	SmartContractTypeProject   = "smart-contract-empty"
+	FungibleTokenTypeProject   = "ft"
+	NonFungibleTypeProject     = "nft"
+	MultisigTypeProject        = "multisig"
+	DaoTypeProject             = "dao"
 	SmartContractProjectFolder = "contract"
 
-	ContractMainGoPath     = "template/contract/main.go.template"
-	ContractMainGoFileName = "./main.go"
+	TemplateFileSuffix = ".template"
+
+	SerializerJSON  = "json"
+	SerializerBorsh = "borsh"
+
+	// MaxContractSize is the protocol limit for deployed code (max_contract_size).
+	MaxContractSize = 4 * 1024 * 1024
+
+	AbiSchemaVersion = "0.4.0"
+	AbiFileName      = "abi.json"
+
+	// ProjectConfigFileName is looked up from the working directory upwards.
+	ProjectConfigFileName = "near-go.toml"
+	// WorkspaceOutDir receives '<contract>.wasm' of every contract built by 'build --all'.
+	WorkspaceOutDir = "out"
+
+	GeneratedBuildFileName = "generated_build.go"
+	// BuildCacheDirName is the folder under ~/.near-go holding WASM builds keyed by
+	// the hash of their inputs.
+	BuildCacheDirName = "cache"
+
+	// Reproducible builds record their inputs in '<name>.build-info.json' ('build-info.json'
+	// for main.wasm) and compile from a copy of the module under ReproducibleBuildRoot,
+	// so absolute paths never leak into the WASM.
+	BuildInfoFileName        = "build-info.json"
+	BuildInfoSchemaVersion   = 1
+	ReproducibleBuildRoot    = "/tmp/near-go-reproducible"
+	TinyGoBundleMarkerName   = ".bundle-sha256"
+	SourceMetadataExport     = "contract_source_metadata"
+	SourceMetadataStandard   = "nep330"
+	SourceMetadataStdVersion = "1.2.0"
+
+	// 'build --optimize' saves the size report of the WASM in '<name>.size.json'
+	// ('size.json' for main.wasm) to diff the next build against it.
+	SizeReportFileName   = "size.json"
+	DefaultSizeReportTop = 15
+
+	// Simulation tests run main.wasm through the 'sim' package and are kept out of
+	// 'tinygo test' by the build tag.
+	SimTestsDir = "sim"
+	SimBuildTag = "sim"
+
+	// 'build --watch' and 'test --watch' poll the module for changed Go files and
+	// run once the files stopped changing for WatchDebounce.
+	WatchPollInterval = 500 * time.Millisecond
+	WatchDebounce     = 300 * time.Millisecond
 
 	ErrProvidedNetwork                   = "(USER_INPUT_ERROR): Missing 'network'"
 	ErrProvidedNetworkAndAccountName     = "(USER_INPUT_ERROR): Missing both 'network' and 'account-name'"
 	ErrProvidedNetworkAndContractId      = "(USER_INPUT_ERROR): Missing both 'network' and 'contract-id'"
+	ErrProvidedContractAndNetwork        = "(USER_INPUT_ERROR): Missing 'contract' or 'network' (pass the flags or set them in near-go.toml)"
 	ErrProvidedProjectNameModuleNameType = "(USER_INPUT_ERROR): Missing 'project-name', 'module-name', or 'type'"
 	ErrIncorrectType                     = "(USER_INPUT_ERROR): Invalid project type"
+	ErrProvidedAccountId                 = "(USER_INPUT_ERROR): Missing 'account-id'"
+	ErrAccountNotFound                   = "(USER_INPUT_ERROR): Account does not exist"
+	ErrProvidedAccountIdAndNetwork       = "(USER_INPUT_ERROR): Missing both 'account-id' and 'network'"
+	ErrSeedPhraseAndPrivateKey           = "(USER_INPUT_ERROR): Provide either 'seed-phrase' or 'private-key', not both"
+	ErrEmptyPassphrase                   = "(USER_INPUT_ERROR): Passphrase must not be empty"
+	ErrPassphraseMismatch                = "(USER_INPUT_ERROR): Passphrases do not match"
+	ErrAborted                           = "(USER_INPUT_ERROR): Aborted"
+	ErrInvalidArgsJSON                   = "(USER_INPUT_ERROR): 'args' must be valid JSON"
+	ErrBlockIdAndFinality                = "(USER_INPUT_ERROR): Use either 'block-id' or 'finality', not both"
+	ErrInvalidFinality                   = "(USER_INPUT_ERROR): 'finality' must be 'final' or 'optimistic'"
+	ErrNotViewMethod                     = "(USER_INPUT_ERROR): Not a view method"
+	ErrInitMethodAndNoInit               = "(USER_INPUT_ERROR): Use either 'init-method' or 'no-init', not both"
+	ErrWasmValidationFailed              = "(WASM_ERROR): Contract failed pre-deploy validation"
 	ErrRunningNearCLI                    = "(INTERNAL_UTILS): Failed to execute Near CLI"
 	ErrRunningCmd                        = "(INTERNAL_UTILS): Failed to start command"
 	ErrGoProjectModFileIsMissing         = "(INTERNAL_PROJECT_CONTRACT): Missing 'go.mod' file"
@@ -27,4 +102,15 @@ const (
 	ErrBuildFailed                       = "(BUILD_ERROR): Build failed after retries"
 	ErrWasmNotFound                      = "(BUILD_ERROR): WASM file not found after build"
 	ErrNetworkUnreachable                = "(NETWORK_ERROR): Unable to download dependencies"
+	ErrSimTestsNotFound                  = "(TEST_ERROR): Simulation tests not found"
+	ErrProfileInitFailed                 = "(PROFILE_ERROR): Init call failed"
+	ErrCacheClean                        = "(CACHE_ERROR): Failed to clean the build cache"
+	ErrTinyGoBundle                      = "(BUILD_ERROR): Failed to extract the bundled TinyGo"
+	ErrReproducibleBuild                 = "(BUILD_ERROR): Failed to prepare the reproducible build"
+	ErrNoContractDeployed                = "(VERIFY_ERROR): No contract is deployed to the account"
+	ErrVerifyMismatch                    = "(VERIFY_ERROR): Local build does not match the deployed code"
+	ErrOptimizeFailed                    = "(BUILD_ERROR): Failed to optimize the WASM"
+	ErrInvalidProjectConfig              = "(CONFIG_ERROR): Invalid project config"
+	ErrWorkspaceContracts                = "(WORKSPACE_ERROR): Cannot find the workspace contracts"
+	ErrWorkspaceBuildFailed              = "(WORKSPACE_ERROR): Contract builds failed"
 )
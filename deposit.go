@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+var amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// parseAmountToYocto converts a deposit option, plain yoctoNEAR or a NEAR amount
+// such as 0.5NEAR, to yoctoNEAR.
+func parseAmountToYocto(amount string) (string, error) {
+	amount = strings.TrimSpace(amount)
+	isNear := strings.HasSuffix(amount, "NEAR")
+	amount = strings.TrimSpace(strings.TrimSuffix(amount, "NEAR"))
+
+	if !amountPattern.MatchString(amount) {
+		return "", fmt.Errorf("'%s' is not an amount: use yoctoNEAR, e.g. 1000, or NEAR, e.g. 0.5NEAR", amount)
+	}
+	value, _ := new(big.Rat).SetString(amount)
+	if isNear {
+		base, _ := new(big.Rat).SetString(yoctoPerNear)
+		value.Mul(value, base)
+	}
+	if !value.IsInt() {
+		return "", fmt.Errorf("'%s' is not a whole number of yoctoNEAR", amount)
+	}
+	yocto := value.Num()
+	if yocto.Cmp(maxUint128) > 0 {
+		return "", fmt.Errorf("'%s' is out of the U128 range of deposits", amount)
+	}
+	return yocto.String(), nil
+}
+
+// acceptsNoDeposit reports whether the export of m rejects attached deposits, as
+// near-sdk-rs does for every call method that isn't payable. Methods that may be
+// called as views, @contract:public without mutating, aren't checked: views
+// can't read the attached deposit.
+func (m *MethodInfo) acceptsNoDeposit() bool {
+	if m.IsPayable || m.IsView {
+		return false
+	}
+	return m.IsMutating || m.IsPromiseCallback || m.IsPrivate || m.IsOwnerOnly || len(m.Roles) > 0
+}
+
+// validateDeposit checks the options of @contract:payable.
+func validateDeposit(m *MethodInfo) error {
+	if m.OneYocto && (m.MinDeposit != "" || m.MaxDeposit != "" || m.ExactDeposit != "") {
+		return fmt.Errorf("method '%s' cannot combine one_yocto with min_deposit, max_deposit or exact_deposit", m.Name)
+	}
+	if m.ExactDeposit != "" && (m.MinDeposit != "" || m.MaxDeposit != "") {
+		return fmt.Errorf("method '%s' cannot combine exact_deposit with min_deposit or max_deposit", m.Name)
+	}
+
+	amounts := map[string]string{}
+	for _, option := range []struct{ name, amount string }{
+		{"min_deposit", m.MinDeposit},
+		{"max_deposit", m.MaxDeposit},
+		{"exact_deposit", m.ExactDeposit},
+	} {
+		if option.amount == "" {
+			continue
+		}
+		yocto, err := parseAmountToYocto(option.amount)
+		if err != nil {
+			return fmt.Errorf("method '%s' has invalid %s: %w", m.Name, option.name, err)
+		}
+		amounts[option.name] = yocto
+	}
+	if min, max := amounts["min_deposit"], amounts["max_deposit"]; min != "" && max != "" {
+		minValue, _ := new(big.Int).SetString(min, 10)
+		maxValue, _ := new(big.Int).SetString(max, 10)
+		if minValue.Cmp(maxValue) > 0 {
+			return fmt.Errorf("method '%s' has min_deposit=%s above max_deposit=%s", m.Name, m.MinDeposit, m.MaxDeposit)
+		}
+	}
+	return nil
+}
+
+// generateDepositCheck returns the guard of an export for the attached deposit:
+// the limits of @contract:payable, or none at all for methods that aren't
+// payable. validateDeposit has checked the amounts.
+func generateDepositCheck(m *MethodInfo, indent string) (string, error) {
+	exportName := toSnakeCase(m.Name)
+	if m.acceptsNoDeposit() {
+		return generateNoDepositCheck(exportName, indent), nil
+	}
+
+	var sb strings.Builder
+	if m.MinDeposit != "" {
+		yocto, err := parseAmountToYocto(m.MinDeposit)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("%sif !validatePayment(\"%s\") {\n", indent, yocto))
+		sb.WriteString(indent + "\tenv.PanicStr(\"Insufficient payment\")\n")
+		sb.WriteString(indent + "}\n")
+	}
+
+	exact := m.ExactDeposit
+	if m.OneYocto {
+		exact = "1"
+	}
+	limits := []struct{ amount, cmp, message string }{
+		{m.MaxDeposit, "> 0", "accepts at most %s yoctoNEAR"},
+		{exact, "!= 0", "requires an attached deposit of exactly %s yoctoNEAR"},
+	}
+	for _, limit := range limits {
+		if limit.amount == "" {
+			continue
+		}
+		yocto, err := parseAmountToYocto(limit.amount)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("%sif depositAttached().Cmp(%s) %s {\n", indent, yoctoLiteral(yocto), limit.cmp))
+		sb.WriteString(fmt.Sprintf("%s\tenv.PanicStr(\"Method '%s' %s\")\n", indent, exportName, fmt.Sprintf(limit.message, yocto)))
+		sb.WriteString(indent + "}\n")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// generateNoDepositCheck returns the guard rejecting attached deposits, which
+// would otherwise be kept by the contract without the method knowing.
+func generateNoDepositCheck(exportName, indent string) string {
+	var sb strings.Builder
+	sb.WriteString(indent + "if depositAttached() != (types.Uint128{}) {\n")
+	sb.WriteString(fmt.Sprintf("%s\tenv.PanicStr(\"Method '%s' doesn't accept deposit\")\n", indent, exportName))
+	sb.WriteString(indent + "}\n\n")
+	return sb.String()
+}
+
+// yoctoLiteral renders a yoctoNEAR amount as a types.Uint128 literal, so the
+// generated code doesn't parse it at run time.
+func yoctoLiteral(yocto string) string {
+	value, _ := new(big.Int).SetString(yocto, 10)
+	lo := new(big.Int).And(value, new(big.Int).SetUint64(^uint64(0)))
+	hi := new(big.Int).Rsh(value, 64)
+	return fmt.Sprintf("types.Uint128{Hi: %s, Lo: %s}", hi, lo)
+}
+
+// generateDepositHelpers returns the deposit helper the deposit checks call.
+func generateDepositHelpers(generated string) string {
+	if !strings.Contains(generated, "depositAttached()") {
+		return ""
+	}
+	return `func depositAttached() types.Uint128 {
+	deposit, err := env.GetAttachedDeposit()
+	if err != nil {
+		env.PanicStr("Failed to read the attached deposit")
+	}
+	return deposit
+}
+`
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const depositContract = `
+package main
+
+// @contract:state
+type Contract struct {
+	Value string
+}
+
+// @contract:mutating
+// @contract:payable one_yocto
+func (c *Contract) FtTransfer(receiverId string) {}
+
+// @contract:mutating
+// @contract:payable min_deposit=1NEAR max_deposit=2.5NEAR
+func (c *Contract) Buy() {}
+
+// @contract:payable exact_deposit=0.00125NEAR
+func (c *Contract) StorageDeposit() {}
+
+// @contract:mutating
+// @contract:payable
+func (c *Contract) Donate() {}
+
+// @contract:mutating
+func (c *Contract) SetValue(value string) {}
+
+// @contract:view
+func (c *Contract) GetValue() string { return c.Value }
+
+// @contract:public
+func (c *Contract) Peek() string { return c.Value }
+`
+
+func TestGenerateCode_Deposits(t *testing.T) {
+	dir := setupTestProject(t, depositContract)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	expected := []string{
+		"if depositAttached().Cmp(types.Uint128{Hi: 0, Lo: 1}) != 0 {",
+		"env.PanicStr(\"Method 'ft_transfer' requires an attached deposit of exactly 1 yoctoNEAR\")",
+		"if !validatePayment(\"1000000000000000000000000\") {",
+		"if depositAttached().Cmp(types.Uint128{Hi: 135525, Lo: 5009410513017241600}) > 0 {",
+		"env.PanicStr(\"Method 'buy' accepts at most 2500000000000000000000000 yoctoNEAR\")",
+		"env.PanicStr(\"Method 'storage_deposit' requires an attached deposit of exactly 1250000000000000000000 yoctoNEAR\")",
+		"if depositAttached() != (types.Uint128{}) {",
+		"env.PanicStr(\"Method 'set_value' doesn't accept deposit\")",
+		"func depositAttached() types.Uint128 {",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(generated, exp) {
+			t.Errorf("Expected generated code to contain %q", exp)
+		}
+	}
+
+	// Payable methods without limits and methods that may be called as views
+	// don't look at the deposit.
+	for _, export := range []string{"donate", "get_value", "peek"} {
+		body := generated[strings.Index(generated, "func "+export+"()"):]
+		body = body[:strings.Index(body, "\n}\n")]
+		if strings.Contains(body, "depositAttached") {
+			t.Errorf("Expected no deposit check in %s", export)
+		}
+	}
+
+	// The check runs before the arguments are parsed.
+	setValue := generated[strings.Index(generated, "func set_value()"):]
+	if strings.Index(setValue, "depositAttached") > strings.Index(setValue, "encodingJson.Unmarshal") {
+		t.Errorf("Deposit check must come before parsing the arguments")
+	}
+}
+
+func TestGenerateCode_NoDepositOnRoleRegistry(t *testing.T) {
+	dir := setupTestProject(t, accessContract)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+	for _, export := range []string{"grant_role", "revoke_role", "sweep", "burn"} {
+		if !strings.Contains(generated, "env.PanicStr(\"Method '"+export+"' doesn't accept deposit\")") {
+			t.Errorf("Expected %s to reject deposits", export)
+		}
+	}
+}
+
+func TestScanContract_DepositErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  string
+		expected string
+	}{
+		{
+			name:     "one_yocto and min",
+			options:  "one_yocto min_deposit=1",
+			expected: "cannot combine one_yocto with min_deposit, max_deposit or exact_deposit",
+		},
+		{
+			name:     "exact and max",
+			options:  "exact_deposit=1NEAR max_deposit=2NEAR",
+			expected: "cannot combine exact_deposit with min_deposit or max_deposit",
+		},
+		{
+			name:     "min above max",
+			options:  "min_deposit=2NEAR max_deposit=1NEAR",
+			expected: "has min_deposit=2NEAR above max_deposit=1NEAR",
+		},
+		{
+			name:     "invalid amount",
+			options:  "min_deposit=one",
+			expected: "has invalid min_deposit: 'one' is not an amount",
+		},
+		{
+			name:     "fractional yocto",
+			options:  "max_deposit=0.5",
+			expected: "has invalid max_deposit: '0.5' is not a whole number of yoctoNEAR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupTestProject(t, "package main\n\n// @contract:state\ntype Contract struct {}\n\n// @contract:mutating\n// @contract:payable "+tt.options+"\nfunc (c *Contract) Pay() {}\n")
+			_, err := ScanContract(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestParseAmountToYocto(t *testing.T) {
+	tests := map[string]string{
+		"1":                              "1",
+		"1NEAR":                          "1000000000000000000000000",
+		"0.1NEAR":                        "100000000000000000000000",
+		"0.000000000000000000000001NEAR": "1",
+		"340282366920938463463374607431768211455": "340282366920938463463374607431768211455",
+		"340282366920938463463374607431768211456": "",
+		"-1":   "",
+		"1e24": "",
+		"1/2":  "",
+		"0.5":  "",
+		"NEAR": "",
+	}
+	for amount, want := range tests {
+		got, err := parseAmountToYocto(amount)
+		if (err == nil) != (want != "") || got != want {
+			t.Errorf("parseAmountToYocto(%q) = %q, %v; want %q", amount, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Codegen writes a '// Export: <name> (from <file>:<line>)', '// State: ...' or
+// '// Event: ...' marker above each block of generated_build.go that belongs to a
+// contract declaration. Compiler errors in the generated file are mapped back to
+// that declaration, since the file itself is deleted after the build.
+var (
+	glueMarkerPattern     = regexp.MustCompile(`^// (Export|State|Event): (\S+) \(from (.+):(\d+)\)$`)
+	generatedErrorPattern = regexp.MustCompile(`^(?:.*[/\\])?` + regexp.QuoteMeta(GeneratedBuildFileName) + `:(\d+)(?::(\d+))?: (.*)$`)
+)
+
+// maxSnippetCommentLines bounds the annotation comments quoted above a declaration.
+const maxSnippetCommentLines = 4
+
+// glueOrigin is the contract declaration a line of generated code was written for.
+type glueOrigin struct {
+	Kind string
+	Name string
+	Path string
+	Line int
+}
+
+// glueOrigins returns the origin of every line of the generated code, indexed
+// from 0. Shared helpers, which belong to no declaration, have none.
+func glueOrigins(code string) []*glueOrigin {
+	lines := strings.Split(code, "\n")
+	origins := make([]*glueOrigin, len(lines))
+	var current *glueOrigin
+	for i, line := range lines {
+		if strings.HasPrefix(line, "// =====") {
+			current = nil
+		} else if m := glueMarkerPattern.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[4])
+			current = &glueOrigin{Kind: strings.ToLower(m[1]), Name: m[2], Path: m[3], Line: n}
+		}
+		origins[i] = current
+	}
+	return origins
+}
+
+// mapGeneratedDiagnostics rewrites compiler errors located in the generated file
+// as errors of the contract declaration they come from, quoting the generated line.
+func mapGeneratedDiagnostics(output, code string, keepGenerated bool) string {
+	origins := glueOrigins(code)
+	codeLines := strings.Split(code, "\n")
+
+	var lines []string
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		m := generatedErrorPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			lines = append(lines, line)
+			continue
+		}
+		found = true
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 || n > len(codeLines) {
+			lines = append(lines, line)
+			continue
+		}
+		if origin := origins[n-1]; origin != nil {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s (in the generated code of %s '%s')", origin.Path, origin.Line, m[3], origin.Kind, origin.Name))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s (in a generated helper)", GeneratedBuildFileName, n, m[3]))
+		}
+		lines = append(lines, fmt.Sprintf("    %s:%d | %s", GeneratedBuildFileName, n, strings.TrimSpace(codeLines[n-1])))
+	}
+	if found && !keepGenerated {
+		lines = append(lines, fmt.Sprintf("💡 Build with --keep-generated to inspect %s", GeneratedBuildFileName))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sourceError points a codegen error at the declaration at line of filePath and
+// quotes it, with the comments above it holding its annotations.
+func sourceError(filePath, relativePath string, line int, err error) error {
+	if line <= 0 {
+		return fmt.Errorf("%s: %w", relativePath, err)
+	}
+	return fmt.Errorf("%s:%d: %w\n%s", relativePath, line, err, sourceSnippet(filePath, line))
+}
+
+func sourceSnippet(filePath string, line int) string {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		return ""
+	}
+	start := line
+	for start > 1 && line-start < maxSnippetCommentLines && strings.HasPrefix(strings.TrimSpace(lines[start-2]), "//") {
+		start--
+	}
+	var sb strings.Builder
+	for n := start; n <= line; n++ {
+		sb.WriteString(fmt.Sprintf("%5d | %s\n", n, lines[n-1]))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const diagnosticsContract = `package main
+
+// @contract:state
+type Contract struct {
+	Total uint64
+}
+
+// Add increases the total.
+//
+// @contract:mutating
+// @contract:payable min_deposit=1NEAR
+func (c *Contract) Add(amount uint64) {
+	c.Total += amount
+}
+`
+
+// generatedLine returns the 1-based line of the generated code containing substr.
+func generatedLine(t *testing.T, code, substr string) int {
+	t.Helper()
+	for i, line := range strings.Split(code, "\n") {
+		if strings.Contains(line, substr) {
+			return i + 1
+		}
+	}
+	t.Fatalf("Generated code does not contain %q", substr)
+	return 0
+}
+
+func TestMapGeneratedDiagnostics(t *testing.T) {
+	dir := setupTestProject(t, diagnosticsContract)
+	code, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+	if !strings.Contains(code, "// Export: add (from main.go:12)") || !strings.Contains(code, "// State: Contract (from main.go:4)") {
+		t.Fatalf("Generated code lacks the source markers:\n%s", code)
+	}
+
+	call := generatedLine(t, code, "state.Add(")
+	getState := generatedLine(t, code, "func getState()")
+	helper := generatedLine(t, code, "func validatePayment(")
+	output := strings.Join([]string{
+		"# example.com/contract",
+		fmt.Sprintf("/tmp/contract/%s:%d:9: cannot use params.Amount (variable of type string) as uint64 value", GeneratedBuildFileName, call),
+		fmt.Sprintf("%s:%d:2: undefined: env", GeneratedBuildFileName, getState),
+		fmt.Sprintf("./%s:%d:6: validatePayment redeclared in this block", GeneratedBuildFileName, helper),
+		"main.go:20:1: missing return",
+	}, "\n")
+
+	mapped := mapGeneratedDiagnostics(output, code, false)
+	for _, want := range []string{
+		"main.go:12: cannot use params.Amount (variable of type string) as uint64 value (in the generated code of export 'add')",
+		fmt.Sprintf("    %s:%d | state.Add(params.Amount)", GeneratedBuildFileName, call),
+		"main.go:4: undefined: env (in the generated code of state 'Contract')",
+		fmt.Sprintf("%s:%d: validatePayment redeclared in this block (in a generated helper)", GeneratedBuildFileName, helper),
+		"main.go:20:1: missing return",
+		"# example.com/contract",
+		"--keep-generated",
+	} {
+		if !strings.Contains(mapped, want) {
+			t.Errorf("Mapped output lacks %q:\n%s", want, mapped)
+		}
+	}
+	if strings.Contains(mapGeneratedDiagnostics(output, code, true), "--keep-generated") {
+		t.Error("The --keep-generated hint must not be shown when the file is kept")
+	}
+	if plain := "main.go:1:1: expected 'package'"; mapGeneratedDiagnostics(plain, code, false) != plain {
+		t.Error("Output without errors in the generated file must be left unchanged")
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	tests := map[string]struct {
+		code string
+		want []string
+	}{
+		"incompatible annotations": {
+			code: diagnosticsContract + `
+// @contract:view
+// @contract:mutating
+func (c *Contract) Both() {}
+`,
+			want: []string{"main.go:18: method 'Both' cannot be both", "   16 | // @contract:view", "   18 | func (c *Contract) Both() {}"},
+		},
+		"second state": {
+			code: diagnosticsContract + "\n// @contract:state\ntype Other struct{}\n",
+			want: []string{"main.go:17: found 2 structs with @contract:state", "   16 | // @contract:state", "   17 | type Other struct{}"},
+		},
+		"borsh result": {
+			code: diagnosticsContract + `
+// @contract:view result=borsh
+func (c *Contract) Pair() (uint64, uint64) { return 0, 0 }
+`,
+			want: []string{"main.go:17: method 'Pair' returns 2 values", "   16 | // @contract:view result=borsh"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := GenerateCode(setupTestProject(t, tt.code))
+			if err == nil {
+				t.Fatal("GenerateCode succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Error lacks %q:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
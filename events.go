@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EventsTestFileName holds the event glue while 'near-go test' runs the unit
+// tests, which TinyGo compiles without generated_build.go.
+const EventsTestFileName = "generated_events_test.go"
+
+// EventInfo is a struct annotated with @contract:event. Codegen gives it an
+// Emit method logging it as a NEP-297 event.
+type EventInfo struct {
+	Name string
+	// Event is the NEP-297 event name: name= or the snake_case struct name.
+	Event        string
+	Standard     string
+	Version      string
+	Doc          string
+	FilePath     string
+	RelativePath string
+	Line         int
+
+	isStruct bool
+	generic  bool
+}
+
+// AbiEvent describes an event the contract logs. Data is the schema of the
+// NEP-297 "data" field.
+type AbiEvent struct {
+	Name     string     `json:"name"`
+	Doc      string     `json:"doc,omitempty"`
+	Standard string     `json:"standard"`
+	Version  string     `json:"version"`
+	Data     JSONSchema `json:"data"`
+}
+
+// extractEventInfo reads the @contract:event annotation of a type declaration
+// from its doc comment, or returns nil when it has none.
+func extractEventInfo(typeSpec *ast.TypeSpec, doc *ast.CommentGroup) *EventInfo {
+	if doc == nil {
+		return nil
+	}
+	var event *EventInfo
+	var docLines []string
+	for _, comment := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment.Text), "//"))
+		if !strings.HasPrefix(text, "@contract:event") {
+			if text != "" && !strings.HasPrefix(text, "@contract:") {
+				docLines = append(docLines, text)
+			}
+			continue
+		}
+		event = &EventInfo{Name: typeSpec.Name.Name, Event: toSnakeCase(typeSpec.Name.Name)}
+		for _, part := range strings.Fields(text)[1:] {
+			switch {
+			case strings.HasPrefix(part, "standard="):
+				event.Standard = strings.TrimPrefix(part, "standard=")
+			case strings.HasPrefix(part, "version="):
+				event.Version = strings.TrimPrefix(part, "version=")
+			case strings.HasPrefix(part, "name="):
+				event.Event = strings.TrimPrefix(part, "name=")
+			}
+		}
+	}
+	if event == nil {
+		return nil
+	}
+	_, event.isStruct = typeSpec.Type.(*ast.StructType)
+	event.generic = typeSpec.TypeParams != nil
+	event.Doc = strings.Join(docLines, "\n")
+	return event
+}
+
+// Events returns the events declared in the contract package.
+func (c *ContractInfo) Events() []*EventInfo {
+	return contractEvents(c.Files)
+}
+
+func contractEvents(files []*FileContent) []*EventInfo {
+	var events []*EventInfo
+	for _, content := range files {
+		if content.Qualifier == "" {
+			events = append(events, content.Events...)
+		}
+	}
+	return events
+}
+
+// validateEvents checks the @contract:event annotations. The same event of a
+// standard may only be declared once, so indexers can tell the events apart.
+func validateEvents(events []*EventInfo) error {
+	seen := map[string]*EventInfo{}
+	for _, e := range events {
+		var err error
+		switch {
+		case !e.isStruct:
+			err = fmt.Errorf("type '%s' with @contract:event must be a struct", e.Name)
+		case e.generic:
+			err = fmt.Errorf("event '%s' cannot have type parameters", e.Name)
+		case e.Standard == "" || e.Version == "":
+			err = fmt.Errorf("event '%s' needs standard= and version=, e.g. '@contract:event standard=nep171 version=1.0.0'", e.Name)
+		case e.Event == "":
+			err = fmt.Errorf("event '%s' has an empty name=", e.Name)
+		}
+		key := e.Standard + " " + e.Event
+		if other, ok := seen[key]; ok && err == nil {
+			err = fmt.Errorf("events '%s' and '%s' are both event '%s' of standard '%s'", other.Name, e.Name, e.Event, e.Standard)
+		}
+		if err != nil {
+			return sourceError(e.FilePath, e.RelativePath, e.Line, err)
+		}
+		seen[key] = e
+	}
+	return nil
+}
+
+// event checks that the event encodes as JSON and leaves room for Emit.
+func (c *jsonTypeChecker) event(e *EventInfo) error {
+	obj, ok := c.pkg.Scope().Lookup(e.Name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if found, _, _ := types.LookupFieldOrMethod(obj.Type(), true, c.pkg, "Emit"); found != nil {
+		return fmt.Errorf("event '%s' already has a field or method named Emit, which @contract:event generates", e.Name)
+	}
+	if bad := c.check(obj.Type(), e.Name, false); bad != nil {
+		return bad.error(fmt.Sprintf("event '%s'", e.Name), e.Name)
+	}
+	return nil
+}
+
+// generateEvents returns the Emit methods of the events. NEP-297 leaves the
+// type of "data" to the standard; NEP-141 and NEP-171 log a list of event
+// objects, so an event is logged as a list of one. NEAR types in the event
+// are logged in their string form.
+func generateEvents(events []*EventInfo, wire *nearWireGenerator) string {
+	var sb strings.Builder
+	for _, e := range events {
+		sb.WriteString(fmt.Sprintf("// Event: %s (from %s:%d)\n", e.Name, e.RelativePath, e.Line))
+		sb.WriteString(fmt.Sprintf("func (e %s) Emit() {\n", e.Name))
+		if wire.needsWire(e.Name) {
+			sb.WriteString(fmt.Sprintf("\tvar data %s\n", wire.wireType(e.Name)))
+			sb.WriteString(wire.toWire(e.Name, "e", "data", "\t"))
+			sb.WriteString(fmt.Sprintf("\teventEmit(%q, %q, %q, []%s{data})\n", e.Standard, e.Version, e.Event, wire.wireType(e.Name)))
+		} else {
+			sb.WriteString(fmt.Sprintf("\teventEmit(%q, %q, %q, []%s{e})\n", e.Standard, e.Version, e.Event, e.Name))
+		}
+		sb.WriteString("}\n\n")
+	}
+	return sb.String()
+}
+
+// generateEventHelpers returns the helper the Emit methods call.
+func generateEventHelpers(generated string) string {
+	if !strings.Contains(generated, "eventEmit(") {
+		return ""
+	}
+	return `func eventEmit(standard, version, event string, data interface{}) {
+	payload, err := encodingJson.Marshal(struct {
+		Standard string      ` + "`json:\"standard\"`" + `
+		Version  string      ` + "`json:\"version\"`" + `
+		Event    string      ` + "`json:\"event\"`" + `
+		Data     interface{} ` + "`json:\"data\"`" + `
+	}{standard, version, event, data})
+	if err != nil {
+		env.PanicStr("Failed to encode event '" + event + "': " + err.Error())
+	}
+	env.LogString("EVENT_JSON:" + string(payload))
+}
+`
+}
+
+// events describes the events in the ABI.
+func (g *abiSchemaGenerator) events(events []*EventInfo) []AbiEvent {
+	var abiEvents []AbiEvent
+	for _, e := range events {
+		abiEvents = append(abiEvents, AbiEvent{
+			Name:     e.Event,
+			Doc:      e.Doc,
+			Standard: e.Standard,
+			Version:  e.Version,
+			Data:     JSONSchema{"type": "array", "items": g.schema(e.Name)},
+		})
+	}
+	return abiEvents
+}
+
+// writeEventsTestFile writes the Emit methods of the events declared in dir to
+// EventsTestFileName, so unit tests compile against them. It returns the path
+// to remove after the tests, or "" when the package declares no events.
+func writeEventsTestFile(dir string) (string, error) {
+	_, _, files, err := parsePackageDir(dir)
+	if err != nil {
+		return "", err
+	}
+	events := contractEvents(files)
+	if len(events) == 0 {
+		return "", nil
+	}
+	if err := validateEvents(events); err != nil {
+		return "", err
+	}
+
+	wire := newNearWireGenerator(collectStructs(files))
+	body := generateEvents(events, wire)
+	wireFunctions, err := wire.structFunctions()
+	if err != nil {
+		return "", err
+	}
+	body += wireFunctions + generateEventHelpers("eventEmit(")
+	if helpers := generateNearHelpers(body); helpers != "" {
+		body += "\n" + helpers
+	}
+
+	fixed := map[string]bool{
+		"encodingJson \"encoding/json\"":          true,
+		"\"github.com/vlmoon99/near-sdk-go/env\"": true,
+	}
+	for spec, name := range map[string]string{
+		"\"github.com/vlmoon99/near-sdk-go/types\"": "types.",
+		"\"strconv\"":         "strconv.",
+		"\"encoding/base64\"": "base64.",
+	} {
+		if strings.Contains(body, name) {
+			fixed[spec] = true
+		}
+	}
+	imports, err := glueImports(body, nil, files, fixed)
+	if err != nil {
+		return "", err
+	}
+	for spec := range fixed {
+		imports = append(imports, spec)
+	}
+	sort.Strings(imports)
+
+	code := "// Code generated by NEAR contract generator. DO NOT EDIT.\n\npackage main\n\nimport (\n\t" +
+		strings.Join(imports, "\n\t") + "\n)\n\n" + body
+	path := filepath.Join(dir, EventsTestFileName)
+	if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write '%s': %w", path, err)
+	}
+	return path, nil
+}
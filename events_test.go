@@ -0,0 +1,167 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const eventsContract = `
+package main
+
+// @contract:state
+type Contract struct {}
+
+// NftMint is logged when tokens are minted.
+//
+// @contract:event standard=nep171 version=1.0.0
+type NftMint struct {
+	OwnerId  string   ` + "`json:\"owner_id\"`" + `
+	TokenIds []string ` + "`json:\"token_ids\"`" + `
+	Memo     *string  ` + "`json:\"memo,omitempty\"`" + `
+}
+
+// @contract:event standard=nep141 version=1.0.0 name=ft_burn
+type Burned struct {
+	Amount string ` + "`json:\"amount\"`" + `
+}
+
+// @contract:mutating
+func (c *Contract) Mint(ownerId string) {
+	NftMint{OwnerId: ownerId, TokenIds: []string{"1"}}.Emit()
+}
+`
+
+func TestGenerateCode_Events(t *testing.T) {
+	dir := setupTestProject(t, eventsContract)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	expected := []string{
+		"// ===== Events =====",
+		"// Event: NftMint (from main.go:10)",
+		"func (e NftMint) Emit() {",
+		"eventEmit(\"nep171\", \"1.0.0\", \"nft_mint\", []NftMint{e})",
+		"eventEmit(\"nep141\", \"1.0.0\", \"ft_burn\", []Burned{e})",
+		"func eventEmit(standard, version, event string, data interface{}) {",
+		"env.LogString(\"EVENT_JSON:\" + string(payload))",
+		"encodingJson \"encoding/json\"",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(generated, exp) {
+			t.Errorf("Expected generated code to contain %q", exp)
+		}
+	}
+
+	// Errors in an Emit method are reported against the event struct.
+	emit := generatedLine(t, generated, "func (e Burned) Emit() {")
+	if origin := glueOrigins(generated)[emit-1]; origin == nil || origin.Kind != "event" || origin.Name != "Burned" {
+		t.Errorf("Expected the Emit method to belong to event Burned, got %+v", origin)
+	}
+}
+
+func TestGenerateABI_Events(t *testing.T) {
+	abi := generateTestABI(t, eventsContract)
+
+	if len(abi.Body.Events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", abi.Body.Events)
+	}
+	mint := abi.Body.Events[0]
+	if mint.Name != "nft_mint" || mint.Standard != "nep171" || mint.Version != "1.0.0" || mint.Doc != "NftMint is logged when tokens are minted." {
+		t.Errorf("Unexpected nft_mint event: %+v", mint)
+	}
+	items, ok := mint.Data["items"].(map[string]interface{})
+	if mint.Data["type"] != "array" || !ok || items["$ref"] != "#/definitions/NftMint" {
+		t.Errorf("Expected a list of NftMint as data, got %v", mint.Data)
+	}
+	definitions := abi.Body.RootSchema["definitions"].(map[string]interface{})
+	def, ok := definitions["NftMint"].(map[string]interface{})
+	if !ok || len(def["required"].([]interface{})) != 2 {
+		t.Errorf("Expected NftMint with owner_id and token_ids required, got %v", definitions["NftMint"])
+	}
+	if abi.Body.Events[1].Name != "ft_burn" {
+		t.Errorf("Expected the name= of Burned, got %s", abi.Body.Events[1].Name)
+	}
+}
+
+func TestScanContract_EventErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "not a struct",
+			code:     "// @contract:event standard=nep171 version=1.0.0\ntype Minted string",
+			expected: "type 'Minted' with @contract:event must be a struct",
+		},
+		{
+			name:     "no version",
+			code:     "// @contract:event standard=nep171\ntype Minted struct{}",
+			expected: "event 'Minted' needs standard= and version=",
+		},
+		{
+			name:     "generic",
+			code:     "// @contract:event standard=nep171 version=1.0.0\ntype Minted[T any] struct{ Data T }",
+			expected: "event 'Minted' cannot have type parameters",
+		},
+		{
+			name:     "duplicate",
+			code:     "// @contract:event standard=nep171 version=1.0.0\ntype NftMint struct{}\n\n// @contract:event standard=nep171 version=1.0.0 name=nft_mint\ntype Minted struct{}",
+			expected: "events 'NftMint' and 'Minted' are both event 'nft_mint' of standard 'nep171'",
+		},
+		{
+			name:     "emit clash",
+			code:     "// @contract:event standard=nep171 version=1.0.0\ntype Minted struct{}\n\nfunc (m Minted) Emit() {}",
+			expected: "event 'Minted' already has a field or method named Emit",
+		},
+		{
+			name:     "unsupported field",
+			code:     "// @contract:event standard=nep171 version=1.0.0\ntype Minted struct{ Done chan bool }",
+			expected: "event 'Minted' has unsupported type chan bool at Minted.Done",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupTestProject(t, "package main\n\n// @contract:state\ntype Contract struct {}\n\n// @contract:mutating\nfunc (c *Contract) Run() {}\n\n"+tt.code+"\n")
+			_, err := ScanContract(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) || !strings.HasPrefix(err.Error(), "main.go:") {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestWriteEventsTestFile(t *testing.T) {
+	dir := setupTestProject(t, eventsContract)
+	path, err := writeEventsTestFile(dir)
+	if err != nil {
+		t.Fatalf("writeEventsTestFile failed: %v", err)
+	}
+	if path != filepath.Join(dir, EventsTestFileName) {
+		t.Fatalf("Unexpected path %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Events test file was not written: %v", err)
+	}
+	for _, exp := range []string{"func (e NftMint) Emit() {", "func eventEmit(", "encodingJson \"encoding/json\""} {
+		if !strings.Contains(string(data), exp) {
+			t.Errorf("Expected the events test file to contain %q", exp)
+		}
+	}
+
+	// The scanner skips the file, so it never ends up in the build glue.
+	if _, err := GenerateCode(dir); err != nil {
+		t.Errorf("GenerateCode failed with the events test file present: %v", err)
+	}
+
+	empty := setupTestProject(t, "package main\n\n// @contract:state\ntype Contract struct {}\n")
+	if path, err := writeEventsTestFile(empty); err != nil || path != "" {
+		t.Errorf("Expected no events test file, got %q, %v", path, err)
+	}
+}
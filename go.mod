@@ -2,9 +2,16 @@ module github.com/vlmoon99/near-cli-go
 
 go 1.23.5
 
-require github.com/urfave/cli v1.22.16
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/tetratelabs/wazero v1.9.0
+	github.com/urfave/cli v1.22.16
+	golang.org/x/crypto v0.36.0
+	golang.org/x/term v0.30.0
+)
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.6 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
 )
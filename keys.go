@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv lets scripts unlock encrypted keys without a prompt.
+const PassphraseEnv = "NEAR_GO_KEY_PASSPHRASE"
+
+// defaultKeyStore opens '~/.near-credentials', asking for the passphrase of encrypted keys.
+func defaultKeyStore() *keystore.Store {
+	store := keystore.DefaultStore()
+	store.Passphrase = func() (string, error) {
+		if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+			return passphrase, nil
+		}
+		return readSecret("🔒 Key passphrase: ")
+	}
+	return store
+}
+
+// readSecret reads a line from stdin without echo when attached to a terminal.
+func readSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		secret, err := term.ReadPassword(fd)
+		fmt.Println()
+		return strings.TrimSpace(string(secret)), err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// newPassphrase asks for a passphrase twice when encrypt is set.
+func newPassphrase(encrypt bool) (string, error) {
+	if !encrypt {
+		return "", nil
+	}
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	passphrase, err := readSecret("🔒 New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New(ErrEmptyPassphrase)
+	}
+	confirm, err := readSecret("🔒 Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", errors.New(ErrPassphraseMismatch)
+	}
+	return passphrase, nil
+}
+
+func HandleKeysList(network string) error {
+	store := defaultKeyStore()
+	entries, err := store.List(network)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No keys found in %s\n", store.Dir)
+		return nil
+	}
+
+	for _, e := range entries {
+		lock := ""
+		if e.Encrypted {
+			lock = " 🔒"
+		}
+		fmt.Printf("%-8s %-40s %s%s\n", e.Network, e.AccountID, e.PublicKey, lock)
+	}
+	return nil
+}
+
+func HandleKeysShow(accountID, network string) error {
+	creds, path, err := defaultKeyStore().Credentials(network, accountID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔑 %s\n", creds.PublicKey)
+	fmt.Printf("   File: %s\n", path)
+	return nil
+}
+
+func HandleKeysGenerate(accountID, network string, encrypt, force bool) error {
+	key, err := keystore.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	return saveKey(accountID, network, key, encrypt, force)
+}
+
+// HandleKeysImport stores a key given as seed phrase or private key. When neither
+// is passed on the command line it is read from stdin so it stays out of the shell history.
+func HandleKeysImport(accountID, network, seedPhrase, privateKey string, encrypt, force bool) error {
+	if seedPhrase != "" && privateKey != "" {
+		return errors.New(ErrSeedPhraseAndPrivateKey)
+	}
+
+	secret := privateKey
+	if secret == "" {
+		secret = seedPhrase
+	}
+	if secret == "" {
+		var err error
+		if secret, err = readSecret("🔑 Seed phrase or private key: "); err != nil {
+			return err
+		}
+	}
+
+	var key *keystore.KeyPair
+	var err error
+	if strings.HasPrefix(secret, "ed25519:") {
+		key, err = keystore.ParsePrivateKey(secret)
+	} else {
+		key, err = keystore.ParseSeedPhrase(secret)
+	}
+	if err != nil {
+		return err
+	}
+	return saveKey(accountID, network, key, encrypt, force)
+}
+
+func saveKey(accountID, network string, key *keystore.KeyPair, encrypt, force bool) error {
+	passphrase, err := newPassphrase(encrypt)
+	if err != nil {
+		return err
+	}
+	path, err := defaultKeyStore().Save(network, accountID, key, passphrase, force)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔑 %s\n", key.PublicKey)
+	fmt.Printf("✅ Key for %s saved to %s\n", accountID, path)
+	return nil
+}
+
+func HandleKeysExport(accountID, network string) error {
+	key, err := defaultKeyStore().Load(network, accountID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(key.PrivateKeyString())
+	return nil
+}
+
+func HandleKeysDelete(accountID, network string, yes bool) error {
+	if !yes {
+		fmt.Printf("⚠️  Delete every key of %s on %s? This cannot be undone. [y/N]: ", accountID, network)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			return errors.New(ErrAborted)
+		}
+	}
+
+	removed, err := defaultKeyStore().Delete(network, accountID)
+	if err != nil {
+		return err
+	}
+	for _, path := range removed {
+		fmt.Printf("🗑️  Removed %s\n", path)
+	}
+	return nil
+}
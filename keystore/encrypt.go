@@ -0,0 +1,95 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	ErrWrongPassphrase = "(KEYSTORE_ERROR): wrong passphrase or corrupted key"
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// EncryptedKey holds a private key sealed with AES-256-GCM under a scrypt-derived key.
+type EncryptedKey struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+func encryptPrivateKey(privateKey, passphrase string) (*EncryptedKey, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedKey{
+		KDF:        "scrypt",
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Cipher:     "aes-256-gcm",
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, []byte(privateKey), nil)),
+	}, nil
+}
+
+func (e *EncryptedKey) decrypt(passphrase string) (string, error) {
+	if e.KDF != "scrypt" || e.Cipher != "aes-256-gcm" {
+		return "", fmt.Errorf("%s: unsupported encryption %s/%s", ErrInvalidCredentials, e.KDF, e.Cipher)
+	}
+	salt, err1 := base64.StdEncoding.DecodeString(e.Salt)
+	nonce, err2 := base64.StdEncoding.DecodeString(e.Nonce)
+	ciphertext, err3 := base64.StdEncoding.DecodeString(e.Ciphertext)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return "", fmt.Errorf("%s: %w", ErrInvalidCredentials, err)
+	}
+
+	gcm, err := newGCM(passphrase, salt, e.N, e.R, e.P)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return "", fmt.Errorf("%s: invalid nonce", ErrInvalidCredentials)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New(ErrWrongPassphrase)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidCredentials, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
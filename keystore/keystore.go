@@ -0,0 +1,342 @@
+// Package keystore manages NEAR access keys in the legacy '~/.near-credentials'
+// layout shared with near-cli and near-cli-rs. Keys may optionally be encrypted
+// with a passphrase, such files are only readable by near-go.
+package keystore
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	CredentialsDirName = ".near-credentials"
+
+	ed25519Prefix = "ed25519:"
+)
+
+const (
+	ErrInvalidKey         = "(KEYSTORE_ERROR): invalid key"
+	ErrKeyNotFound        = "(KEYSTORE_ERROR): no key found"
+	ErrInvalidCredentials = "(KEYSTORE_ERROR): invalid credentials file"
+	ErrKeyExists          = "(KEYSTORE_ERROR): a key already exists"
+	ErrPassphraseRequired = "(KEYSTORE_ERROR): key is encrypted and no passphrase is available"
+)
+
+type KeyType byte
+
+const KeyTypeED25519 KeyType = 0
+
+type PublicKey struct {
+	Type KeyType
+	Data []byte
+}
+
+// ParsePublicKey parses the 'ed25519:<base58>' form used by NEAR.
+func ParsePublicKey(s string) (PublicKey, error) {
+	if !strings.HasPrefix(s, ed25519Prefix) {
+		return PublicKey{}, fmt.Errorf("%s: unsupported key type in '%s'", ErrInvalidKey, s)
+	}
+	data, err := Base58Decode(strings.TrimPrefix(s, ed25519Prefix))
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("%s: %w", ErrInvalidKey, err)
+	}
+	if len(data) != ed25519.PublicKeySize {
+		return PublicKey{}, fmt.Errorf("%s: public key must be %d bytes, got %d", ErrInvalidKey, ed25519.PublicKeySize, len(data))
+	}
+	return PublicKey{Type: KeyTypeED25519, Data: data}, nil
+}
+
+func (k PublicKey) String() string {
+	return ed25519Prefix + Base58Encode(k.Data)
+}
+
+type KeyPair struct {
+	PublicKey  PublicKey
+	PrivateKey ed25519.PrivateKey
+}
+
+func NewKeyPair(privateKey ed25519.PrivateKey) *KeyPair {
+	public := privateKey.Public().(ed25519.PublicKey)
+	return &KeyPair{
+		PublicKey:  PublicKey{Type: KeyTypeED25519, Data: []byte(public)},
+		PrivateKey: privateKey,
+	}
+}
+
+// ParsePrivateKey accepts 'ed25519:<base58>' holding either the 64 byte expanded
+// key written by NEAR tools or a bare 32 byte seed.
+func ParsePrivateKey(s string) (*KeyPair, error) {
+	if !strings.HasPrefix(s, ed25519Prefix) {
+		return nil, fmt.Errorf("%s: unsupported private key type", ErrInvalidKey)
+	}
+	data, err := Base58Decode(strings.TrimPrefix(s, ed25519Prefix))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidKey, err)
+	}
+
+	switch len(data) {
+	case ed25519.SeedSize:
+		return NewKeyPair(ed25519.NewKeyFromSeed(data)), nil
+	case ed25519.PrivateKeySize:
+		privateKey := ed25519.NewKeyFromSeed(data[:ed25519.SeedSize])
+		if string(privateKey[ed25519.SeedSize:]) != string(data[ed25519.SeedSize:]) {
+			return nil, fmt.Errorf("%s: private key does not match its embedded public key", ErrInvalidKey)
+		}
+		return NewKeyPair(privateKey), nil
+	}
+	return nil, fmt.Errorf("%s: private key must be %d or %d bytes, got %d", ErrInvalidKey, ed25519.SeedSize, ed25519.PrivateKeySize, len(data))
+}
+
+func GenerateKeyPair() (*KeyPair, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyPair(privateKey), nil
+}
+
+func (k *KeyPair) PrivateKeyString() string {
+	return ed25519Prefix + Base58Encode(k.PrivateKey)
+}
+
+func (k *KeyPair) Sign(message []byte) []byte {
+	return ed25519.Sign(k.PrivateKey, message)
+}
+
+// Credentials is the JSON file format of the legacy keychain. Encrypted files
+// carry EncryptedPrivateKey instead of PrivateKey.
+type Credentials struct {
+	AccountID           string        `json:"account_id"`
+	PublicKey           string        `json:"public_key"`
+	PrivateKey          string        `json:"private_key,omitempty"`
+	EncryptedPrivateKey *EncryptedKey `json:"encrypted_private_key,omitempty"`
+}
+
+func (c *Credentials) Encrypted() bool {
+	return c.EncryptedPrivateKey != nil
+}
+
+// KeyPair parses the private key and checks it against the stored public key.
+// passphrase is only called for encrypted credentials.
+func (c *Credentials) KeyPair(passphrase func() (string, error)) (*KeyPair, error) {
+	privateKey := c.PrivateKey
+	if c.Encrypted() {
+		if passphrase == nil {
+			return nil, errors.New(ErrPassphraseRequired)
+		}
+		secret, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if privateKey, err = c.EncryptedPrivateKey.decrypt(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	keyPair, err := ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if c.PublicKey != "" && c.PublicKey != keyPair.PublicKey.String() {
+		return nil, fmt.Errorf("%s: public key %s does not match private key", ErrInvalidCredentials, c.PublicKey)
+	}
+	return keyPair, nil
+}
+
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return CredentialsDirName
+	}
+	return filepath.Join(home, CredentialsDirName)
+}
+
+// Store is a credentials directory laid out as '<dir>/<network>/<account>.json'.
+// near-cli-rs may also keep several keys per account in '<dir>/<network>/<account>/<public key>.json'.
+type Store struct {
+	Dir string
+
+	// Passphrase is asked for when an encrypted key has to be decrypted.
+	Passphrase func() (string, error)
+}
+
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+func DefaultStore() *Store {
+	return NewStore(DefaultDir())
+}
+
+func (s *Store) accountPath(network, accountID string) string {
+	return filepath.Join(s.Dir, network, accountID+".json")
+}
+
+// Entry describes a stored key without decrypting it.
+type Entry struct {
+	Network   string
+	AccountID string
+	PublicKey string
+	Encrypted bool
+	Path      string
+}
+
+// List returns the stored keys of network, or of every network when network is empty.
+func (s *Store) List(network string) ([]Entry, error) {
+	networks := []string{network}
+	if network == "" {
+		dirs, err := os.ReadDir(s.Dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		networks = networks[:0]
+		for _, d := range dirs {
+			if d.IsDir() {
+				networks = append(networks, d.Name())
+			}
+		}
+	}
+
+	var entries []Entry
+	for _, net := range networks {
+		paths, _ := filepath.Glob(filepath.Join(s.Dir, net, "*.json"))
+		nested, _ := filepath.Glob(filepath.Join(s.Dir, net, "*", "*.json"))
+		for _, path := range append(paths, nested...) {
+			creds, err := readCredentials(path)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, Entry{
+				Network:   net,
+				AccountID: creds.AccountID,
+				PublicKey: creds.PublicKey,
+				Encrypted: creds.Encrypted(),
+				Path:      path,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Network != entries[j].Network {
+			return entries[i].Network < entries[j].Network
+		}
+		return entries[i].AccountID < entries[j].AccountID
+	})
+	return entries, nil
+}
+
+// Credentials returns the stored credentials of accountID without decrypting them.
+func (s *Store) Credentials(network, accountID string) (*Credentials, string, error) {
+	path := s.accountPath(network, accountID)
+	if _, err := os.Stat(path); err != nil {
+		matches, _ := filepath.Glob(filepath.Join(s.Dir, network, accountID, "*.json"))
+		if len(matches) == 0 {
+			return nil, "", fmt.Errorf("%s: for '%s' in %s", ErrKeyNotFound, accountID, filepath.Join(s.Dir, network))
+		}
+		sort.Strings(matches)
+		path = matches[0]
+	}
+
+	creds, err := readCredentials(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return creds, path, nil
+}
+
+// Load returns the key pair of accountID on network.
+func (s *Store) Load(network, accountID string) (*KeyPair, error) {
+	creds, _, err := s.Credentials(network, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return creds.KeyPair(s.Passphrase)
+}
+
+// Save writes the key of accountID to '<dir>/<network>/<account>.json', encrypted
+// when passphrase is not empty. Existing keys are only replaced with overwrite.
+func (s *Store) Save(network, accountID string, key *KeyPair, passphrase string, overwrite bool) (string, error) {
+	path := s.accountPath(network, accountID)
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("%s: for '%s' at %s", ErrKeyExists, accountID, path)
+	}
+
+	creds := Credentials{
+		AccountID: accountID,
+		PublicKey: key.PublicKey.String(),
+	}
+	if passphrase == "" {
+		creds.PrivateKey = key.PrivateKeyString()
+	} else {
+		encrypted, err := encryptPrivateKey(key.PrivateKeyString(), passphrase)
+		if err != nil {
+			return "", err
+		}
+		creds.EncryptedPrivateKey = encrypted
+	}
+
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Delete removes every stored key of accountID on network.
+func (s *Store) Delete(network, accountID string) ([]string, error) {
+	var removed []string
+	path := s.accountPath(network, accountID)
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+
+	dir := filepath.Join(s.Dir, network, accountID)
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, match := range matches {
+		if err := os.Remove(match); err != nil {
+			return removed, err
+		}
+		removed = append(removed, match)
+	}
+	if len(matches) > 0 {
+		os.Remove(dir)
+	}
+
+	if len(removed) == 0 {
+		return nil, fmt.Errorf("%s: for '%s' in %s", ErrKeyNotFound, accountID, filepath.Join(s.Dir, network))
+	}
+	return removed, nil
+}
+
+func readCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidCredentials, err)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", ErrInvalidCredentials, path, err)
+	}
+	if creds.PrivateKey == "" && creds.EncryptedPrivateKey == nil {
+		return nil, fmt.Errorf("%s: %s: %w", ErrInvalidCredentials, path, errors.New("missing private_key"))
+	}
+	return &creds, nil
+}
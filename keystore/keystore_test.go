@@ -0,0 +1,207 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBase58(t *testing.T) {
+	tests := []struct {
+		hex     string
+		encoded string
+	}{
+		{"", ""},
+		{"00", "1"},
+		{"0000287fb4cd", "11233QC4"},
+		{hex.EncodeToString([]byte("Hello World!")), "2NEpo7TZRRrLZSi2U"},
+	}
+
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		if result := Base58Encode(data); result != tt.encoded {
+			t.Errorf("Base58Encode(%s) = %q; want %q", tt.hex, result, tt.encoded)
+		}
+		decoded, err := Base58Decode(tt.encoded)
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Errorf("Base58Decode(%q) = %x, %v; want %s", tt.encoded, decoded, err, tt.hex)
+		}
+	}
+
+	if _, err := Base58Decode("0OIl"); err == nil {
+		t.Errorf("Expected error for characters outside the alphabet")
+	}
+}
+
+// RFC 8032 section 7.1, TEST 2.
+const (
+	rfcSeed      = "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb"
+	rfcPublicKey = "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c"
+	rfcSignature = "92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00"
+)
+
+func TestParsePrivateKey(t *testing.T) {
+	seed, _ := hex.DecodeString(rfcSeed)
+	public, _ := hex.DecodeString(rfcPublicKey)
+
+	for _, raw := range [][]byte{seed, append(append([]byte{}, seed...), public...)} {
+		keyPair, err := ParsePrivateKey("ed25519:" + Base58Encode(raw))
+		if err != nil {
+			t.Fatalf("ParsePrivateKey failed: %v", err)
+		}
+		if !bytes.Equal(keyPair.PublicKey.Data, public) {
+			t.Errorf("Public key = %x; want %s", keyPair.PublicKey.Data, rfcPublicKey)
+		}
+		if hex.EncodeToString(keyPair.Sign([]byte{0x72})) != rfcSignature {
+			t.Errorf("Signature does not match RFC 8032 test vector")
+		}
+	}
+
+	corrupted := append(append([]byte{}, seed...), make([]byte, 32)...)
+	if _, err := ParsePrivateKey("ed25519:" + Base58Encode(corrupted)); err == nil {
+		t.Errorf("Expected error for mismatched embedded public key")
+	}
+	if _, err := ParsePrivateKey("secp256k1:abc"); err == nil {
+		t.Errorf("Expected error for unsupported key type")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	public, _ := hex.DecodeString(rfcPublicKey)
+	s := "ed25519:" + Base58Encode(public)
+
+	key, err := ParsePublicKey(s)
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	if key.String() != s {
+		t.Errorf("String() = %q; want %q", key.String(), s)
+	}
+	if _, err := ParsePublicKey("ed25519:" + Base58Encode(public[:31])); err == nil {
+		t.Errorf("Expected error for short public key")
+	}
+}
+
+func writeCredentials(t *testing.T, path string, creds string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(creds), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_Load(t *testing.T) {
+	seed, _ := hex.DecodeString(rfcSeed)
+	public, _ := hex.DecodeString(rfcPublicKey)
+	publicKey := "ed25519:" + Base58Encode(public)
+	privateKey := "ed25519:" + Base58Encode(append(seed, public...))
+
+	dir := t.TempDir()
+	store := NewStore(dir)
+	writeCredentials(t, filepath.Join(dir, "testnet", "alice.testnet.json"),
+		`{"account_id":"alice.testnet","public_key":"`+publicKey+`","private_key":"`+privateKey+`"}`)
+	writeCredentials(t, filepath.Join(dir, "testnet", "bob.testnet", publicKey+".json"),
+		`{"account_id":"bob.testnet","public_key":"`+publicKey+`","private_key":"`+privateKey+`"}`)
+	writeCredentials(t, filepath.Join(dir, "testnet", "eve.testnet.json"),
+		`{"account_id":"eve.testnet","public_key":"ed25519:11111111111111111111111111111111","private_key":"`+privateKey+`"}`)
+
+	for _, account := range []string{"alice.testnet", "bob.testnet"} {
+		keyPair, err := store.Load("testnet", account)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", account, err)
+		}
+		if keyPair.PublicKey.String() != publicKey {
+			t.Errorf("Load(%s) returned the wrong key", account)
+		}
+	}
+
+	if _, err := store.Load("mainnet", "alice.testnet"); err == nil {
+		t.Errorf("Expected error for missing network")
+	}
+	if _, err := store.Load("testnet", "eve.testnet"); err == nil {
+		t.Errorf("Expected error for mismatched public key")
+	}
+}
+
+func TestStore_SaveListDelete(t *testing.T) {
+	store := NewStore(t.TempDir())
+	key, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair failed: %v", err)
+	}
+
+	path, err := store.Save("testnet", "alice.testnet", key, "", false)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected key file with 0600 permissions, got %v", info.Mode())
+	}
+	if _, err := store.Save("testnet", "alice.testnet", key, "", false); err == nil {
+		t.Errorf("Expected error when overwriting an existing key")
+	}
+	if _, err := store.Save("mainnet", "alice.near", key, "", false); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	entries, err := store.List("")
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("List returned %d entries: %v", len(entries), err)
+	}
+	if entries[0].Network != "mainnet" || entries[1].AccountID != "alice.testnet" || entries[1].PublicKey != key.PublicKey.String() {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+	if entries, _ := store.List("testnet"); len(entries) != 1 {
+		t.Errorf("Expected a single testnet entry, got %+v", entries)
+	}
+
+	if _, err := store.Delete("testnet", "alice.testnet"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Load("testnet", "alice.testnet"); err == nil {
+		t.Errorf("Expected deleted key to be gone")
+	}
+	if _, err := store.Delete("testnet", "alice.testnet"); err == nil {
+		t.Errorf("Expected error when deleting a missing key")
+	}
+}
+
+func TestStore_Encrypted(t *testing.T) {
+	store := NewStore(t.TempDir())
+	key, _ := GenerateKeyPair()
+
+	path, err := store.Save("testnet", "alice.testnet", key, "correct horse", false)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte(key.PrivateKeyString())) || bytes.Contains(data, []byte(`"private_key"`)) {
+		t.Errorf("Encrypted key file must not contain the private key")
+	}
+
+	entries, _ := store.List("testnet")
+	if len(entries) != 1 || !entries[0].Encrypted {
+		t.Errorf("Expected encrypted entry, got %+v", entries)
+	}
+
+	if _, err := store.Load("testnet", "alice.testnet"); err == nil {
+		t.Errorf("Expected error without a passphrase callback")
+	}
+
+	store.Passphrase = func() (string, error) { return "wrong", nil }
+	if _, err := store.Load("testnet", "alice.testnet"); err == nil || err.Error() != ErrWrongPassphrase {
+		t.Errorf("Expected wrong passphrase error, got %v", err)
+	}
+
+	store.Passphrase = func() (string, error) { return "correct horse", nil }
+	loaded, err := store.Load("testnet", "alice.testnet")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.PrivateKeyString() != key.PrivateKeyString() {
+		t.Errorf("Decrypted key does not match the saved key")
+	}
+}
@@ -0,0 +1,56 @@
+package keystore
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// NEAR wallets derive the account key at m/44'/397'/0' (397 is NEAR's SLIP-44 coin type).
+var nearDerivationPath = []uint32{44, 397, 0}
+
+const hardenedOffset = 0x80000000
+
+// ParseSeedPhrase derives the key pair NEAR wallets use for a BIP39 seed phrase.
+// The words are not checked against the BIP39 word list, matching near-seed-phrase.
+func ParseSeedPhrase(phrase string) (*KeyPair, error) {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) < 12 {
+		return nil, fmt.Errorf("%s: seed phrase must have at least 12 words, got %d", ErrInvalidKey, len(words))
+	}
+	seed := mnemonicToSeed(strings.Join(words, " "), "")
+	key, _ := deriveSLIP10(seed, nearDerivationPath)
+	return NewKeyPair(ed25519.NewKeyFromSeed(key)), nil
+}
+
+// mnemonicToSeed implements the BIP39 seed derivation.
+func mnemonicToSeed(mnemonic, passphrase string) []byte {
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+}
+
+// deriveSLIP10 derives an ed25519 private key and chain code along a path of
+// hardened indexes (SLIP-0010 only defines hardened derivation for ed25519).
+func deriveSLIP10(seed []byte, path []uint32) (key, chainCode []byte) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode = sum[:32], sum[32:]
+
+	for _, index := range path {
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index+hardenedOffset)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return key, chainCode
+}
@@ -0,0 +1,56 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestMnemonicToSeed(t *testing.T) {
+	// BIP39 reference vector with passphrase "TREZOR".
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	expected := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+
+	if seed := hex.EncodeToString(mnemonicToSeed(mnemonic, "TREZOR")); seed != expected {
+		t.Errorf("mnemonicToSeed = %s; want %s", seed, expected)
+	}
+}
+
+func TestDeriveSLIP10(t *testing.T) {
+	// SLIP-0010 test vector 1 for ed25519.
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path      []uint32
+		key       string
+		chainCode string
+	}{
+		{nil, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", "90046a93de5380a72b5e45010748567d5ea02bbf6522f979e05c0d8d8ca9fffb"},
+		{[]uint32{0}, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", "8b59aa11380b624e81507a27fedda59fea6d0b779a778918a2fd3590e16e9c69"},
+	}
+
+	for _, tt := range tests {
+		key, chainCode := deriveSLIP10(seed, tt.path)
+		if hex.EncodeToString(key) != tt.key || hex.EncodeToString(chainCode) != tt.chainCode {
+			t.Errorf("deriveSLIP10(%v) = %x, %x; want %s, %s", tt.path, key, chainCode, tt.key, tt.chainCode)
+		}
+	}
+}
+
+func TestParseSeedPhrase(t *testing.T) {
+	phrase := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	keyPair, err := ParseSeedPhrase(phrase)
+	if err != nil {
+		t.Fatalf("ParseSeedPhrase failed: %v", err)
+	}
+	normalized, err := ParseSeedPhrase("  ABANDON abandon abandon abandon abandon abandon\n abandon abandon abandon abandon abandon about ")
+	if err != nil {
+		t.Fatalf("ParseSeedPhrase failed: %v", err)
+	}
+	if keyPair.PublicKey.String() != normalized.PublicKey.String() {
+		t.Errorf("Seed phrase should be normalized before derivation")
+	}
+
+	if _, err := ParseSeedPhrase("too short phrase"); err == nil {
+		t.Errorf("Expected error for short seed phrase")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/urfave/cli"
+	"github.com/vlmoon99/near-cli-go/sim"
 )
 
 func main() {
@@ -20,6 +21,9 @@ func main() {
 			{Name: "Github : vlmoon99, Telegram : @vlmoon99"},
 		},
 		Description: "A comprehensive toolchain for scaffolding, building, testing, and deploying NEAR smart contracts written in Go. It utilizes TinyGo for WASM compilation and an annotation-based code generator for boilerplate reduction.",
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "config", Usage: "Project config file (default: the nearest " + ProjectConfigFileName + ")"},
+		},
 		Commands: []cli.Command{
 			{
 				Name:  "create",
@@ -29,7 +33,7 @@ func main() {
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "project-name, p", Required: true, Usage: "Name of the project folder to create"},
 					&cli.StringFlag{Name: "module-name, m", Required: true, Usage: "Go module name (e.g., github.com/user/project)"},
-					&cli.StringFlag{Name: "project-type, t", Required: true, Usage: "Type of project (e.g., smart-contract-empty)"},
+					&cli.StringFlag{Name: "project-type, t", Required: true, Usage: "Type of project: smart-contract-empty, ft (NEP-141), nft (NEP-171), multisig or dao"},
 				},
 				Action: func(c *cli.Context) error {
 					if c.String("project-name") == "" || c.String("module-name") == "" {
@@ -44,15 +48,34 @@ func main() {
 				Description: `Executes the full build pipeline using Comment Directives:
    
    1. Scans for @contract annotations:
-      - @contract:state: Identifies the main state struct (Only 1 allowed).
+      - @contract:state: Identifies the main state struct (Only 1 allowed). Add 'serializer=borsh' to store it as Borsh instead of JSON.
       - @contract:init: Marks the initialization method (Only 1 allowed).
       - @contract:view: Read-only method. Compatible with promise_callback.
       - @contract:mutating: Modifies state. Compatible with payable and promise_callback.
       - @contract:payable: Accepts attached NEAR.
       - @contract:promise_callback: Handles async promise results. Must be combined with 'view' or 'mutating'.
+      - @contract:migrate: Takes the previous state type, rewrites stored state. Only callable by the contract account.
+        Use 'from=json' or 'from=borsh' when the previous state used a different serializer.
+      - Methods accept 'args=borsh' and 'result=borsh' options to exchange Borsh payloads instead of JSON.
 
    2. Generates 'generated_build.go' with JSON logic and SDK glue code.
-   3. Compiles using TinyGo to WASM.`,
+   3. Compiles using TinyGo to WASM.
+   4. Writes the NEAR ABI ('abi.json') describing every exported method next to the WASM.
+
+   Step 3 is skipped when the sources, go.mod/go.sum, TinyGo version and flags match a build
+   in ~/.near-go/cache. Use --no-cache to force compilation and 'near-go cache clean' to empty it.
+
+   With --reproducible the bundled TinyGo compiles a canonical copy of the module with -trimpath and
+   SOURCE_DATE_EPOCH=0, a NEP-330 'contract_source_metadata' view is generated from the git checkout
+   and 'build-info.json' records the source hash, toolchain, flags and WASM hashes.
+
+   Defaults for --source, --output, --optimize and the TinyGo -opt, -gc, -scheduler, -tags and
+   -ldflags options are read from the [build] table of near-go.toml.
+
+   With --all every contract of the workspace is built in parallel into 'out/<name>.wasm': the folders
+   listed in 'contracts' of the [workspace] table, or every folder with a @contract:state struct.
+
+   With --watch the build reruns whenever a Go file, go.mod or go.sum of the module changes.`,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "source, s",
@@ -68,26 +91,148 @@ func main() {
 						Name:  "keep-generated, k",
 						Usage: "Keep the intermediate 'generated_build.go' file for inspection/debugging",
 					},
+					&cli.BoolFlag{
+						Name:  "no-cache",
+						Usage: "Always run TinyGo instead of reusing a cached build from ~/.near-go/cache",
+					},
+					&cli.BoolFlag{
+						Name:  "reproducible",
+						Usage: "Build byte-for-byte reproducibly, embed NEP-330 source metadata and write build-info.json",
+					},
+					&cli.BoolFlag{
+						Name:  "optimize",
+						Usage: "Remove unannotated exports and dead code, run wasm-opt if found, strip custom sections and report sizes",
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Build every contract of the workspace into out/<name>.wasm ([workspace] in near-go.toml)",
+					},
+					&cli.IntFlag{
+						Name:  "jobs, j",
+						Usage: "Contracts built in parallel with --all (default: [workspace] jobs or the CPU count)",
+					},
+					watchFlag,
+				},
+				Action: func(c *cli.Context) error {
+					config, err := projectConfig(c)
+					if err != nil {
+						return err
+					}
+					opts := config.BuildOptions()
+					opts.KeepGenerated = c.Bool("keep-generated")
+					opts.NoCache = c.Bool("no-cache")
+					opts.Reproducible = c.Bool("reproducible")
+					opts.Optimize = opts.Optimize || c.Bool("optimize")
+					source := flagOrConfig(c, "source", config.Build.Source)
+					build := func() error {
+						return HandleBuild(source, flagOrConfig(c, "output", config.Build.Output), opts)
+					}
+					if c.Bool("all") {
+						if source, err = config.Dir(); err != nil {
+							return err
+						}
+						build = func() error { return HandleBuildAll(config, opts, c.Int("jobs")) }
+					}
+					if !c.Bool("watch") {
+						return build()
+					}
+					w, err := newWatcher(source, false)
+					if err != nil {
+						return err
+					}
+					return watchUntilInterrupted(w, "Build", build)
+				},
+			},
+			{
+				Name:  "verify",
+				Usage: "Check that a deployed contract was built from these sources",
+				Description: "Rebuilds the sources with 'build --reproducible' and compares the sha256 of the WASM with the " +
+					"code hash of the account. Check out the commit recorded in the contract's build-info.json or " +
+					"'contract_source_metadata' first.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "contract-id, id", Usage: "Account the contract is deployed to (default: [deploy] account)"},
+					&cli.StringFlag{Name: "network, n", Usage: "Network ID (testnet, mainnet) or RPC URL (default: [deploy] network)"},
+					&cli.StringFlag{Name: "source, s", Value: "./", Usage: "Contract sources"},
+					&cli.BoolFlag{Name: "optimize", Usage: "Rebuild with --optimize, as recorded in build-info.json"},
+				},
+				Action: func(c *cli.Context) error {
+					config, err := projectConfig(c)
+					if err != nil {
+						return err
+					}
+					id, net := flagOrConfig(c, "contract-id", config.Deploy.Account), flagOrConfig(c, "network", config.Deploy.Network)
+					if id == "" || net == "" {
+						return errors.New(ErrProvidedNetworkAndContractId)
+					}
+					opts := config.BuildOptions()
+					opts.Optimize = opts.Optimize || c.Bool("optimize")
+					return HandleVerify(id, net, flagOrConfig(c, "source", config.Build.Source), opts)
+				},
+			},
+			{
+				Name:      "size",
+				Usage:     "Report the size of a WASM file by section and function",
+				ArgsUsage: "[file.wasm]",
+				Description: "Prints the size of every section and of the largest functions, the storage staking needed for " +
+					"the code and the difference with the previous 'build --optimize' (saved in size.json) or with --base.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "base", Usage: "WASM file to compare against"},
+					&cli.IntFlag{Name: "top", Value: DefaultSizeReportTop, Usage: "Number of functions to list"},
 				},
 				Action: func(c *cli.Context) error {
-					return HandleBuild(c.String("source"), c.String("output"), c.Bool("keep-generated"))
+					file := c.Args().First()
+					if file == "" {
+						config, err := projectConfig(c)
+						if err != nil {
+							return err
+						}
+						if file = config.Build.Output; file == "" {
+							file = "main.wasm"
+						}
+					}
+					return HandleSize(file, c.String("base"), c.Int("top"))
+				},
+			},
+			{
+				Name:  "cache",
+				Usage: "Manage the build cache",
+				Description: "Builds are cached in ~/.near-go/cache, keyed by the hash of the module's Go files, the generated code, " +
+					"go.mod/go.sum, the TinyGo version and the build flags.",
+				Subcommands: []cli.Command{
+					{
+						Name:   "clean",
+						Usage:  "Remove all cached builds",
+						Action: func(c *cli.Context) error { return HandleCacheClean() },
+					},
 				},
 			},
 			{
 				Name:  "test",
 				Usage: "Run contract unit tests using TinyGo",
 				Description: "Executes Go tests using the TinyGo compiler to simulate the WASM environment constraints. " +
-					"This ensures dependencies and logic are compatible with the strict requirements of the NEAR runtime.",
+					"This ensures dependencies and logic are compatible with the strict requirements of the NEAR runtime. " +
+					"With --watch the tests rerun whenever a Go file of the module changes.",
+				Flags: []cli.Flag{watchFlag},
 				Subcommands: []cli.Command{
 					{
 						Name:   "project",
 						Usage:  "Run tests recursively for the entire project (./...)",
-						Action: func(c *cli.Context) error { return HandleTests("project") },
+						Flags:  []cli.Flag{watchFlag},
+						Action: func(c *cli.Context) error { return runTests(c, "project") },
 					},
 					{
 						Name:   "package",
 						Usage:  "Run tests only for the current directory (./)",
-						Action: func(c *cli.Context) error { return HandleTests("package") },
+						Flags:  []cli.Flag{watchFlag},
+						Action: func(c *cli.Context) error { return runTests(c, "package") },
+					},
+					{
+						Name:  "sim",
+						Usage: "Build main.wasm and run the simulation tests in ./sim",
+						Description: "Runs 'go test -tags sim ./sim/...' against the compiled contract. The tests load it with " +
+							"sim.Load(sim.DefaultWasmPath()) and call its exports in-process with the NEAR host functions.",
+						Flags:  []cli.Flag{watchFlag},
+						Action: func(c *cli.Context) error { return runTests(c, "sim") },
 					},
 				},
 			},
@@ -113,6 +258,24 @@ func main() {
 							return HandleCreateAccount(net, name)
 						},
 					},
+					{
+						Name:  "view",
+						Usage: "Show balance, storage usage and code hash of an account",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "account-id, id", Required: true, Usage: "Account ID to look up"},
+							&cli.StringFlag{Name: "network, n", Required: true, Usage: "Network ID (testnet, mainnet) or RPC URL"},
+						},
+						Action: func(c *cli.Context) error {
+							id, net := c.String("account-id"), c.String("network")
+							if id == "" {
+								return errors.New(ErrProvidedAccountId)
+							}
+							if net == "" {
+								return errors.New(ErrProvidedNetwork)
+							}
+							return HandleViewAccount(id, net)
+						},
+					},
 					{
 						Name:  "import",
 						Usage: "Import an existing account via private key",
@@ -122,20 +285,203 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "keys",
+				Usage: "Manage access keys stored in ~/.near-credentials",
+				Description: "Reads and writes the legacy keychain layout '~/.near-credentials/<network>/<account>.json' used by near-cli. " +
+					"Keys can be encrypted with a passphrase (--encrypt); encrypted files are only readable by near-go. " +
+					"Set " + PassphraseEnv + " to unlock encrypted keys without a prompt.",
+				Subcommands: []cli.Command{
+					{
+						Name:  "list",
+						Usage: "List stored keys",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "network, n", Usage: "Only list keys of this network"},
+						},
+						Action: func(c *cli.Context) error {
+							return HandleKeysList(c.String("network"))
+						},
+					},
+					{
+						Name:  "show",
+						Usage: "Show the public key of an account",
+						Flags: keyAccountFlags(),
+						Action: func(c *cli.Context) error {
+							id, net, err := keyAccount(c)
+							if err != nil {
+								return err
+							}
+							return HandleKeysShow(id, net)
+						},
+					},
+					{
+						Name:  "generate",
+						Usage: "Generate a new ed25519 key for an account",
+						Flags: append(keyAccountFlags(),
+							&cli.BoolFlag{Name: "encrypt", Usage: "Encrypt the private key with a passphrase"},
+							&cli.BoolFlag{Name: "force", Usage: "Overwrite an existing key"},
+						),
+						Action: func(c *cli.Context) error {
+							id, net, err := keyAccount(c)
+							if err != nil {
+								return err
+							}
+							return HandleKeysGenerate(id, net, c.Bool("encrypt"), c.Bool("force"))
+						},
+					},
+					{
+						Name:  "import",
+						Usage: "Import a key from a seed phrase or private key (prompted when neither flag is given)",
+						Flags: append(keyAccountFlags(),
+							&cli.StringFlag{Name: "seed-phrase", Usage: "BIP39 seed phrase, derived at m/44'/397'/0'"},
+							&cli.StringFlag{Name: "private-key", Usage: "Private key in the form ed25519:..."},
+							&cli.BoolFlag{Name: "encrypt", Usage: "Encrypt the private key with a passphrase"},
+							&cli.BoolFlag{Name: "force", Usage: "Overwrite an existing key"},
+						),
+						Action: func(c *cli.Context) error {
+							id, net, err := keyAccount(c)
+							if err != nil {
+								return err
+							}
+							return HandleKeysImport(id, net, c.String("seed-phrase"), c.String("private-key"), c.Bool("encrypt"), c.Bool("force"))
+						},
+					},
+					{
+						Name:  "export",
+						Usage: "Print the private key of an account",
+						Flags: keyAccountFlags(),
+						Action: func(c *cli.Context) error {
+							id, net, err := keyAccount(c)
+							if err != nil {
+								return err
+							}
+							return HandleKeysExport(id, net)
+						},
+					},
+					{
+						Name:  "delete",
+						Usage: "Delete the stored keys of an account",
+						Flags: append(keyAccountFlags(),
+							&cli.BoolFlag{Name: "yes, y", Usage: "Do not ask for confirmation"},
+						),
+						Action: func(c *cli.Context) error {
+							id, net, err := keyAccount(c)
+							if err != nil {
+								return err
+							}
+							return HandleKeysDelete(id, net, c.Bool("yes"))
+						},
+					},
+				},
+			},
 			{
 				Name:  "deploy",
 				Usage: "Deploy a compiled WASM contract",
+				Description: "Sends DeployContract and, when an init method is known, the init FunctionCall in one transaction, " +
+					"so nobody can initialize the contract in between. The @contract:init method is detected from --source " +
+					"and only called when the account has no contract yet.",
 				Flags: []cli.Flag{
-					&cli.StringFlag{Name: "contract-id, id", Required: true, Usage: "Account ID to deploy the contract to"},
-					&cli.StringFlag{Name: "network, n", Required: true, Usage: "Network ID (testnet, mainnet)"},
+					&cli.StringFlag{Name: "contract-id, id", Usage: "Account ID to deploy the contract to (default: [deploy] account)"},
+					&cli.StringFlag{Name: "network, n", Usage: "Network ID (testnet, mainnet) (default: [deploy] network)"},
 					&cli.StringFlag{Name: "file, f", Usage: "Path to WASM file", Value: "main.wasm"},
+					&cli.StringFlag{Name: "init-method", Usage: "Method called in the same transaction as the deployment (default: the @contract:init method, on first deploy)"},
+					&cli.StringFlag{Name: "init-args", Value: "{}", Usage: "JSON arguments of the init call"},
+					&cli.StringFlag{Name: "init-deposit", Value: "0 NEAR", Usage: "Deposit attached to the init call"},
+					&cli.StringFlag{Name: "init-gas", Value: "100 Tgas", Usage: "Prepaid gas of the init call"},
+					&cli.BoolFlag{Name: "no-init", Usage: "Deploy without any init call"},
+					&cli.StringFlag{Name: "source, s", Value: "./", Usage: "Contract sources used to detect the @contract:init method"},
 				},
 				Action: func(c *cli.Context) error {
-					id, net := c.String("contract-id"), c.String("network")
+					config, err := projectConfig(c)
+					if err != nil {
+						return err
+					}
+					id, net := flagOrConfig(c, "contract-id", config.Deploy.Account), flagOrConfig(c, "network", config.Deploy.Network)
 					if id == "" || net == "" {
 						return errors.New(ErrProvidedNetworkAndContractId)
 					}
-					return HandleDeployContract(id, net)
+					return HandleDeployContract(id, net, flagOrConfig(c, "file", config.Build.Output), DeployInit{
+						Method:   c.String("init-method"),
+						Args:     c.String("init-args"),
+						Deposit:  c.String("init-deposit"),
+						Gas:      c.String("init-gas"),
+						Source:   flagOrConfig(c, "source", config.Build.Source),
+						Disabled: c.Bool("no-init"),
+					})
+				},
+			},
+			{
+				Name:  "profile",
+				Usage: "Measure the gas and storage of a contract method locally",
+				Description: "Builds the contract with debug names, runs the method in the in-process simulator and reports " +
+					"the gas burnt per category (loading, compute, json, storage_read, storage_write, log, promise, host), " +
+					"the storage bytes added and removed and the resulting storage staking cost. The contract is first " +
+					"initialized with the @contract:init method unless --no-init is given. Promises are not executed.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "method, function", Required: true, Usage: "Method to profile (Go name or export name)"},
+					&cli.StringFlag{Name: "args", Value: "{}", Usage: "JSON arguments string"},
+					&cli.StringFlag{Name: "deposit", Value: "0 NEAR", Usage: "Deposit attached to the call"},
+					&cli.StringFlag{Name: "gas", Value: "300 Tgas", Usage: "Prepaid gas"},
+					&cli.StringFlag{Name: "predecessor", Value: sim.DefaultPredecessor, Usage: "Account calling the method"},
+					&cli.StringFlag{Name: "source, s", Value: "./", Usage: "Contract sources"},
+					&cli.StringFlag{Name: "file, f", Usage: "Profile a prebuilt WASM instead of building the sources (JSON gas needs a build without -no-debug)"},
+					&cli.StringFlag{Name: "init-method", Usage: "Method called before profiling (default: the @contract:init method)"},
+					&cli.StringFlag{Name: "init-args", Value: "{}", Usage: "JSON arguments of the init call"},
+					&cli.StringFlag{Name: "init-deposit", Value: "0 NEAR", Usage: "Deposit attached to the init call"},
+					&cli.StringFlag{Name: "init-gas", Value: "300 Tgas", Usage: "Prepaid gas of the init call"},
+					&cli.BoolFlag{Name: "no-init", Usage: "Profile an uninitialized contract"},
+				},
+				Action: func(c *cli.Context) error {
+					config, err := projectConfig(c)
+					if err != nil {
+						return err
+					}
+					return HandleProfile(ProfileOptions{
+						Method:      c.String("method"),
+						Args:        c.String("args"),
+						Deposit:     c.String("deposit"),
+						Gas:         c.String("gas"),
+						Predecessor: c.String("predecessor"),
+						Source:      flagOrConfig(c, "source", config.Build.Source),
+						File:        c.String("file"),
+						TinyGo:      config.Build.TinyGoOptions,
+						Init: DeployInit{
+							Method:   c.String("init-method"),
+							Args:     c.String("init-args"),
+							Deposit:  c.String("init-deposit"),
+							Gas:      c.String("init-gas"),
+							Disabled: c.Bool("no-init"),
+						},
+					})
+				},
+			},
+			{
+				Name:  "view",
+				Usage: "Call a read-only method without signing a transaction",
+				Description: "Runs an RPC 'call_function' query and prints the JSON result and logs. No gas or signer is needed. " +
+					"Methods the ABI lists as 'call' are refused: the one passed with --abi, or the project's 'abi.json' when the contract is the [deploy] account.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "contract, to", Usage: "Contract Account ID (default: [deploy] account)"},
+					&cli.StringFlag{Name: "method, function", Required: true, Usage: "View method name"},
+					&cli.StringFlag{Name: "args", Value: "{}", Usage: "JSON arguments string"},
+					&cli.StringFlag{Name: "network, n", Usage: "Network ID (testnet, mainnet) or RPC URL (default: [deploy] network)"},
+					&cli.StringFlag{Name: "block-id", Usage: "Block height or hash to query at"},
+					&cli.StringFlag{Name: "finality", Usage: "'final' (default) or 'optimistic'"},
+					&cli.StringFlag{Name: "abi", Usage: "ABI file used to reject mutating methods (default: ./abi.json if present and the contract is the [deploy] account)"},
+				},
+				Action: func(c *cli.Context) error {
+					contract, net, err := contractAndNetwork(c)
+					if err != nil {
+						return err
+					}
+					config, err := projectConfig(c)
+					if err != nil {
+						return err
+					}
+					return HandleViewFunction(
+						contract, c.String("method"), c.String("args"), net,
+						c.String("block-id"), c.String("finality"), viewABIPath(c.String("abi"), contract, config.Deploy.Account),
+					)
 				},
 			},
 			{
@@ -143,18 +489,22 @@ func main() {
 				Usage: "Invoke a method on a smart contract",
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "signer, from", Required: true, Usage: "Account ID signing the transaction"},
-					&cli.StringFlag{Name: "contract, to", Required: true, Usage: "Contract Account ID"},
+					&cli.StringFlag{Name: "contract, to", Usage: "Contract Account ID (default: [deploy] account)"},
 					&cli.StringFlag{Name: "method, function", Required: true, Usage: "Method name to invoke"},
 					&cli.StringFlag{Name: "args", Value: "{}", Usage: "JSON arguments string"},
-					&cli.StringFlag{Name: "gas", Value: "100 Tgas", Usage: "Prepaid gas"},
-					&cli.StringFlag{Name: "deposit", Value: "0 NEAR", Usage: "Attached deposit"},
-					&cli.StringFlag{Name: "network", Required: true, Usage: "Network ID"},
+					&cli.StringFlag{Name: "gas", Value: "100 Tgas", Usage: "Prepaid gas (e.g. 100 Tgas, max 300 Tgas)"},
+					&cli.StringFlag{Name: "deposit", Value: "0 NEAR", Usage: "Attached deposit (e.g. 1 NEAR, 1 yoctoNEAR)"},
+					&cli.StringFlag{Name: "network", Usage: "Network ID (default: [deploy] network)"},
 				},
 				Action: func(c *cli.Context) error {
+					contract, net, err := contractAndNetwork(c)
+					if err != nil {
+						return err
+					}
 					return HandleCallFunction(
-						c.String("signer"), c.String("contract"),
+						c.String("signer"), contract,
 						c.String("method"), c.String("args"),
-						c.String("gas"), c.String("deposit"), c.String("network"),
+						c.String("gas"), c.String("deposit"), net,
 					)
 				},
 			},
@@ -165,3 +515,51 @@ func main() {
 		fmt.Printf("Error: %v\n", err)
 	}
 }
+
+// watchFlag is accepted by 'build' and by 'test' before or after the test type.
+var watchFlag = &cli.BoolFlag{Name: "watch, w", Usage: "Run again whenever a Go file of the module changes"}
+
+// runTests runs a 'test' subcommand with the project config.
+func runTests(c *cli.Context, testType string) error {
+	config, err := projectConfig(c)
+	if err != nil {
+		return err
+	}
+	if !c.Bool("watch") && !c.GlobalBool("watch") {
+		return HandleTests(testType, config)
+	}
+	w, err := newWatcher(".", true)
+	if err != nil {
+		return err
+	}
+	return watchUntilInterrupted(w, "Tests", func() error { return HandleTests(testType, config) })
+}
+
+// contractAndNetwork reads --contract and --network, defaulting to the [deploy]
+// table of the project config.
+func contractAndNetwork(c *cli.Context) (string, string, error) {
+	config, err := projectConfig(c)
+	if err != nil {
+		return "", "", err
+	}
+	contract, net := flagOrConfig(c, "contract", config.Deploy.Account), flagOrConfig(c, "network", config.Deploy.Network)
+	if contract == "" || net == "" {
+		return "", "", errors.New(ErrProvidedContractAndNetwork)
+	}
+	return contract, net, nil
+}
+
+func keyAccountFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "account-id, id", Required: true, Usage: "Account ID the key belongs to"},
+		&cli.StringFlag{Name: "network, n", Required: true, Usage: "Network ID (testnet, mainnet)"},
+	}
+}
+
+func keyAccount(c *cli.Context) (string, string, error) {
+	id, net := c.String("account-id"), c.String("network")
+	if id == "" || net == "" {
+		return "", "", errors.New(ErrProvidedAccountIdAndNetwork)
+	}
+	return id, net, nil
+}
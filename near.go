@@ -1,10 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/vlmoon99/near-cli-go/rpc"
+	"github.com/vlmoon99/near-cli-go/transaction"
 )
 
 func runNearCLI(args ...string) error {
@@ -18,13 +28,167 @@ func runNearCLI(args ...string) error {
 	return nil
 }
 
-func HandleDeployContract(id, network string) error {
-	args := []string{
-		"contract", "deploy", id, "use-file", "./main.wasm",
-		"without-init-call", "network-config", network,
-		"sign-with-legacy-keychain", "send",
+// newSender prepares a signer for accountID using its key from '~/.near-credentials'.
+func newSender(accountID, network string) (*transaction.Sender, error) {
+	client, err := rpc.NewClientForNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+	key, err := defaultKeyStore().Load(network, accountID)
+	if err != nil {
+		return nil, err
+	}
+	return transaction.NewSender(client, accountID, key), nil
+}
+
+// printOutcome reports the logs and result of a finished transaction, returning
+// the execution failure if there was one.
+func printOutcome(outcome *rpc.FinalExecutionOutcome) error {
+	fmt.Printf("🔗 Transaction: %s\n", outcome.TransactionOutcome.ID)
+	for _, log := range outcome.Logs() {
+		fmt.Printf("   📜 %s\n", log)
+	}
+	if err := outcome.Status.Err(); err != nil {
+		return err
 	}
-	return runNearCLI(args...)
+	if value, err := outcome.Status.Value(); err == nil && len(value) > 0 {
+		fmt.Printf("   ↩️  %s\n", value)
+	}
+	fmt.Printf("⛽ Gas burnt: %d\n", outcome.TotalGasBurnt())
+	return nil
+}
+
+// emptyCodeHash is the code hash of accounts without a contract.
+const emptyCodeHash = "11111111111111111111111111111111"
+
+// DeployInit describes the initialization call batched with a deployment.
+type DeployInit struct {
+	Method   string
+	Args     string
+	Deposit  string
+	Gas      string
+	Source   string
+	Disabled bool
+}
+
+func HandleDeployContract(id, network, file string, init DeployInit) error {
+	code, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+
+	// The sources are optional: a prebuilt WASM can be deployed from anywhere.
+	// A contract that is there must scan, or init detection and the export
+	// check would be skipped without notice.
+	contract, err := scanContractIfPresent(init.Source)
+	if err != nil {
+		return fmt.Errorf("code generation failed for %s, needed to check the WASM and detect @contract:init: %w", init.Source, err)
+	}
+
+	fmt.Printf("🔍 Validating %s...\n", file)
+	if err := checkContractWasm(code, contract); err != nil {
+		return err
+	}
+
+	sender, err := newSender(id, network)
+	if err != nil {
+		return err
+	}
+
+	actions := []transaction.Action{transaction.DeployContract{Code: code}}
+	initCall, err := resolveInitCall(sender.Client, id, contract, init)
+	if err != nil {
+		return err
+	}
+	if initCall != nil {
+		actions = append(actions, *initCall)
+		fmt.Printf("🚀 Deploying %d bytes to %s on %s and calling %s...\n", len(code), id, network, initCall.MethodName)
+	} else {
+		fmt.Printf("🚀 Deploying %d bytes to %s on %s...\n", len(code), id, network)
+	}
+
+	outcome, err := sender.Send(context.Background(), id, actions...)
+	if err != nil {
+		return err
+	}
+	if err := printOutcome(outcome); err != nil {
+		return err
+	}
+	fmt.Println("✅ Contract deployed")
+	return nil
+}
+
+// resolveInitCall returns the FunctionCall to batch after DeployContract, or nil.
+// An explicit init method is always called. Otherwise the @contract:init method
+// found in the sources is only called on accounts without a contract, since the
+// generated init panics once state exists and would revert a redeploy.
+func resolveInitCall(client *rpc.Client, id string, contract *ContractInfo, init DeployInit) (*transaction.FunctionCall, error) {
+	if init.Disabled {
+		if init.Method != "" {
+			return nil, errors.New(ErrInitMethodAndNoInit)
+		}
+		return nil, nil
+	}
+
+	method := init.Method
+	if method != "" {
+		method = methodExportName(contract, method)
+	} else {
+		detected := detectInitMethod(contract)
+		if detected == "" {
+			return nil, nil
+		}
+		account, err := client.ViewAccount(context.Background(), id, rpc.FinalityFinal())
+		if err != nil && !rpc.IsUnknownAccount(err) {
+			return nil, err
+		}
+		if account != nil && account.CodeHash != emptyCodeHash {
+			fmt.Printf("ℹ️  %s already has a contract, skipping init '%s' (pass --init-method to call it anyway)\n", id, detected)
+			return nil, nil
+		}
+		fmt.Printf("🧩 Detected @contract:init method '%s'\n", detected)
+		method = detected
+	}
+
+	if !json.Valid([]byte(init.Args)) {
+		return nil, fmt.Errorf("%s: %s", ErrInvalidArgsJSON, init.Args)
+	}
+	gas, err := transaction.ParseGas(init.Gas)
+	if err != nil {
+		return nil, err
+	}
+	deposit, err := transaction.ParseNearAmount(init.Deposit)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction.FunctionCall{MethodName: method, Args: []byte(init.Args), Gas: gas, Deposit: deposit}, nil
+}
+
+// detectInitMethod returns the export name of the @contract:init method, if any.
+func detectInitMethod(contract *ContractInfo) string {
+	if contract == nil {
+		return ""
+	}
+	for _, m := range contract.Methods {
+		if m.IsInit && m.IsExported() {
+			return toSnakeCase(m.Name)
+		}
+	}
+	return ""
+}
+
+// methodExportName maps a Go method name like 'InitContract' to its WASM export.
+// Names that are not methods of the scanned contract are used as given.
+func methodExportName(contract *ContractInfo, method string) string {
+	if contract == nil {
+		return method
+	}
+	for _, m := range contract.Methods {
+		if m.Name == method && m.IsExported() {
+			return toSnakeCase(m.Name)
+		}
+	}
+	return method
 }
 
 func HandleCreateAccount(network, name string) error {
@@ -36,17 +200,157 @@ func HandleCreateAccount(network, name string) error {
 		"network-config", "testnet", "create")
 }
 
+func HandleViewAccount(accountID, network string) error {
+	client, err := rpc.NewClientForNetwork(network)
+	if err != nil {
+		return err
+	}
+
+	account, err := client.ViewAccount(context.Background(), accountID, rpc.FinalityFinal())
+	if err != nil {
+		if rpc.IsUnknownAccount(err) {
+			return fmt.Errorf("%s: '%s' on %s", ErrAccountNotFound, accountID, network)
+		}
+		return err
+	}
+
+	fmt.Printf("👤 Account %s (%s, block #%d)\n", accountID, network, account.BlockHeight)
+	fmt.Printf("   Balance:       %s NEAR\n", formatYoctoNear(account.Amount))
+	fmt.Printf("   Locked:        %s NEAR\n", formatYoctoNear(account.Locked))
+	fmt.Printf("   Storage usage: %d bytes\n", account.StorageUsage)
+	fmt.Printf("   Code hash:     %s\n", account.CodeHash)
+	return nil
+}
+
 func HandleImportAccount() error {
 	return runNearCLI("account", "import-account")
 }
 
 func HandleCallFunction(signer, contract, method, args, gas, deposit, network string) error {
-	cmd := []string{
-		"contract", "call-function", "as-transaction", contract, method,
-		"json-args", args, "prepaid-gas", gas, "attached-deposit", deposit,
-		"sign-as", signer, "network-config", network,
-		"sign-with-keychain", "send",
+	gasAmount, err := transaction.ParseGas(gas)
+	if err != nil {
+		return err
 	}
+	depositAmount, err := transaction.ParseNearAmount(deposit)
+	if err != nil {
+		return err
+	}
+
+	sender, err := newSender(signer, network)
+	if err != nil {
+		return err
+	}
+
 	fmt.Printf("📞 Calling %s on %s...\n", method, contract)
-	return runNearCLI(cmd...)
-}
\ No newline at end of file
+	outcome, err := sender.Send(context.Background(), contract, transaction.FunctionCall{
+		MethodName: method,
+		Args:       []byte(args),
+		Gas:        gasAmount,
+		Deposit:    depositAmount,
+	})
+	if err != nil {
+		return err
+	}
+	return printOutcome(outcome)
+}
+
+// HandleViewFunction runs a read-only call_function query, no signer or gas needed.
+func HandleViewFunction(contract, method, args, network, blockID, finality, abiPath string) error {
+	if !json.Valid([]byte(args)) {
+		return fmt.Errorf("%s: %s", ErrInvalidArgsJSON, args)
+	}
+	block, err := blockReference(blockID, finality)
+	if err != nil {
+		return err
+	}
+	if err := checkViewMethod(method, abiPath); err != nil {
+		return err
+	}
+
+	client, err := rpc.NewClientForNetwork(network)
+	if err != nil {
+		return err
+	}
+	result, err := client.CallFunction(context.Background(), contract, method, []byte(args), block)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("👀 %s.%s (block #%d)\n", contract, method, result.BlockHeight)
+	for _, log := range result.Logs {
+		fmt.Printf("   📜 %s\n", log)
+	}
+	fmt.Println(formatViewResult(result.Result))
+	return nil
+}
+
+func blockReference(blockID, finality string) (rpc.BlockReference, error) {
+	if blockID != "" && finality != "" {
+		return nil, errors.New(ErrBlockIdAndFinality)
+	}
+	if blockID != "" {
+		if height, err := strconv.ParseUint(blockID, 10, 64); err == nil {
+			return rpc.BlockHeight(height), nil
+		}
+		return rpc.BlockHash(blockID), nil
+	}
+	switch finality {
+	case "", "final":
+		return rpc.FinalityFinal(), nil
+	case "optimistic":
+		return rpc.FinalityOptimistic(), nil
+	}
+	return nil, fmt.Errorf("%s: '%s'", ErrInvalidFinality, finality)
+}
+
+// viewABIPath returns the ABI to check view calls against: the one passed with
+// --abi, else the abi.json in the working directory when the contract is the
+// [deploy] account of the project. That ABI says nothing about other contracts.
+func viewABIPath(abiPath, contract, deployAccount string) string {
+	if abiPath != "" || deployAccount == "" || contract != deployAccount {
+		return abiPath
+	}
+	if _, err := os.Stat(AbiFileName); err != nil {
+		return ""
+	}
+	return AbiFileName
+}
+
+// checkViewMethod refuses methods the ABI at abiPath lists as 'call'. Without an
+// ABI nothing is checked.
+func checkViewMethod(method, abiPath string) error {
+	if abiPath == "" {
+		return nil
+	}
+
+	abi, err := readABI(abiPath)
+	if err != nil {
+		return err
+	}
+	fn := abi.Function(method)
+	if fn == nil {
+		fmt.Printf("⚠️  Method '%s' is not listed in %s\n", method, abiPath)
+		return nil
+	}
+	if fn.Kind != "view" {
+		return fmt.Errorf("%s: '%s' modifies state, use 'near-go call'", ErrNotViewMethod, method)
+	}
+	return nil
+}
+
+// formatViewResult pretty-prints JSON results, falling back to text or hex for other payloads.
+func formatViewResult(result []byte) string {
+	if len(result) == 0 {
+		return "(no result)"
+	}
+	if json.Valid(result) {
+		var out bytes.Buffer
+		if err := json.Indent(&out, result, "", "  "); err == nil {
+			return out.String()
+		}
+	}
+	if utf8.Valid(result) {
+		return string(result)
+	}
+	return "0x" + hex.EncodeToString(result)
+}
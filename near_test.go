@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+	"github.com/vlmoon99/near-cli-go/rpc"
+)
+
+func TestBlockReference(t *testing.T) {
+	tests := []struct {
+		blockID  string
+		finality string
+		expected rpc.BlockReference
+		wantErr  bool
+	}{
+		{"", "", rpc.FinalityFinal(), false},
+		{"", "optimistic", rpc.FinalityOptimistic(), false},
+		{"12345", "", rpc.BlockHeight(12345), false},
+		{"9Xg7Ww", "", rpc.BlockHash("9Xg7Ww"), false},
+		{"1", "final", nil, true},
+		{"", "doomslug", nil, true},
+	}
+
+	for _, tt := range tests {
+		result, err := blockReference(tt.blockID, tt.finality)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("blockReference(%q, %q) = %v, %v; want %v, error=%v", tt.blockID, tt.finality, result, err, tt.expected, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatViewResult(t *testing.T) {
+	tests := []struct {
+		input    []byte
+		expected string
+	}{
+		{[]byte(`{"a":1}`), "{\n  \"a\": 1\n}"},
+		{[]byte(`"hello"`), `"hello"`},
+		{[]byte("plain"), "plain"},
+		{[]byte{0xff, 0x00}, "0xff00"},
+		{nil, "(no result)"},
+	}
+
+	for _, tt := range tests {
+		if result := formatViewResult(tt.input); result != tt.expected {
+			t.Errorf("formatViewResult(%q) = %q; want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestCheckViewMethod(t *testing.T) {
+	contractCode := `
+package main
+
+// @contract:state
+type Contract struct {}
+
+// @contract:view
+func (c *Contract) GetCount() int { return 0 }
+
+// @contract:mutating
+func (c *Contract) Increment() {}
+`
+	abi := generateTestABI(t, contractCode)
+	data, _ := json.Marshal(abi)
+	abiPath := filepath.Join(t.TempDir(), AbiFileName)
+	os.WriteFile(abiPath, data, 0644)
+
+	if err := checkViewMethod("get_count", abiPath); err != nil {
+		t.Errorf("View method should be allowed: %v", err)
+	}
+	if err := checkViewMethod("increment", abiPath); err == nil || !strings.Contains(err.Error(), ErrNotViewMethod) {
+		t.Errorf("Expected mutating method to be refused, got %v", err)
+	}
+	if err := checkViewMethod("unknown", abiPath); err != nil {
+		t.Errorf("Methods missing from the ABI should not be refused: %v", err)
+	}
+	if err := checkViewMethod("increment", filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Expected error for an explicit ABI path that does not exist")
+	}
+	if err := checkViewMethod("increment", ""); err != nil {
+		t.Errorf("Nothing should be checked without an ABI: %v", err)
+	}
+}
+
+func TestViewABIPath(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if path := viewABIPath("", "app.testnet", "app.testnet"); path != "" {
+		t.Errorf("Expected no ABI without abi.json, got %q", path)
+	}
+	os.WriteFile(AbiFileName, []byte("{}"), 0644)
+
+	tests := []struct {
+		name, abiPath, contract, deployAccount, want string
+	}{
+		{"deploy account", "", "app.testnet", "app.testnet", AbiFileName},
+		{"other contract", "", "other.testnet", "app.testnet", ""},
+		{"no project account", "", "app.testnet", "", ""},
+		{"explicit abi", "other.json", "other.testnet", "app.testnet", "other.json"},
+	}
+	for _, tt := range tests {
+		if path := viewABIPath(tt.abiPath, tt.contract, tt.deployAccount); path != tt.want {
+			t.Errorf("%s: viewABIPath() = %q, want %q", tt.name, path, tt.want)
+		}
+	}
+}
+
+func TestHandleViewFunction(t *testing.T) {
+	var params map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params map[string]interface{} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":{"result":[52,50],"logs":[],"block_height":7,"block_hash":"h"}}`))
+	}))
+	defer server.Close()
+
+	if err := HandleViewFunction("counter.testnet", "get_count", `{"x":1}`, server.URL, "", "optimistic", ""); err != nil {
+		t.Fatalf("HandleViewFunction failed: %v", err)
+	}
+	if params["request_type"] != "call_function" || params["method_name"] != "get_count" || params["finality"] != "optimistic" || params["args_base64"] != "eyJ4IjoxfQ==" {
+		t.Errorf("Unexpected query params: %v", params)
+	}
+
+	if err := HandleViewFunction("counter.testnet", "get_count", `{bad`, server.URL, "", "", ""); err == nil {
+		t.Errorf("Expected error for invalid JSON args")
+	}
+}
+
+// deployTestEnv prepares a contract project in the working directory, a signing key
+// in a temporary HOME and a fake RPC node. It returns the RPC URL and a pointer to
+// the last broadcast transaction.
+func deployTestEnv(t *testing.T, codeHash string) (string, *[]byte) {
+	dir := setupTestProject(t, `
+package main
+
+// @contract:state
+type Contract struct {}
+
+// @contract:init
+func (c *Contract) InitContract(owner string) {}
+`)
+	os.WriteFile(filepath.Join(dir, "main.wasm"), testWasm([]string{"env.input"}, "init_contract"), 0644)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	var payload []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		result := ""
+		switch {
+		case req.Method == "query" && strings.Contains(string(req.Params), "view_account"):
+			result = `{"amount":"0","locked":"0","code_hash":"` + codeHash + `","storage_usage":0}`
+		case req.Method == "query":
+			result = `{"nonce":1,"permission":"FullAccess"}`
+		case req.Method == "block":
+			result = `{"header":{"height":1,"hash":"244ZQ9cgj3CQ6bWBdytfrJMuMQ1jdXLFGnr4HhvtCTnM"}}`
+		case req.Method == "broadcast_tx_commit":
+			var params []string
+			json.Unmarshal(req.Params, &params)
+			payload, _ = base64.StdEncoding.DecodeString(params[0])
+			result = `{"status":{"SuccessValue":""},"transaction":{},"transaction_outcome":{"id":"tx","outcome":{"status":{}}},"receipts_outcome":[]}`
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":` + result + `}`))
+	}))
+	t.Cleanup(server.Close)
+
+	// Keys are stored per network, here the network is the fake node's URL.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	key, _ := keystore.GenerateKeyPair()
+	if _, err := keystore.NewStore(filepath.Join(home, keystore.CredentialsDirName)).Save(server.URL, "app.testnet", key, "", false); err != nil {
+		t.Fatalf("Failed to save key: %v", err)
+	}
+	return server.URL, &payload
+}
+
+// initCallAction is the start of a Borsh encoded FunctionCall action to init_contract.
+var initCallAction = []byte("\x02\x0d\x00\x00\x00init_contract")
+
+func defaultDeployInit() DeployInit {
+	return DeployInit{Args: "{}", Deposit: "0 NEAR", Gas: "100 Tgas", Source: "./"}
+}
+
+func TestHandleDeployContract_DetectsInit(t *testing.T) {
+	network, payload := deployTestEnv(t, emptyCodeHash)
+
+	init := defaultDeployInit()
+	init.Args = `{"owner":"app.testnet"}`
+	if err := HandleDeployContract("app.testnet", network, "main.wasm", init); err != nil {
+		t.Fatalf("HandleDeployContract failed: %v", err)
+	}
+	if !bytes.Contains(*payload, []byte("\x00asm")) {
+		t.Errorf("Expected the WASM code in the transaction")
+	}
+	if !bytes.Contains(*payload, initCallAction) || !bytes.Contains(*payload, []byte(init.Args)) {
+		t.Errorf("Expected the detected init call to be batched with the deployment")
+	}
+}
+
+func TestHandleDeployContract_SkipsInitOnRedeploy(t *testing.T) {
+	network, payload := deployTestEnv(t, "8Kb3xmJQE2bYkqgbnPHxVbnxEb4iPXxmH8rwgVntS4Ep")
+
+	if err := HandleDeployContract("app.testnet", network, "main.wasm", defaultDeployInit()); err != nil {
+		t.Fatalf("HandleDeployContract failed: %v", err)
+	}
+	if bytes.Contains(*payload, initCallAction) {
+		t.Errorf("Init must not be called when the account already has a contract")
+	}
+
+	init := defaultDeployInit()
+	init.Method = "InitContract"
+	if err := HandleDeployContract("app.testnet", network, "main.wasm", init); err != nil {
+		t.Fatalf("HandleDeployContract failed: %v", err)
+	}
+	if !bytes.Contains(*payload, initCallAction) {
+		t.Errorf("An explicit init method must be called and mapped to its export name")
+	}
+}
+
+func TestHandleDeployContract_InitOptions(t *testing.T) {
+	network, _ := deployTestEnv(t, emptyCodeHash)
+
+	init := defaultDeployInit()
+	init.Method, init.Disabled = "init_contract", true
+	if err := HandleDeployContract("app.testnet", network, "main.wasm", init); err == nil || !strings.Contains(err.Error(), ErrInitMethodAndNoInit) {
+		t.Errorf("Expected error for init-method with no-init, got %v", err)
+	}
+
+	init = defaultDeployInit()
+	init.Args = "{bad"
+	if err := HandleDeployContract("app.testnet", network, "main.wasm", init); err == nil {
+		t.Errorf("Expected error for invalid init args")
+	}
+}
+
+func TestHandleDeployContract_ValidatesWasm(t *testing.T) {
+	network, payload := deployTestEnv(t, emptyCodeHash)
+	os.WriteFile("other.wasm", testWasm([]string{"wasi_snapshot_preview1.fd_write"}), 0644)
+
+	err := HandleDeployContract("app.testnet", network, "other.wasm", defaultDeployInit())
+	if err == nil || !strings.Contains(err.Error(), ErrWasmValidationFailed) {
+		t.Fatalf("Expected validation error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "missing export 'init_contract'") || !strings.Contains(err.Error(), "wasi_snapshot_preview1") {
+		t.Errorf("Expected every problem in the report, got %v", err)
+	}
+	if *payload != nil {
+		t.Errorf("Nothing must be sent when validation fails")
+	}
+}
+
+func TestHandleDeployContract_ContractErrors(t *testing.T) {
+	network, payload := deployTestEnv(t, emptyCodeHash)
+
+	// A broken contract in the source folder is reported, not skipped.
+	os.WriteFile("main.go", []byte("package main\n\n// @contract:state\ntype Contract struct {}\n\n// @contract:init\n// @contract:view\nfunc (c *Contract) InitContract() {}\n"), 0644)
+	err := HandleDeployContract("app.testnet", network, "main.wasm", defaultDeployInit())
+	if err == nil || !strings.Contains(err.Error(), "code generation failed") {
+		t.Fatalf("Expected the scan error, got %v", err)
+	}
+	if *payload != nil {
+		t.Errorf("Nothing must be sent when the contract doesn't scan")
+	}
+
+	// A folder without a contract deploys the WASM as it is.
+	init := defaultDeployInit()
+	init.Source = t.TempDir()
+	if err := HandleDeployContract("app.testnet", network, "main.wasm", init); err != nil {
+		t.Fatalf("HandleDeployContract without sources failed: %v", err)
+	}
+	if bytes.Contains(*payload, initCallAction) {
+		t.Errorf("No init call can be detected without sources")
+	}
+}
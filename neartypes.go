@@ -0,0 +1,477 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// nearType is a NEAR type that crosses the JSON boundary of an exported method
+// as a string, the way near-sdk-rs passes it: U64 and U128 as decimal strings
+// so JavaScript clients keep their precision, account IDs checked against the
+// protocol rules and byte vectors as base64.
+type nearType struct {
+	// parse is the glue helper turning the JSON string into the underlying value.
+	parse string
+	// format renders a value, %s, as the JSON string.
+	format string
+	// base describes the underlying type a type of that name must have.
+	base   string
+	schema JSONSchema
+}
+
+var nearTypes = map[string]nearType{
+	"U128": {
+		parse:  "nearParseU128",
+		format: "types.Uint128(%s).String()",
+		base:   "types.Uint128",
+		schema: JSONSchema{"type": "string", "pattern": "^[0-9]+$"},
+	},
+	"U64": {
+		parse:  "nearParseU64",
+		format: "strconv.FormatUint(uint64(%s), 10)",
+		base:   "uint64",
+		schema: JSONSchema{"type": "string", "pattern": "^[0-9]+$"},
+	},
+	"AccountId": {
+		parse:  "nearParseAccountId",
+		format: "string(%s)",
+		base:   "string",
+		schema: JSONSchema{
+			"type":      "string",
+			"minLength": 2,
+			"maxLength": 64,
+			"pattern":   `^(([a-z\d]+[-_])*[a-z\d]+\.)*([a-z\d]+[-_])*[a-z\d]+$`,
+		},
+	},
+	"Base64VecU8": {
+		parse:  "nearParseBase64",
+		format: "base64.StdEncoding.EncodeToString(%s)",
+		base:   "[]byte",
+		schema: JSONSchema{"type": "string", "contentEncoding": "base64"},
+	},
+}
+
+// nearTypeAliases are the other names the NEAR types go by.
+var nearTypeAliases = map[string]string{
+	"Uint128": "U128",
+	"Balance": "U128",
+}
+
+var namedTypePattern = regexp.MustCompile(`^(?:[A-Za-z_][A-Za-z0-9_]*\.)?([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// lookupNearType returns the NEAR type typeStr is passed as: the SDK's
+// types.Uint128, or a type named U128, Balance, U64, AccountId or Base64VecU8
+// in any package of the contract.
+func lookupNearType(typeStr string) (nearType, bool) {
+	match := namedTypePattern.FindStringSubmatch(typeStr)
+	if match == nil {
+		return nearType{}, false
+	}
+	name := match[1]
+	if name == "Uint128" && typeStr != "types.Uint128" {
+		return nearType{}, false
+	}
+	if alias, ok := nearTypeAliases[name]; ok {
+		name = alias
+	}
+	t, ok := nearTypes[name]
+	return t, ok
+}
+
+// splitNearType returns the NEAR type of a parameter or result type T, *T or []T,
+// and the wrapping prefix.
+func splitNearType(typeStr string) (prefix, elem string, t nearType, ok bool) {
+	prefix, elem = "", typeStr
+	for _, p := range []string{"*", "[]"} {
+		if strings.HasPrefix(typeStr, p) {
+			prefix, elem = p, typeStr[len(p):]
+			break
+		}
+	}
+	t, ok = lookupNearType(elem)
+	return prefix, elem, t, ok
+}
+
+// isNearParam reports whether a JSON parameter is decoded from its NEAR string form.
+func isNearParam(p Param) bool {
+	_, _, _, ok := splitNearType(p.Type)
+	return ok
+}
+
+// nearWireType is the type a NEAR typed value has in the JSON input or output.
+func nearWireType(typeStr string) string {
+	prefix, _, _, _ := splitNearType(typeStr)
+	return prefix + "string"
+}
+
+// nearParamArg is the variable generateNearParamConversion declares for p.
+func nearParamArg(p Param) string {
+	return "arg" + capitalizeFirst(p.Name)
+}
+
+// generateNearParamConversion turns the decoded string form of a NEAR typed
+// parameter, params.<Name>, into a variable of the parameter type.
+func generateNearParamConversion(p Param, indent string) string {
+	prefix, elem, t, _ := splitNearType(p.Type)
+	field, arg := "params."+capitalizeFirst(p.Name), nearParamArg(p)
+	parse := func(s string) string { return fmt.Sprintf("%s(%s(%q, %s))", elem, t.parse, p.Name, s) }
+
+	var sb strings.Builder
+	switch prefix {
+	case "*":
+		sb.WriteString(fmt.Sprintf("%svar %s %s\n", indent, arg, p.Type))
+		sb.WriteString(fmt.Sprintf("%sif %s != nil {\n", indent, field))
+		sb.WriteString(fmt.Sprintf("%s\tvalue := %s\n", indent, parse("*"+field)))
+		sb.WriteString(fmt.Sprintf("%s\t%s = &value\n", indent, arg))
+		sb.WriteString(indent + "}\n")
+	case "[]":
+		sb.WriteString(fmt.Sprintf("%s%s := make(%s, len(%s))\n", indent, arg, p.Type, field))
+		sb.WriteString(fmt.Sprintf("%sfor i, s := range %s {\n", indent, field))
+		sb.WriteString(fmt.Sprintf("%s\t%s[i] = %s\n", indent, arg, parse("s")))
+		sb.WriteString(indent + "}\n")
+	default:
+		sb.WriteString(fmt.Sprintf("%s%s := %s\n", indent, arg, parse(field)))
+	}
+	return sb.String()
+}
+
+// generateNearResult declares resultValue, the string form of a NEAR typed result.
+func generateNearResult(typeStr, indent string) string {
+	prefix, _, t, _ := splitNearType(typeStr)
+
+	var sb strings.Builder
+	switch prefix {
+	case "*":
+		sb.WriteString(indent + "var resultValue *string\n")
+		sb.WriteString(indent + "if result != nil {\n")
+		sb.WriteString(fmt.Sprintf("%s\tvalue := %s\n", indent, fmt.Sprintf(t.format, "*result")))
+		sb.WriteString(indent + "\tresultValue = &value\n")
+		sb.WriteString(indent + "}\n")
+	case "[]":
+		sb.WriteString(indent + "resultValue := make([]string, len(result))\n")
+		sb.WriteString(indent + "for i, v := range result {\n")
+		sb.WriteString(fmt.Sprintf("%s\tresultValue[i] = %s\n", indent, fmt.Sprintf(t.format, "v")))
+		sb.WriteString(indent + "}\n")
+	default:
+		sb.WriteString(fmt.Sprintf("%sresultValue := %s\n", indent, fmt.Sprintf(t.format, "result")))
+	}
+	return sb.String()
+}
+
+var nearHelpers = map[string]string{
+	"nearParseU128": `func nearParseU128(arg, s string) types.Uint128 {
+	v, err := types.U128FromString(s)
+	if err != nil {
+		env.PanicStr("Argument '" + arg + "' must be a U128 decimal string, got '" + s + "': " + err.Error())
+	}
+	return v
+}
+`,
+	"nearParseU64": `func nearParseU64(arg, s string) uint64 {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		env.PanicStr("Argument '" + arg + "' must be a U64 decimal string, got '" + s + "'")
+	}
+	return v
+}
+`,
+	"nearParseAccountId": `func nearParseAccountId(arg, s string) string {
+	problem := ""
+	if len(s) < 2 || len(s) > 64 {
+		problem = "it must be 2 to 64 characters long"
+	}
+	separator := true
+	for i := 0; i < len(s) && problem == ""; i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z' || c >= '0' && c <= '9':
+			separator = false
+		case c == '-' || c == '_' || c == '.':
+			if separator {
+				problem = "'-', '_' and '.' must stand between letters or digits"
+			}
+			separator = true
+		default:
+			problem = "only lowercase letters, digits, '-', '_' and '.' are allowed"
+		}
+	}
+	if problem == "" && separator {
+		problem = "it must end with a letter or digit"
+	}
+	if problem != "" {
+		env.PanicStr("Argument '" + arg + "' is not a valid account ID '" + s + "': " + problem)
+	}
+	return s
+}
+`,
+	"nearParseBase64": `func nearParseBase64(arg, s string) []byte {
+	v, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		env.PanicStr("Argument '" + arg + "' must be a base64 string: " + err.Error())
+	}
+	return v
+}
+`,
+}
+
+// generateNearHelpers returns the parse helpers the generated code calls.
+func generateNearHelpers(generated string) string {
+	var names []string
+	for name := range nearHelpers {
+		if strings.Contains(generated, name+"(") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(nearHelpers[name])
+	}
+	return sb.String()
+}
+
+// nearWireGenerator converts values holding NEAR types below the top level,
+// such as a U128 field of a struct parameter, to and from their JSON form, in
+// which the NEAR types are strings. Every struct type reached gets a wire
+// struct with the same JSON fields and two conversion functions, queued and
+// rendered by structFunctions like the Borsh functions.
+type nearWireGenerator struct {
+	structs   map[string]*StructInfo
+	queued    map[string]bool
+	pending   []string
+	seq       int
+	generated strings.Builder
+}
+
+func newNearWireGenerator(structs map[string]*StructInfo) *nearWireGenerator {
+	return &nearWireGenerator{
+		structs: structs,
+		queued:  make(map[string]bool),
+	}
+}
+
+// needsWire reports whether values of typeStr hold a NEAR type.
+func (g *nearWireGenerator) needsWire(typeStr string) bool {
+	return g.holdsNear(typeStr, map[string]bool{})
+}
+
+func (g *nearWireGenerator) holdsNear(typeStr string, visiting map[string]bool) bool {
+	if _, ok := lookupNearType(typeStr); ok {
+		return true
+	}
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		return g.holdsNear(typeStr[1:], visiting)
+	case strings.HasPrefix(typeStr, "[]"):
+		return g.holdsNear(typeStr[2:], visiting)
+	case isArrayType(typeStr):
+		_, elem := splitArrayType(typeStr)
+		return g.holdsNear(elem, visiting)
+	case strings.HasPrefix(typeStr, "map["):
+		_, value := splitMapType(typeStr)
+		return g.holdsNear(value, visiting)
+	}
+	st, ok := g.structs[typeStr]
+	if !ok || visiting[typeStr] {
+		return false
+	}
+	visiting[typeStr] = true
+	defer delete(visiting, typeStr)
+	for _, field := range st.Fields {
+		if _, _, skip := jsonFieldName(field); skip && !field.Embedded {
+			continue
+		}
+		if g.holdsNear(field.Type, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// wireType is the type a value of typeStr has in the JSON input or output.
+func (g *nearWireGenerator) wireType(typeStr string) string {
+	if !g.needsWire(typeStr) {
+		return typeStr
+	}
+	if _, ok := lookupNearType(typeStr); ok {
+		return "string"
+	}
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		return "*" + g.wireType(typeStr[1:])
+	case strings.HasPrefix(typeStr, "[]"):
+		return "[]" + g.wireType(typeStr[2:])
+	case isArrayType(typeStr):
+		length, elem := splitArrayType(typeStr)
+		return "[" + length + "]" + g.wireType(elem)
+	case strings.HasPrefix(typeStr, "map["):
+		key, value := splitMapType(typeStr)
+		return "map[" + key + "]" + g.wireType(value)
+	}
+	g.queue(typeStr)
+	return "nearWire" + borshFuncSuffix(typeStr)
+}
+
+func (g *nearWireGenerator) queue(name string) {
+	if !g.queued[name] {
+		g.queued[name] = true
+		g.pending = append(g.pending, name)
+	}
+}
+
+func (g *nearWireGenerator) nextVar(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s%d", prefix, g.seq)
+}
+
+// toWire sets dst to src, of type typeStr, in its JSON form.
+func (g *nearWireGenerator) toWire(typeStr, src, dst, indent string) string {
+	return g.convert(typeStr, src, dst, "", indent, true)
+}
+
+// fromWire sets dst to the value of type typeStr decoded from src, its JSON
+// form. arg names the argument in the error of an invalid NEAR string.
+func (g *nearWireGenerator) fromWire(typeStr, src, dst, arg, indent string) string {
+	return g.convert(typeStr, src, dst, arg, indent, false)
+}
+
+func (g *nearWireGenerator) convert(typeStr, src, dst, arg, indent string, toWire bool) string {
+	if !g.needsWire(typeStr) {
+		return fmt.Sprintf("%s%s = %s\n", indent, dst, src)
+	}
+	if t, ok := lookupNearType(typeStr); ok {
+		if toWire {
+			return fmt.Sprintf("%s%s = %s\n", indent, dst, fmt.Sprintf(t.format, src))
+		}
+		return fmt.Sprintf("%s%s = %s(%s(%q, %s))\n", indent, dst, typeStr, t.parse, arg, src)
+	}
+	target := func(typeStr string) string {
+		if toWire {
+			return g.wireType(typeStr)
+		}
+		return typeStr
+	}
+
+	var sb strings.Builder
+	switch {
+	case strings.HasPrefix(typeStr, "*"):
+		v := g.nextVar("v")
+		sb.WriteString(fmt.Sprintf("%sif %s != nil {\n", indent, src))
+		sb.WriteString(fmt.Sprintf("%s\tvar %s %s\n", indent, v, target(typeStr[1:])))
+		sb.WriteString(g.convert(typeStr[1:], "(*"+src+")", v, arg, indent+"\t", toWire))
+		sb.WriteString(fmt.Sprintf("%s\t%s = &%s\n", indent, dst, v))
+		sb.WriteString(indent + "}\n")
+	case strings.HasPrefix(typeStr, "[]"), isArrayType(typeStr):
+		elem, i := typeStr[2:], g.nextVar("i")
+		inner := indent
+		if isArrayType(typeStr) {
+			_, elem = splitArrayType(typeStr)
+		} else {
+			sb.WriteString(fmt.Sprintf("%sif %s != nil {\n", indent, src))
+			sb.WriteString(fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, target(typeStr), src))
+			inner += "\t"
+		}
+		sb.WriteString(fmt.Sprintf("%sfor %s := range %s {\n", inner, i, src))
+		sb.WriteString(g.convert(elem, src+"["+i+"]", dst+"["+i+"]", arg, inner+"\t", toWire))
+		sb.WriteString(inner + "}\n")
+		if inner != indent {
+			sb.WriteString(indent + "}\n")
+		}
+	case strings.HasPrefix(typeStr, "map["):
+		_, value := splitMapType(typeStr)
+		k, v, w := g.nextVar("k"), g.nextVar("v"), g.nextVar("w")
+		sb.WriteString(fmt.Sprintf("%sif %s != nil {\n", indent, src))
+		sb.WriteString(fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, target(typeStr), src))
+		sb.WriteString(fmt.Sprintf("%s\tfor %s, %s := range %s {\n", indent, k, v, src))
+		sb.WriteString(fmt.Sprintf("%s\t\tvar %s %s\n", indent, w, target(value)))
+		sb.WriteString(g.convert(value, v, w, arg, indent+"\t\t", toWire))
+		sb.WriteString(fmt.Sprintf("%s\t\t%s[%s] = %s\n", indent, dst, k, w))
+		sb.WriteString(indent + "\t}\n")
+		sb.WriteString(indent + "}\n")
+	default:
+		g.queue(typeStr)
+		if toWire {
+			sb.WriteString(fmt.Sprintf("%s%s = nearToWire%s(%s)\n", indent, dst, borshFuncSuffix(typeStr), src))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s%s = nearFromWire%s(%s)\n", indent, dst, borshFuncSuffix(typeStr), src))
+		}
+	}
+	return sb.String()
+}
+
+// wireField is a JSON field of a wire struct. Fields of embedded structs are
+// promoted, as encoding/json does, and reached through the embedding struct.
+type wireField struct {
+	name, typ, tag, arg string
+}
+
+func (g *nearWireGenerator) wireFields(name string, st *StructInfo) ([]wireField, error) {
+	var fields []wireField
+	for _, field := range st.Fields {
+		if lookupStructTag(field.Tag, "json") == "-" {
+			continue
+		}
+		jsonName, _, skip := jsonFieldName(field)
+		if field.Embedded && (skip || jsonName == field.Name) {
+			if embedded, ok := g.structs[strings.TrimPrefix(field.Type, "*")]; ok {
+				if strings.HasPrefix(field.Type, "*") && g.needsWire(field.Type) {
+					return nil, fmt.Errorf("field %s.%s: embedded pointers to structs with NEAR types are not supported, embed %s instead", name, field.Name, embedded.Name)
+				}
+				promoted, err := g.wireFields(name, embedded)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, promoted...)
+				continue
+			}
+		}
+		if skip {
+			continue
+		}
+		fields = append(fields, wireField{name: field.Name, typ: field.Type, tag: field.Tag, arg: jsonName})
+	}
+	return fields, nil
+}
+
+// structFunctions renders the wire struct and the conversions of every queued
+// struct. Fields encoding/json skips are left out.
+func (g *nearWireGenerator) structFunctions() (string, error) {
+	for len(g.pending) > 0 {
+		name := g.pending[0]
+		g.pending = g.pending[1:]
+		fields, err := g.wireFields(name, g.structs[name])
+		if err != nil {
+			return "", err
+		}
+
+		suffix := borshFuncSuffix(name)
+		wire := "nearWire" + suffix
+		var decl, to, from strings.Builder
+		decl.WriteString(fmt.Sprintf("type %s struct {\n", wire))
+		to.WriteString(fmt.Sprintf("func nearToWire%s(v %s) %s {\n\tvar w %s\n", suffix, name, wire, wire))
+		from.WriteString(fmt.Sprintf("func nearFromWire%s(w %s) %s {\n\tvar v %s\n", suffix, wire, name, name))
+		for _, field := range fields {
+			if field.tag != "" {
+				decl.WriteString(fmt.Sprintf("\t%s %s `%s`\n", field.name, g.wireType(field.typ), field.tag))
+			} else {
+				decl.WriteString(fmt.Sprintf("\t%s %s\n", field.name, g.wireType(field.typ)))
+			}
+			to.WriteString(g.toWire(field.typ, "v."+field.name, "w."+field.name, "\t"))
+			from.WriteString(g.fromWire(field.typ, "w."+field.name, "v."+field.name, field.arg, "\t"))
+		}
+		decl.WriteString("}\n\n")
+		to.WriteString("\treturn w\n}\n\n")
+		from.WriteString("\treturn v\n}\n\n")
+
+		g.generated.WriteString(decl.String())
+		g.generated.WriteString(to.String())
+		g.generated.WriteString(from.String())
+	}
+	return g.generated.String(), nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const nearTypesContract = `
+package main
+
+import "github.com/vlmoon99/near-sdk-go/types"
+
+type AccountId string
+
+type Balance = types.Uint128
+
+type U64 uint64
+
+type Base64VecU8 []byte
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating
+func (c *Contract) Transfer(receiverId AccountId, amount types.Uint128, memo *Base64VecU8, nonce U64) {}
+
+// @contract:mutating
+func (c *Contract) Allow(ids []AccountId) {}
+
+// @contract:view
+func (c *Contract) BalanceOf(accountId AccountId) (Balance, error) { return Balance{}, nil }
+
+// @contract:view
+func (c *Contract) Nonce() *U64 { return nil }
+
+// @contract:mutating args=borsh
+func (c *Contract) Deposit(amount types.Uint128) {}
+`
+
+func TestGenerateCode_NearTypes(t *testing.T) {
+	dir := setupTestProject(t, nearTypesContract)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	expected := []string{
+		"ReceiverId string `json:\"receiverId\"`",
+		"Amount string `json:\"amount\"`",
+		"Memo *string `json:\"memo\"`",
+		"Nonce string `json:\"nonce\"`",
+		"argReceiverId := AccountId(nearParseAccountId(\"receiverId\", params.ReceiverId))",
+		"argAmount := types.Uint128(nearParseU128(\"amount\", params.Amount))",
+		"value := Base64VecU8(nearParseBase64(\"memo\", *params.Memo))",
+		"argNonce := U64(nearParseU64(\"nonce\", params.Nonce))",
+		"state.Transfer(argReceiverId, argAmount, argMemo, argNonce)",
+		"argIds[i] = AccountId(nearParseAccountId(\"ids\", s))",
+		"resultValue := types.Uint128(result).String()",
+		"value := strconv.FormatUint(uint64(*result), 10)",
+		"encodingJson.Marshal(resultValue)",
+		"func nearParseAccountId(arg, s string) string {",
+		"func nearParseU128(arg, s string) types.Uint128 {",
+		"\"strconv\"",
+		"\"encoding/base64\"",
+		// Borsh arguments keep their binary form.
+		"params.Amount = types.Uint128(argsReader.readU128())",
+		"state.Deposit(params.Amount)",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(generated, exp) {
+			t.Errorf("Expected generated code to contain %q", exp)
+		}
+	}
+}
+
+func TestGenerateABI_NearTypes(t *testing.T) {
+	abi := generateTestABI(t, nearTypesContract)
+
+	transfer := findAbiFunction(abi, "transfer")
+	if transfer == nil || transfer.Params == nil || len(transfer.Params.Args) != 4 {
+		t.Fatalf("Expected transfer with 4 args, got %+v", transfer)
+	}
+	receiver, amount := transfer.Params.Args[0].TypeSchema, transfer.Params.Args[1].TypeSchema
+	if receiver["type"] != "string" || receiver["maxLength"] != float64(64) {
+		t.Errorf("Unexpected account ID schema: %v", receiver)
+	}
+	if amount["type"] != "string" || amount["pattern"] != "^[0-9]+$" {
+		t.Errorf("Unexpected U128 schema: %v", amount)
+	}
+
+	balance := findAbiFunction(abi, "balance_of")
+	if balance == nil || balance.Result == nil || balance.Result.TypeSchema["type"] != "string" {
+		t.Errorf("Expected a string result for balance_of, got %+v", balance)
+	}
+	if nonce := findAbiFunction(abi, "nonce"); nonce == nil || nonce.Result == nil || nonce.Result.TypeSchema["anyOf"] == nil {
+		t.Errorf("Expected an optional string result for nonce, got %+v", nonce)
+	}
+}
+
+func TestLookupNearType(t *testing.T) {
+	tests := map[string]string{
+		"types.Uint128":     "types.Uint128",
+		"Balance":           "types.Uint128",
+		"model.U128":        "types.Uint128",
+		"U64":               "uint64",
+		"types.AccountId":   "string",
+		"Base64VecU8":       "[]byte",
+		"Uint128":           "",
+		"map[string]U64":    "",
+		"AccountIdentifier": "",
+	}
+	for typeStr, base := range tests {
+		near, ok := lookupNearType(typeStr)
+		if ok != (base != "") || near.base != base {
+			t.Errorf("lookupNearType(%q) = %q, %v; want %q", typeStr, near.base, ok, base)
+		}
+	}
+}
+
+func TestScanContract_NearTypeBase(t *testing.T) {
+	dir := setupTestProject(t, `
+package main
+
+type AccountId int
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating
+func (c *Contract) Add(owner AccountId) {}
+`)
+	_, err := ScanContract(dir)
+	if err == nil || !strings.Contains(err.Error(), "method 'Add' parameter 'owner' has type AccountId") ||
+		!strings.Contains(err.Error(), "is not based on string") {
+		t.Errorf("Expected an error about the base type of AccountId, got %v", err)
+	}
+}
+
+const nearNestedContract = `
+package main
+
+import "github.com/vlmoon99/near-sdk-go/types"
+
+type U128 types.Uint128
+
+type AccountId string
+
+type StorageBalance struct {
+	Total     U128            ` + "`json:\"total\"`" + `
+	Available U128            ` + "`json:\"available\"`" + `
+	Owner     *AccountId      ` + "`json:\"owner\"`" + `
+	Next      *StorageBalance ` + "`json:\"next\"`" + `
+}
+
+// @contract:state
+type Contract struct {}
+
+// @contract:view
+func (c *Contract) StorageBalanceOf(accountId AccountId) *StorageBalance { return nil }
+
+// @contract:mutating
+func (c *Contract) Store(balances []StorageBalance, memo string) {}
+`
+
+func TestGenerateCode_NearTypesInStructs(t *testing.T) {
+	dir := setupTestProject(t, nearNestedContract)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	expected := []string{
+		"type nearWireStorageBalance struct {",
+		"Total string `json:\"total\"`",
+		"Owner *string `json:\"owner\"`",
+		"Next *nearWireStorageBalance `json:\"next\"`",
+		"w.Total = types.Uint128(v.Total).String()",
+		"v.Total = U128(nearParseU128(\"total\", w.Total))",
+		"= nearFromWireStorageBalance((*w.Next))",
+		"Balances []nearWireStorageBalance `json:\"balances\"`",
+		"= nearFromWireStorageBalance(params.Balances[i",
+		"state.Store(argBalances, params.Memo)",
+		"var resultValue *nearWireStorageBalance",
+		"encodingJson.Marshal(resultValue)",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(generated, exp) {
+			t.Errorf("Expected generated code to contain %q", exp)
+		}
+	}
+}
+
+func TestGenerateABI_NearTypesInStructs(t *testing.T) {
+	abi := generateTestABI(t, nearNestedContract)
+
+	definitions, _ := abi.Body.RootSchema["definitions"].(map[string]interface{})
+	balance, _ := definitions["StorageBalance"].(map[string]interface{})
+	properties, _ := balance["properties"].(map[string]interface{})
+	total, _ := properties["total"].(map[string]interface{})
+	if total["type"] != "string" || total["pattern"] != "^[0-9]+$" {
+		t.Errorf("Expected a U128 string schema for StorageBalance.total, got %v", balance)
+	}
+}
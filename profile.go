@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/vlmoon99/near-cli-go/sim"
+	"github.com/vlmoon99/near-cli-go/transaction"
+)
+
+// ProfileOptions describes the call measured by 'near-go profile'.
+type ProfileOptions struct {
+	Method      string
+	Args        string
+	Deposit     string
+	Gas         string
+	Predecessor string
+	Source      string
+	// File is a prebuilt WASM. Without it the sources are built with debug names.
+	File string
+	Init DeployInit
+	// TinyGo holds the compiler settings from near-go.toml.
+	TinyGo TinyGoOptions
+}
+
+// HandleProfile runs a method in the local simulator and reports where its gas
+// goes and how much storage it adds or frees.
+func HandleProfile(opts ProfileOptions) error {
+	if !json.Valid([]byte(opts.Args)) {
+		return fmt.Errorf("%s: %s", ErrInvalidArgsJSON, opts.Args)
+	}
+	gas, err := transaction.ParseGas(opts.Gas)
+	if err != nil {
+		return err
+	}
+	deposit, err := transaction.ParseNearAmount(opts.Deposit)
+	if err != nil {
+		return err
+	}
+
+	// A prebuilt WASM may be profiled without sources, but a contract that is
+	// there must scan: it tells views from calls and finds @contract:init.
+	scan := scanContractIfPresent
+	if opts.File == "" {
+		scan = ScanContract
+	}
+	contract, err := scan(opts.Source)
+	if err != nil {
+		return fmt.Errorf("code generation failed: %w", err)
+	}
+
+	file := opts.File
+	if file == "" {
+		tmpDir, err := os.MkdirTemp("", "near-go-profile")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		file = filepath.Join(tmpDir, "main.wasm")
+		if err := HandleBuild(opts.Source, file, BuildOptions{Debug: true, TinyGo: opts.TinyGo}); err != nil {
+			return err
+		}
+	}
+	code, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+
+	c, err := sim.New(code, sim.WithProfiling())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := profileInit(c, contract, opts.Init); err != nil {
+		return err
+	}
+
+	method := methodExportName(contract, opts.Method)
+	call := c.Call
+	if isViewExport(contract, method) {
+		call = c.View
+	}
+	fmt.Printf("📊 Profiling %s...\n", method)
+	result, err := call(method, json.RawMessage(opts.Args),
+		sim.WithPredecessor(opts.Predecessor), sim.WithDeposit(deposit), sim.WithGas(gas))
+	printProfile(result, gas)
+	if err != nil {
+		return err
+	}
+	if len(result.Value) > 0 {
+		fmt.Printf("   ↩️  %s\n", result.Value)
+	}
+	return nil
+}
+
+// profileInit initializes the simulated contract like a deployment would: the
+// explicit init method, else the detected @contract:init method, called by the
+// contract account itself.
+func profileInit(c *sim.Contract, contract *ContractInfo, init DeployInit) error {
+	if init.Disabled {
+		if init.Method != "" {
+			return errors.New(ErrInitMethodAndNoInit)
+		}
+		return nil
+	}
+	method := methodExportName(contract, init.Method)
+	if method == "" {
+		if method = detectInitMethod(contract); method == "" {
+			return nil
+		}
+	}
+	if !json.Valid([]byte(init.Args)) {
+		return fmt.Errorf("%s: %s", ErrInvalidArgsJSON, init.Args)
+	}
+	gas, err := transaction.ParseGas(init.Gas)
+	if err != nil {
+		return err
+	}
+	deposit, err := transaction.ParseNearAmount(init.Deposit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🧩 Initializing with %s...\n", method)
+	if _, err := c.Call(method, json.RawMessage(init.Args),
+		sim.WithPredecessor(c.AccountID), sim.WithDeposit(deposit), sim.WithGas(gas)); err != nil {
+		return fmt.Errorf("%s: %w", ErrProfileInitFailed, err)
+	}
+	return nil
+}
+
+// isViewExport reports whether the export belongs to a @contract:view method.
+func isViewExport(contract *ContractInfo, export string) bool {
+	if contract == nil {
+		return false
+	}
+	for _, m := range contract.Methods {
+		if m.IsExported() && toSnakeCase(m.Name) == export {
+			return m.IsView
+		}
+	}
+	return false
+}
+
+func printProfile(result *sim.Result, prepaid uint64) {
+	if result == nil {
+		return
+	}
+	for _, log := range result.Logs {
+		fmt.Printf("   📜 %s\n", log)
+	}
+
+	fmt.Printf("⛽ Gas burnt: %s of %s\n", formatGas(result.GasBurnt), formatGas(prepaid))
+	for _, category := range sim.Categories {
+		gas := result.Profile.Gas[category]
+		if gas == 0 {
+			continue
+		}
+		fmt.Printf("   %-14s %12s  %5.1f%%\n", category, formatGas(gas), 100*float64(gas)/float64(result.GasBurnt))
+	}
+
+	cost := result.Profile.StorageCost()
+	sign := ""
+	if cost.Sign() < 0 {
+		sign = "-"
+	}
+	fmt.Printf("💾 Storage: +%d bytes, -%d bytes\n", result.Profile.StorageAdded, result.Profile.StorageRemoved)
+	fmt.Printf("💰 Storage staking: %s%s NEAR\n", sign, formatYoctoNear(new(big.Int).Abs(cost).String()))
+	for _, receipt := range result.Receipts {
+		fmt.Printf("   📤 Receipt to %s with %d action(s) (not executed)\n", receipt.ReceiverID, len(receipt.Actions))
+	}
+}
+
+// formatGas renders gas in Tgas with three decimals.
+func formatGas(gas uint64) string {
+	return fmt.Sprintf("%.3f Tgas", float64(gas)/1e12)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A module exporting an empty 'noop' function.
+var noopWasm = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+	0x03, 0x02, 0x01, 0x00,
+	0x07, 0x08, 0x01, 0x04, 'n', 'o', 'o', 'p', 0x00, 0x00,
+	0x0a, 0x04, 0x01, 0x02, 0x00, 0x0b,
+}
+
+func TestHandleProfile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.wasm")
+	if err := os.WriteFile(file, noopWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := ProfileOptions{
+		Method: "noop", Args: "{}", Deposit: "0 NEAR", Gas: "300 Tgas", Predecessor: "alice.near",
+		Source: dir, File: file, Init: DeployInit{Disabled: true},
+	}
+
+	if err := HandleProfile(opts); err != nil {
+		t.Errorf("HandleProfile() failed: %v", err)
+	}
+
+	opts.Method = "missing"
+	if err := HandleProfile(opts); err == nil || !strings.Contains(err.Error(), "MethodNotFound") {
+		t.Errorf("HandleProfile(missing) error = %v", err)
+	}
+
+	opts.Method, opts.Args = "noop", "{"
+	if err := HandleProfile(opts); err == nil || !strings.Contains(err.Error(), ErrInvalidArgsJSON) {
+		t.Errorf("HandleProfile(invalid args) error = %v", err)
+	}
+
+	opts.Args, opts.Init = "{}", DeployInit{Method: "noop", Disabled: true}
+	if err := HandleProfile(opts); err == nil || err.Error() != ErrInitMethodAndNoInit {
+		t.Errorf("HandleProfile(init and no-init) error = %v", err)
+	}
+
+	// Sources that don't scan are reported even with a prebuilt WASM.
+	opts.Init = DeployInit{Disabled: true}
+	broken := "package main\n\n// @contract:state\ntype Contract struct {}\n\n// @contract:init\n// @contract:view\nfunc (c *Contract) Init() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := HandleProfile(opts); err == nil || !strings.Contains(err.Error(), "code generation failed") {
+		t.Errorf("HandleProfile(broken sources) error = %v", err)
+	}
+}
+
+func TestIsViewExport(t *testing.T) {
+	contract := &ContractInfo{Methods: []*MethodInfo{
+		{Name: "ReadData", IsView: true, IsPublic: true},
+		{Name: "WriteData", IsMutating: true, IsPublic: true},
+	}}
+
+	if !isViewExport(contract, "read_data") || isViewExport(contract, "write_data") || isViewExport(nil, "read_data") {
+		t.Error("isViewExport() did not follow the @contract:view annotations")
+	}
+}
@@ -3,10 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
 func HandleCreateProject(projectName, projectType, moduleName string) error {
-	if projectType != SmartContractTypeProject {
+	templateDir, ok := ProjectTemplates[projectType]
+	if !ok {
 		return fmt.Errorf("%s", ErrIncorrectType)
 	}
 
@@ -16,7 +20,7 @@ func HandleCreateProject(projectName, projectType, moduleName string) error {
 		return err
 	}
 
-	if err := initializeSmartContract(moduleName); err != nil {
+	if err := initializeSmartContract(templateDir, moduleName); err != nil {
 		os.Chdir("..")
 		return err
 	}
@@ -24,18 +28,13 @@ func HandleCreateProject(projectName, projectType, moduleName string) error {
 	return nil
 }
 
-func initializeSmartContract(moduleName string) error {
+func initializeSmartContract(templateDir, moduleName string) error {
 	if err := CreateFolderAndNavigate(SmartContractProjectFolder); err != nil {
 		return err
 	}
 
 	fmt.Println("📝 Creating template...")
-	content, err := templates.ReadFile(ContractMainGoPath)
-	if err != nil {
-		return fmt.Errorf("%s %v", ErrToReadFile, err)
-	}
-
-	if err := WriteToFile(ContractMainGoFileName, string(content)); err != nil {
+	if err := writeTemplate(templateDir, "."); err != nil {
 		return err
 	}
 
@@ -61,3 +60,26 @@ func initializeSmartContract(moduleName string) error {
 	fmt.Println("✅ Project created successfully!")
 	return nil
 }
+
+// writeTemplate copies every '*.template' file of templateDir into targetDir without the suffix.
+func writeTemplate(templateDir, targetDir string) error {
+	entries, err := templates.ReadDir(templateDir)
+	if err != nil {
+		return fmt.Errorf("%s %v", ErrToReadFile, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), TemplateFileSuffix) {
+			continue
+		}
+		content, err := templates.ReadFile(path.Join(templateDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("%s %v", ErrToReadFile, err)
+		}
+		target := filepath.Join(targetDir, strings.TrimSuffix(entry.Name(), TemplateFileSuffix))
+		if err := WriteToFile(target, string(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
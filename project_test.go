@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestProjectTemplates(t *testing.T) {
+	tests := []struct {
+		projectType string
+		exports     []string
+	}{
+		{SmartContractTypeProject, []string{"init_contract", "write_data", "read_data"}},
+		{FungibleTokenTypeProject, []string{
+			"new", "ft_transfer", "ft_transfer_call", "ft_resolve_transfer", "ft_total_supply", "ft_balance_of",
+			"ft_metadata", "storage_deposit", "storage_withdraw", "storage_unregister", "storage_balance_bounds",
+			"storage_balance_of",
+		}},
+		{NonFungibleTypeProject, []string{
+			"new", "nft_mint", "nft_transfer", "nft_transfer_call", "nft_resolve_transfer", "nft_token",
+			"nft_metadata", "nft_total_supply", "nft_tokens", "nft_supply_for_owner", "nft_tokens_for_owner",
+		}},
+		{MultisigTypeProject, []string{"new", "add_request", "confirm", "delete_request", "get_request", "get_members"}},
+		{DaoTypeProject, []string{"new", "add_proposal", "act_on_proposal", "get_proposal", "get_proposals", "get_policy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.projectType, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := writeTemplate(ProjectTemplates[tt.projectType], dir); err != nil {
+				t.Fatalf("writeTemplate() failed: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+				t.Fatalf("main.go was not written: %v", err)
+			}
+
+			contract, err := ScanContract(dir)
+			if err != nil {
+				t.Fatalf("ScanContract() failed: %v", err)
+			}
+			if _, err := GenerateCode(dir); err != nil {
+				t.Fatalf("GenerateCode() failed: %v", err)
+			}
+			if _, err := GenerateABI(contract, tt.projectType); err != nil {
+				t.Fatalf("GenerateABI() failed: %v", err)
+			}
+
+			exports := contract.ExportNames()
+			sort.Strings(exports)
+			for _, name := range tt.exports {
+				i := sort.SearchStrings(exports, name)
+				if i == len(exports) || exports[i] != name {
+					t.Errorf("export '%s' is missing, got %v", name, exports)
+				}
+			}
+		})
+	}
+
+	for projectType := range ProjectTemplates {
+		if projectType == SmartContractTypeProject {
+			continue
+		}
+		if _, err := templates.ReadFile(filepath.ToSlash(filepath.Join(ProjectTemplates[projectType], "main_test.go"+TemplateFileSuffix))); err != nil {
+			t.Errorf("template '%s' has no tests: %v", projectType, err)
+		}
+	}
+}
+
+func TestHandleCreateProjectRejectsUnknownType(t *testing.T) {
+	if err := HandleCreateProject("p", "token", "example.com/p"); err == nil || err.Error() != ErrIncorrectType {
+		t.Errorf("HandleCreateProject() error = %v, want %s", err, ErrIncorrectType)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli"
+)
+
+// ProjectConfig holds the settings of near-go.toml. Command line flags win over
+// the file, the file wins over the built-in defaults.
+type ProjectConfig struct {
+	// Path is the file the config was read from, "" when there is none.
+	Path      string          `toml:"-"`
+	Build     BuildConfig     `toml:"build"`
+	Deploy    DeployConfig    `toml:"deploy"`
+	Workspace WorkspaceConfig `toml:"workspace"`
+}
+
+// BuildConfig is the [build] table, used by build, test and profile.
+type BuildConfig struct {
+	// Source and Output are resolved against the folder of near-go.toml.
+	Source string `toml:"source"`
+	Output string `toml:"output"`
+	TinyGoOptions
+	Optimize bool `toml:"optimize"`
+}
+
+// DeployConfig is the [deploy] table. Network and Account are the defaults of
+// every command targeting a deployed contract.
+type DeployConfig struct {
+	Network string `toml:"network"`
+	Account string `toml:"account"`
+}
+
+// WorkspaceConfig is the [workspace] table used by 'build --all'.
+type WorkspaceConfig struct {
+	// Contracts are folders or glob patterns relative to near-go.toml. When empty
+	// every folder with a @contract:state struct is built.
+	Contracts []string `toml:"contracts"`
+	Out       string   `toml:"out"`
+	Jobs      int      `toml:"jobs"`
+}
+
+// Dir is the folder holding near-go.toml, or the working directory without one.
+func (c *ProjectConfig) Dir() (string, error) {
+	if c.Path == "" {
+		return os.Getwd()
+	}
+	return filepath.Dir(c.Path), nil
+}
+
+// TinyGoOptions are passed to 'tinygo build' and 'tinygo test' when set.
+type TinyGoOptions struct {
+	Opt       string   `toml:"opt"`
+	GC        string   `toml:"gc"`
+	Scheduler string   `toml:"scheduler"`
+	Tags      []string `toml:"tags"`
+	LDFlags   string   `toml:"ldflags"`
+}
+
+func (o TinyGoOptions) args() []string {
+	var args []string
+	for _, option := range []struct{ flag, value string }{
+		{"opt", o.Opt}, {"gc", o.GC}, {"scheduler", o.Scheduler},
+		{"tags", strings.Join(o.Tags, " ")}, {"ldflags", o.LDFlags},
+	} {
+		if option.value != "" {
+			args = append(args, "-"+option.flag+"="+option.value)
+		}
+	}
+	return args
+}
+
+// findProjectConfig returns the nearest near-go.toml in dir or its parents.
+func findProjectConfig(dir string) string {
+	for current := dir; ; {
+		path := filepath.Join(current, ProjectConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return ""
+		}
+		current = parent
+	}
+}
+
+// LoadProjectConfig reads the config at path, or finds one from the working
+// directory when path is empty. No config file yields an empty config.
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if path = findProjectConfig(wd); path == "" {
+			return &ProjectConfig{}, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	config, err := parseProjectConfig(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", ErrInvalidProjectConfig, path, err)
+	}
+
+	config.Path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(config.Path)
+	for _, p := range []*string{&config.Build.Source, &config.Build.Output} {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return config, nil
+}
+
+func parseProjectConfig(data string) (*ProjectConfig, error) {
+	config := &ProjectConfig{}
+	meta, err := toml.Decode(data, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Typos would otherwise be ignored silently.
+	var unknown []string
+	for _, key := range meta.Undecoded() {
+		unknown = append(unknown, key.String())
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		table, name, nested := strings.Cut(key, ".")
+		switch {
+		case nested:
+			return nil, fmt.Errorf("unknown key '%s' in [%s]", name, table)
+		case meta.Type(key) == "Hash":
+			return nil, fmt.Errorf("unknown table [%s]", key)
+		default:
+			return nil, fmt.Errorf("keys must be in the [build], [deploy] or [workspace] table")
+		}
+	}
+	if config.Workspace.Jobs < 0 {
+		return nil, fmt.Errorf("[workspace] jobs: expected a positive integer")
+	}
+	return config, nil
+}
+
+// BuildOptions returns the build settings of the config.
+func (c *ProjectConfig) BuildOptions() BuildOptions {
+	return BuildOptions{TinyGo: c.Build.TinyGoOptions, Optimize: c.Build.Optimize}
+}
+
+// projectConfig loads the config named by the global --config flag or found
+// from the working directory.
+func projectConfig(c *cli.Context) (*ProjectConfig, error) {
+	return LoadProjectConfig(c.GlobalString("config"))
+}
+
+// flagOrConfig returns the flag when it was given on the command line, else the
+// configured value, else the flag default.
+func flagOrConfig(c *cli.Context, name, configured string) string {
+	if c.IsSet(name) || configured == "" {
+		return c.String(name)
+	}
+	return configured
+}
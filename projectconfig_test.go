@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testProjectConfig = `# near-go project settings
+[build]
+source = "contract"
+output = 'out/counter.wasm'  # relative to this file
+opt = "z"
+gc = "leaking"
+scheduler = "none"
+tags = ["prod", "no_logs",]
+ldflags = "-X main.version=1.0.0"
+optimize = true
+
+[deploy]
+network = "testnet"
+account = "counter.testnet"
+`
+
+func TestParseProjectConfig_TOML(t *testing.T) {
+	config, err := parseProjectConfig("[workspace]\ncontracts = [\n  \"contracts/ft\",  # fungible token\n  'contracts/nft',\n  \"contracts/\\u0064ao\",\n]\njobs = 1_0\n")
+	if err != nil {
+		t.Fatalf("parseProjectConfig() failed: %v", err)
+	}
+	if !reflect.DeepEqual(config.Workspace, WorkspaceConfig{Contracts: []string{"contracts/ft", "contracts/nft", "contracts/dao"}, Jobs: 10}) {
+		t.Errorf("Workspace = %+v", config.Workspace)
+	}
+
+	// Go escapes that TOML doesn't have are rejected.
+	if _, err := parseProjectConfig("[deploy]\naccount = \"\\x41.testnet\"\n"); err == nil || !strings.Contains(err.Error(), "invalid escape") {
+		t.Errorf("parseProjectConfig() of the escape \\x41 error = %v", err)
+	}
+}
+
+func TestLoadProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ProjectConfigFileName)
+	if err := os.WriteFile(path, []byte(testProjectConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(dir, "contract", "internal")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if found := findProjectConfig(nested); found != path {
+		t.Fatalf("findProjectConfig() = %q, want %q", found, path)
+	}
+
+	config, err := LoadProjectConfig(path)
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() failed: %v", err)
+	}
+	if config.Build.Source != filepath.Join(dir, "contract") || config.Build.Output != filepath.Join(dir, "out", "counter.wasm") {
+		t.Errorf("Paths were not resolved against the config folder: %+v", config.Build)
+	}
+	if config.Deploy != (DeployConfig{Network: "testnet", Account: "counter.testnet"}) {
+		t.Errorf("Deploy = %+v", config.Deploy)
+	}
+
+	opts := config.BuildOptions()
+	if !opts.Optimize {
+		t.Error("BuildOptions() dropped 'optimize'")
+	}
+	args := strings.Join(opts.TinyGo.args(), " ")
+	if args != "-opt=z -gc=leaking -scheduler=none -tags=prod no_logs -ldflags=-X main.version=1.0.0" {
+		t.Errorf("TinyGo args = %q", args)
+	}
+	if len((TinyGoOptions{}).args()) != 0 {
+		t.Error("Empty TinyGo options produced arguments")
+	}
+}
+
+func TestLoadProjectConfig_Errors(t *testing.T) {
+	tests := map[string]string{
+		"[build]\noutpt = \"x.wasm\"": "unknown key 'outpt' in [build]",
+		"[tests]\n":                   "unknown table [tests]",
+		"network = \"testnet\"":       "must be in the [build], [deploy] or [workspace] table",
+		"[build]\ntags = \"prod\"":    "build.tags",
+		"[build]\noptimize = \"yes\"": "build.optimize",
+		"[deploy]\nnetwork = 1":       "deploy.network",
+		"[workspace]\njobs = -1":      "expected a positive integer",
+		"[build]\n[build]":            "has already been defined",
+	}
+	for content, want := range tests {
+		path := filepath.Join(t.TempDir(), ProjectConfigFileName)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadProjectConfig(path)
+		if err == nil || !strings.Contains(err.Error(), ErrInvalidProjectConfig) || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadProjectConfig(%q) error = %v, want %q", content, err, want)
+		}
+	}
+
+	if _, err := LoadProjectConfig(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("LoadProjectConfig() of a missing file succeeded")
+	}
+}
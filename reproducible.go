@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+	"github.com/vlmoon99/near-cli-go/rpc"
+)
+
+// reproducibleEnv is added to the TinyGo environment of reproducible builds.
+var reproducibleEnv = []string{"GOFLAGS=-trimpath", "SOURCE_DATE_EPOCH=0", "ZERO_AR_DATE=1"}
+
+// reproducibleBuildCommand is recorded in the NEP-330 metadata. It is the same
+// for every output path, so verify can rebuild anywhere.
+func reproducibleBuildCommand(opts BuildOptions) []string {
+	command := []string{"near-go", "build", "--reproducible"}
+	if opts.Optimize {
+		command = append(command, "--optimize")
+	}
+	return command
+}
+
+// BuildInfo is written next to the WASM of a reproducible build so that anyone
+// can check which inputs produced it.
+type BuildInfo struct {
+	SchemaVersion int            `json:"schema_version"`
+	Module        string         `json:"module"`
+	ContractPath  string         `json:"contract_path"`
+	SourceHash    string         `json:"source_hash"`
+	GitCommit     string         `json:"git_commit,omitempty"`
+	GitDirty      bool           `json:"git_dirty,omitempty"`
+	Toolchain     BuildToolchain `json:"toolchain"`
+	Flags         []string       `json:"flags"`
+	Optimized     bool           `json:"optimized,omitempty"`
+	Env           []string       `json:"env"`
+	WasmSize      int            `json:"wasm_size"`
+	WasmSha256    string         `json:"wasm_sha256"`
+	// CodeHash is the base58 sha256 the node reports as the account 'code_hash'.
+	CodeHash string `json:"code_hash"`
+}
+
+type BuildToolchain struct {
+	NearGo             string `json:"near_go"`
+	TinyGo             string `json:"tinygo"`
+	TinyGoBundleSha256 string `json:"tinygo_bundle_sha256"`
+}
+
+// SourceMetadata is the NEP-330 contract source metadata returned by the
+// generated 'contract_source_metadata' view.
+type SourceMetadata struct {
+	Version   string           `json:"version,omitempty"`
+	Link      string           `json:"link,omitempty"`
+	Standards []SourceStandard `json:"standards"`
+	BuildInfo *SourceBuildInfo `json:"build_info,omitempty"`
+}
+
+type SourceStandard struct {
+	Standard string `json:"standard"`
+	Version  string `json:"version"`
+}
+
+type SourceBuildInfo struct {
+	BuildEnvironment   string   `json:"build_environment"`
+	BuildCommand       []string `json:"build_command"`
+	SourceCodeSnapshot string   `json:"source_code_snapshot"`
+	ContractPath       string   `json:"contract_path"`
+}
+
+// buildInfoPathForWasm mirrors abiPathForWasm: 'build-info.json' for main.wasm,
+// '<name>.build-info.json' otherwise.
+func buildInfoPathForWasm(wasmPath string) string {
+	dir, base := filepath.Split(wasmPath)
+	if base == "main.wasm" {
+		return filepath.Join(dir, BuildInfoFileName)
+	}
+	return filepath.Join(dir, strings.TrimSuffix(base, ".wasm")+"."+BuildInfoFileName)
+}
+
+// codeHash returns the base58 sha256 of the code, as shown by 'view_account'.
+func codeHash(code []byte) string {
+	sum := sha256.Sum256(code)
+	return keystore.Base58Encode(sum[:])
+}
+
+// runGit runs git in dir and returns its trimmed output, or "" when dir is not
+// a git checkout or git is missing.
+func runGit(dir string, args ...string) string {
+	out, err := ExecuteCommand("git", append([]string{"-C", dir}, args...)...)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// gitContractPath returns dir relative to the repository root, "" for the root.
+func gitContractPath(dir string) string {
+	top := runGit(dir, "rev-parse", "--show-toplevel")
+	if top == "" {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	rel, err := filepath.Rel(top, dir)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// normalizeGitURL turns remote URLs like 'git@github.com:org/repo.git' into the
+// https form NEP-330 expects for 'link'.
+func normalizeGitURL(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSpace(remote), ".git")
+	if rest, ok := strings.CutPrefix(remote, "git@"); ok {
+		remote = "https://" + strings.Replace(rest, ":", "/", 1)
+	}
+	if rest, ok := strings.CutPrefix(remote, "ssh://git@"); ok {
+		remote = "https://" + rest
+	}
+	return remote
+}
+
+// detectSourceMetadata fills the NEP-330 metadata from the git checkout holding sourceDir.
+func detectSourceMetadata(sourceDir, tinyGoVersion string, buildCommand []string) *SourceMetadata {
+	meta := &SourceMetadata{
+		Version:   runGit(sourceDir, "describe", "--tags", "--always"),
+		Link:      normalizeGitURL(runGit(sourceDir, "config", "--get", "remote.origin.url")),
+		Standards: []SourceStandard{{Standard: SourceMetadataStandard, Version: SourceMetadataStdVersion}},
+	}
+
+	snapshot := ""
+	if commit := runGit(sourceDir, "rev-parse", "HEAD"); commit != "" && meta.Link != "" {
+		snapshot = fmt.Sprintf("git+%s?rev=%s", meta.Link, commit)
+	}
+	meta.BuildInfo = &SourceBuildInfo{
+		BuildEnvironment:   fmt.Sprintf("near-go %s (%s)", NearSdkGoVersion, tinyGoVersion),
+		BuildCommand:       buildCommand,
+		SourceCodeSnapshot: snapshot,
+		ContractPath:       gitContractPath(sourceDir),
+	}
+	return meta
+}
+
+// generateSourceMetadataExport returns the 'contract_source_metadata' view
+// appended to the generated glue code.
+func generateSourceMetadataExport(meta *SourceMetadata) (string, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n// Export: %s (NEP-330 source metadata)\n", SourceMetadataExport))
+	sb.WriteString(fmt.Sprintf("//go:export %s\n", SourceMetadataExport))
+	sb.WriteString(fmt.Sprintf("func %s() {\n", SourceMetadataExport))
+	sb.WriteString(fmt.Sprintf("\tcontractBuilder.ReturnValue(%s)\n", strconv.Quote(string(data))))
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// hasExport reports whether one of the contract methods already exports name.
+func hasExport(contract *ContractInfo, name string) bool {
+	for _, m := range contract.Methods {
+		if m.IsExported() && toSnakeCase(m.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareReproducibleDir copies the module holding sourceDir to a path derived
+// only from the source hash, with fixed file times, and returns the copy of
+// sourceDir. Identical sources therefore always compile from identical paths,
+// while the contracts of one module, built concurrently by 'build --all', each
+// get their own copy since the hash covers the contract folder.
+func prepareReproducibleDir(sourceDir, hash string) (string, func(), error) {
+	moduleDir := findModuleDir(sourceDir)
+	rel, err := filepath.Rel(moduleDir, sourceDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", ErrReproducibleBuild, err)
+	}
+
+	root := filepath.Join(ReproducibleBuildRoot, hash[:16])
+	cleanup := func() { os.RemoveAll(root) }
+	cleanup()
+	if err := copyTree(moduleDir, root); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%s: %w", ErrReproducibleBuild, err)
+	}
+	return filepath.Join(root, rel), cleanup, nil
+}
+
+// copyTree copies src to dst, skipping hidden folders and previous build
+// outputs, and resets every modification time to the Unix epoch.
+func copyTree(src, dst string) error {
+	epoch := time.Unix(0, 0)
+	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(d.Name(), ".wasm") || d.Name() == GeneratedBuildFileName {
+			return nil
+		}
+		if err := copyFile(path, target); err != nil {
+			return err
+		}
+		return os.Chtimes(target, epoch, epoch)
+	})
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(dst, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, epoch, epoch)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeBuildInfo describes the reproducible build of wasmPath next to it.
+func writeBuildInfo(sourceDir, wasmPath, tinyGoVersion string, flags []string, optimized bool) (string, *BuildInfo, error) {
+	code, err := os.ReadFile(wasmPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	hash, err := sourceHash(sourceDir)
+	if err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(code)
+
+	info := &BuildInfo{
+		SchemaVersion: BuildInfoSchemaVersion,
+		Module:        readModuleName(sourceDir),
+		ContractPath:  gitContractPath(sourceDir),
+		SourceHash:    hash,
+		GitCommit:     runGit(sourceDir, "rev-parse", "HEAD"),
+		Toolchain: BuildToolchain{
+			NearGo:             NearSdkGoVersion,
+			TinyGo:             tinyGoVersion,
+			TinyGoBundleSha256: tinyGoBundleHash(),
+		},
+		Flags:      flags,
+		Optimized:  optimized,
+		Env:        reproducibleEnv,
+		WasmSize:   len(code),
+		WasmSha256: hex.EncodeToString(sum[:]),
+		CodeHash:   codeHash(code),
+	}
+	if info.GitCommit != "" {
+		info.GitDirty = runGit(sourceDir, "status", "--porcelain") != ""
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+	path := buildInfoPathForWasm(wasmPath)
+	if err := WriteToFile(path, string(data)+"\n"); err != nil {
+		return "", nil, fmt.Errorf("failed to write build info '%s': %w", path, err)
+	}
+	return path, info, nil
+}
+
+// HandleVerify rebuilds the sources reproducibly and compares the result with
+// the code deployed to contractID. opts must match the original build.
+func HandleVerify(contractID, network, sourceDir string, opts BuildOptions) error {
+	client, err := rpc.NewClientForNetwork(network)
+	if err != nil {
+		return err
+	}
+	account, err := client.ViewAccount(context.Background(), contractID, rpc.FinalityFinal())
+	if err != nil {
+		if rpc.IsUnknownAccount(err) {
+			return fmt.Errorf("%s: '%s' on %s", ErrAccountNotFound, contractID, network)
+		}
+		return err
+	}
+	if account.CodeHash == emptyCodeHash {
+		return fmt.Errorf("%s: '%s' on %s", ErrNoContractDeployed, contractID, network)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "near-go-verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	wasmPath := filepath.Join(tmpDir, "main.wasm")
+
+	fmt.Printf("🔁 Rebuilding %s reproducibly...\n", sourceDir)
+	opts.Reproducible, opts.NoCache = true, true
+	if err := HandleBuild(sourceDir, wasmPath, opts); err != nil {
+		return err
+	}
+	code, err := os.ReadFile(wasmPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	return compareCodeHash(contractID, account.CodeHash, codeHash(code))
+}
+
+func compareCodeHash(contractID, deployed, local string) error {
+	fmt.Printf("   On-chain code hash: %s\n", deployed)
+	fmt.Printf("   Local code hash:    %s\n", local)
+	if deployed != local {
+		return errors.New(ErrVerifyMismatch)
+	}
+	fmt.Printf("✅ %s runs exactly this source\n", contractID)
+	return nil
+}
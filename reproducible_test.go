@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildInfoPathForWasm(t *testing.T) {
+	tests := map[string]string{
+		"out/main.wasm":  filepath.Join("out", "build-info.json"),
+		"out/token.wasm": filepath.Join("out", "token.build-info.json"),
+	}
+	for wasm, want := range tests {
+		if got := buildInfoPathForWasm(wasm); got != want {
+			t.Errorf("buildInfoPathForWasm(%q) = %q, want %q", wasm, got, want)
+		}
+	}
+}
+
+func TestNormalizeGitURL(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:org/repo.git":       "https://github.com/org/repo",
+		"ssh://git@github.com/org/repo.git": "https://github.com/org/repo",
+		"https://github.com/org/repo.git\n": "https://github.com/org/repo",
+		"https://github.com/org/repo":       "https://github.com/org/repo",
+		"":                                  "",
+	}
+	for remote, want := range tests {
+		if got := normalizeGitURL(remote); got != want {
+			t.Errorf("normalizeGitURL(%q) = %q, want %q", remote, got, want)
+		}
+	}
+}
+
+func TestGenerateSourceMetadataExport(t *testing.T) {
+	meta := &SourceMetadata{
+		Version:   "v1.0.0",
+		Link:      "https://github.com/org/repo",
+		Standards: []SourceStandard{{Standard: SourceMetadataStandard, Version: SourceMetadataStdVersion}},
+		BuildInfo: &SourceBuildInfo{
+			BuildEnvironment:   "near-go v0.1.1 (tinygo version 0.37.0)",
+			BuildCommand:       reproducibleBuildCommand(BuildOptions{Optimize: true}),
+			SourceCodeSnapshot: "git+https://github.com/org/repo?rev=abc",
+			ContractPath:       "contract",
+		},
+	}
+	code, err := generateSourceMetadataExport(meta)
+	if err != nil {
+		t.Fatalf("generateSourceMetadataExport() failed: %v", err)
+	}
+	if !strings.Contains(code, "//go:export contract_source_metadata\nfunc contract_source_metadata() {") {
+		t.Fatalf("Missing export in:\n%s", code)
+	}
+
+	start := strings.Index(code, "ReturnValue(") + len("ReturnValue(")
+	end := strings.LastIndex(code, ")\n}")
+	literal, err := strconv.Unquote(code[start:end])
+	if err != nil {
+		t.Fatalf("ReturnValue argument is not a Go string literal: %v", err)
+	}
+	var decoded SourceMetadata
+	if err := json.Unmarshal([]byte(literal), &decoded); err != nil {
+		t.Fatalf("Metadata is not valid JSON: %v", err)
+	}
+	if decoded.Standards[0].Standard != "nep330" || decoded.BuildInfo.ContractPath != "contract" ||
+		strings.Join(decoded.BuildInfo.BuildCommand, " ") != "near-go build --reproducible --optimize" {
+		t.Errorf("Unexpected metadata: %s", literal)
+	}
+}
+
+func TestGenerateCodeIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeTemplate(ProjectTemplates[FungibleTokenTypeProject], dir); err != nil {
+		t.Fatalf("writeTemplate() failed: %v", err)
+	}
+	first, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode() failed: %v", err)
+	}
+	for range 10 {
+		if code, _ := GenerateCode(dir); code != first {
+			t.Fatal("GenerateCode() output differs between runs")
+		}
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "copy")
+	files := map[string]string{
+		"go.mod":                  "module example.com/c\n",
+		"contract/main.go":        "package main\n",
+		"contract/main.wasm":      "old build",
+		".git/HEAD":               "ref: refs/heads/main\n",
+		GeneratedBuildFileName:    "package main\n",
+		"node_modules/x/index.js": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		t.Fatalf("copyTree() failed: %v", err)
+	}
+	for name := range files {
+		_, err := os.Stat(filepath.Join(dst, name))
+		copied := name == "go.mod" || name == "contract/main.go"
+		if copied != (err == nil) {
+			t.Errorf("%s copied = %v, want %v", name, err == nil, copied)
+		}
+	}
+	for _, name := range []string{"go.mod", "contract", "contract/main.go"} {
+		info, err := os.Stat(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(time.Unix(0, 0)) {
+			t.Errorf("%s mtime = %v, want the Unix epoch", name, info.ModTime())
+		}
+	}
+}
+
+func TestPrepareReproducibleDir_ContractsOfOneModule(t *testing.T) {
+	module := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module example.com/c\n",
+		"a/main.go": "package main\n",
+		"b/main.go": "package main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(module, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dirs := map[string]string{}
+	cleanups := map[string]func(){}
+	for _, name := range []string{"a", "b"} {
+		sourceDir := filepath.Join(module, name)
+		hash, err := sourceHash(sourceDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dir, cleanup, err := prepareReproducibleDir(sourceDir, hash)
+		if err != nil {
+			t.Fatalf("prepareReproducibleDir(%s) failed: %v", name, err)
+		}
+		t.Cleanup(cleanup)
+		dirs[name], cleanups[name] = dir, cleanup
+	}
+	if filepath.Dir(dirs["a"]) == filepath.Dir(dirs["b"]) {
+		t.Fatalf("Both contracts are built from the copy %s", filepath.Dir(dirs["a"]))
+	}
+
+	// Removing the copy of one contract leaves the other one in place.
+	cleanups["a"]()
+	if _, err := os.Stat(filepath.Join(dirs["b"], "main.go")); err != nil {
+		t.Errorf("Copy of b is gone after cleaning up a: %v", err)
+	}
+}
+
+func TestWriteBuildInfo(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeTemplate(ProjectTemplates[SmartContractTypeProject], dir); err != nil {
+		t.Fatalf("writeTemplate() failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/counter\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wasmPath := filepath.Join(dir, "counter.wasm")
+	if err := os.WriteFile(wasmPath, noopWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	flags := []string{"build", "-no-debug"}
+	path, info, err := writeBuildInfo(dir, wasmPath, "tinygo version 0.37.0", flags, false)
+	if err != nil {
+		t.Fatalf("writeBuildInfo() failed: %v", err)
+	}
+	if path != filepath.Join(dir, "counter.build-info.json") {
+		t.Errorf("Build info path = %s", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written BuildInfo
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("build-info.json is not valid JSON: %v", err)
+	}
+	hash, _ := sourceHash(dir)
+	if written.Module != "counter" || written.SourceHash != hash || written.CodeHash != codeHash(noopWasm) ||
+		written.WasmSize != len(noopWasm) || len(written.WasmSha256) != 64 || written.CodeHash != info.CodeHash {
+		t.Errorf("Unexpected build info: %s", data)
+	}
+	if written.Toolchain.TinyGo != "tinygo version 0.37.0" || written.Toolchain.TinyGoBundleSha256 != tinyGoBundleHash() ||
+		strings.Join(written.Flags, " ") != "build -no-debug" {
+		t.Errorf("Unexpected toolchain or flags: %s", data)
+	}
+	if strings.Contains(string(data), dir) {
+		t.Error("build-info.json contains an absolute path")
+	}
+}
+
+func TestCompareCodeHash(t *testing.T) {
+	hash := codeHash(noopWasm)
+	if err := compareCodeHash("c.testnet", hash, hash); err != nil {
+		t.Errorf("compareCodeHash() on equal hashes failed: %v", err)
+	}
+	if err := compareCodeHash("c.testnet", emptyCodeHash, hash); err == nil || err.Error() != ErrVerifyMismatch {
+		t.Errorf("compareCodeHash() on different hashes error = %v", err)
+	}
+}
+
+func TestEnsureBundledTinyGo(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureBundledTinyGo(); err != nil {
+		t.Fatalf("ensureBundledTinyGo() failed: %v", err)
+	}
+	if marker, err := os.ReadFile(tinyGoBundleMarker()); err != nil || string(marker) != tinyGoBundleHash() {
+		t.Fatalf("Bundle marker = %q (%v)", marker, err)
+	}
+
+	stale := filepath.Join(getToolHome(), "tinygo", "stale")
+	if err := os.WriteFile(stale, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureBundledTinyGo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Error("ensureBundledTinyGo() re-extracted a matching installation")
+	}
+
+	if err := os.WriteFile(tinyGoBundleMarker(), []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureBundledTinyGo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("ensureBundledTinyGo() kept an installation from another bundle")
+	}
+}
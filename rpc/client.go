@@ -0,0 +1,314 @@
+// Package rpc implements a typed client for the NEAR JSON-RPC API.
+//
+// Only the methods used by near-go are covered: account, access key, state and
+// function call queries, transaction submission and status, blocks and gas price.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TestnetURL = "https://rpc.testnet.near.org"
+	MainnetURL = "https://rpc.mainnet.near.org"
+
+	DefaultTimeout = 60 * time.Second
+)
+
+const (
+	ErrUnknownNetwork  = "(RPC_ERROR): unknown network"
+	ErrRequestFailed   = "(RPC_ERROR): request failed"
+	ErrInvalidResponse = "(RPC_ERROR): invalid response"
+)
+
+// Client sends JSON-RPC requests to a single NEAR RPC endpoint.
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+
+	requestID uint64
+}
+
+func NewClient(url string) *Client {
+	return &Client{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// NetworkURL resolves a network ID ("testnet", "mainnet") or an explicit http(s) URL.
+func NetworkURL(network string) (string, error) {
+	switch network {
+	case "testnet":
+		return TestnetURL, nil
+	case "mainnet":
+		return MainnetURL, nil
+	}
+	if strings.HasPrefix(network, "http://") || strings.HasPrefix(network, "https://") {
+		return network, nil
+	}
+	return "", fmt.Errorf("%s: '%s'", ErrUnknownNetwork, network)
+}
+
+func NewClientForNetwork(network string) (*Client, error) {
+	url, err := NetworkURL(network)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(url), nil
+}
+
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      string      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      string          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+// Call performs a raw JSON-RPC call and decodes the result into result.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := atomic.AddUint64(&c.requestID, 1)
+	body, err := json.Marshal(request{
+		JSONRPC: "2.0",
+		ID:      strconv.FormatUint(id, 10),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRequestFailed, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRequestFailed, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrRequestFailed, err)
+	}
+
+	var rpcResp response
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s: HTTP %d: %s", ErrRequestFailed, resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+		return fmt.Errorf("%s: %w", ErrInvalidResponse, err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %d", ErrRequestFailed, resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("%s: %w", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// query runs a 'query' request. Some query failures are reported inside the result
+// instead of the JSON-RPC error object, those are returned as *QueryError.
+func (c *Client) query(ctx context.Context, requestType string, params map[string]interface{}, block BlockReference, result interface{}) error {
+	params["request_type"] = requestType
+	for k, v := range block {
+		params[k] = v
+	}
+
+	var raw json.RawMessage
+	if err := c.Call(ctx, "query", params, &raw); err != nil {
+		return err
+	}
+
+	var queryErr QueryError
+	if err := json.Unmarshal(raw, &queryErr); err == nil && queryErr.Message != "" {
+		return &queryErr
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("%s: %w", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+func (c *Client) ViewAccount(ctx context.Context, accountID string, block BlockReference) (*AccountView, error) {
+	var result AccountView
+	params := map[string]interface{}{"account_id": accountID}
+	if err := c.query(ctx, "view_account", params, block, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CallFunction runs a read-only contract call. args are passed as-is (usually JSON).
+func (c *Client) CallFunction(ctx context.Context, accountID, methodName string, args []byte, block BlockReference) (*CallResult, error) {
+	var result CallResult
+	params := map[string]interface{}{
+		"account_id":  accountID,
+		"method_name": methodName,
+		"args_base64": base64.StdEncoding.EncodeToString(args),
+	}
+	if err := c.query(ctx, "call_function", params, block, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ViewState returns the contract storage entries whose keys start with prefix.
+func (c *Client) ViewState(ctx context.Context, accountID string, prefix []byte, block BlockReference) (*StateView, error) {
+	var result StateView
+	params := map[string]interface{}{
+		"account_id":    accountID,
+		"prefix_base64": base64.StdEncoding.EncodeToString(prefix),
+	}
+	if err := c.query(ctx, "view_state", params, block, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ViewAccessKey(ctx context.Context, accountID, publicKey string, block BlockReference) (*AccessKeyView, error) {
+	var result AccessKeyView
+	params := map[string]interface{}{
+		"account_id": accountID,
+		"public_key": publicKey,
+	}
+	if err := c.query(ctx, "view_access_key", params, block, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BroadcastTxCommit submits a base64 encoded signed transaction and waits for its final outcome.
+func (c *Client) BroadcastTxCommit(ctx context.Context, signedTxBase64 string) (*FinalExecutionOutcome, error) {
+	var result FinalExecutionOutcome
+	if err := c.Call(ctx, "broadcast_tx_commit", []string{signedTxBase64}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TxStatus looks up a transaction by hash. senderID is the signer of the transaction.
+func (c *Client) TxStatus(ctx context.Context, txHash, senderID string) (*FinalExecutionOutcome, error) {
+	var result FinalExecutionOutcome
+	if err := c.Call(ctx, "tx", []string{txHash, senderID}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Block(ctx context.Context, block BlockReference) (*BlockView, error) {
+	var result BlockView
+	if err := c.Call(ctx, "block", block, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GasPrice returns the gas price in yoctoNEAR for the given block height or hash,
+// or for the latest block when blockID is nil.
+func (c *Client) GasPrice(ctx context.Context, blockID interface{}) (string, error) {
+	var result struct {
+		GasPrice string `json:"gas_price"`
+	}
+	if err := c.Call(ctx, "gas_price", []interface{}{blockID}, &result); err != nil {
+		return "", err
+	}
+	return result.GasPrice, nil
+}
+
+// Error is a JSON-RPC error returned by the node. Name is the error category (e.g.
+// HANDLER_ERROR) and Cause the specific reason (e.g. UNKNOWN_ACCOUNT).
+type Error struct {
+	Name    string          `json:"name"`
+	Cause   ErrorCause      `json:"cause"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type ErrorCause struct {
+	Name string          `json:"name"`
+	Info json.RawMessage `json:"info"`
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteString("(RPC_ERROR): ")
+	if e.Name != "" {
+		sb.WriteString(e.Name)
+	} else {
+		sb.WriteString(e.Message)
+	}
+	if e.Cause.Name != "" {
+		sb.WriteString(": " + e.Cause.Name)
+	}
+	if len(e.Data) > 0 && string(e.Data) != "null" {
+		var data string
+		if json.Unmarshal(e.Data, &data) == nil {
+			sb.WriteString(": " + data)
+		} else {
+			sb.WriteString(": " + string(e.Data))
+		}
+	}
+	return sb.String()
+}
+
+// QueryError is reported by older nodes inside a successful query result, for
+// example when a contract view call panics.
+type QueryError struct {
+	Message     string   `json:"error"`
+	Logs        []string `json:"logs"`
+	BlockHeight uint64   `json:"block_height"`
+	BlockHash   string   `json:"block_hash"`
+}
+
+func (e *QueryError) Error() string {
+	return "(RPC_ERROR): query failed: " + e.Message
+}
+
+// HasCause reports whether err is an RPC error with the given cause name.
+func HasCause(err error, cause string) bool {
+	var rpcErr *Error
+	return errors.As(err, &rpcErr) && rpcErr.Cause.Name == cause
+}
+
+func IsUnknownAccount(err error) bool {
+	return HasCause(err, "UNKNOWN_ACCOUNT")
+}
+
+func IsUnknownAccessKey(err error) bool {
+	return HasCause(err, "UNKNOWN_ACCESS_KEY")
+}
+
+func IsTimeout(err error) bool {
+	var rpcErr *Error
+	return errors.As(err, &rpcErr) && (rpcErr.Name == "TIMEOUT_ERROR" || rpcErr.Cause.Name == "TIMEOUT_ERROR")
+}
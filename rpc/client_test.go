@@ -0,0 +1,285 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type fakeRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// newFakeRPC starts a server that answers every request with the handler's raw result or error JSON.
+func newFakeRPC(t *testing.T, handler func(req fakeRequest) (result string, rpcErr string)) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fakeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Invalid request body: %v", err)
+		}
+		result, rpcErr := handler(req)
+		w.Header().Set("Content-Type", "application/json")
+		if rpcErr != "" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":"1","error":` + rpcErr + `}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":` + result + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL)
+}
+
+func decodeParams(t *testing.T, req fakeRequest) map[string]interface{} {
+	var params map[string]interface{}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		t.Fatalf("Expected object params, got %s", req.Params)
+	}
+	return params
+}
+
+func TestNetworkURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"testnet", TestnetURL, false},
+		{"mainnet", MainnetURL, false},
+		{"http://localhost:3030", "http://localhost:3030", false},
+		{"betanet", "", true},
+	}
+
+	for _, tt := range tests {
+		result, err := NetworkURL(tt.input)
+		if (err != nil) != tt.wantErr || result != tt.expected {
+			t.Errorf("NetworkURL(%q) = %q, %v; want %q, error=%v", tt.input, result, err, tt.expected, tt.wantErr)
+		}
+	}
+}
+
+func TestViewAccount(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		params := decodeParams(t, req)
+		if req.Method != "query" || params["request_type"] != "view_account" || params["account_id"] != "alice.testnet" || params["finality"] != "final" {
+			t.Errorf("Unexpected request: %s %s", req.Method, req.Params)
+		}
+		return `{"amount":"1000","locked":"0","code_hash":"11111111111111111111111111111111","storage_usage":182,"storage_paid_at":0,"block_height":10,"block_hash":"abc"}`, ""
+	})
+
+	account, err := client.ViewAccount(context.Background(), "alice.testnet", FinalityFinal())
+	if err != nil {
+		t.Fatalf("ViewAccount failed: %v", err)
+	}
+	if account.Amount != "1000" || account.StorageUsage != 182 || account.BlockHeight != 10 {
+		t.Errorf("Unexpected account view: %+v", account)
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		params := decodeParams(t, req)
+		if params["request_type"] != "call_function" || params["method_name"] != "get_count" || params["args_base64"] != "e30=" {
+			t.Errorf("Unexpected params: %s", req.Params)
+		}
+		if params["block_id"] != float64(42) {
+			t.Errorf("Expected block_id 42, got %v", params["block_id"])
+		}
+		return `{"result":[49,50],"logs":["hello"],"block_height":42,"block_hash":"h"}`, ""
+	})
+
+	result, err := client.CallFunction(context.Background(), "counter.testnet", "get_count", []byte("{}"), BlockHeight(42))
+	if err != nil {
+		t.Fatalf("CallFunction failed: %v", err)
+	}
+	if string(result.Result) != "12" || !reflect.DeepEqual(result.Logs, []string{"hello"}) {
+		t.Errorf("Unexpected call result: %+v", result)
+	}
+}
+
+func TestCallFunction_QueryErrorInResult(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		return `{"error":"wasm execution failed with error: MethodNotFound","logs":[],"block_height":1,"block_hash":"h"}`, ""
+	})
+
+	_, err := client.CallFunction(context.Background(), "counter.testnet", "missing", nil, FinalityFinal())
+	queryErr, ok := err.(*QueryError)
+	if !ok {
+		t.Fatalf("Expected *QueryError, got %T: %v", err, err)
+	}
+	if queryErr.Message != "wasm execution failed with error: MethodNotFound" {
+		t.Errorf("Unexpected query error message: %s", queryErr.Message)
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		return "", `{"name":"HANDLER_ERROR","cause":{"name":"UNKNOWN_ACCOUNT","info":{"requested_account_id":"nobody.testnet"}},"code":-32000,"message":"Server error","data":"account nobody.testnet does not exist while viewing"}`
+	})
+
+	_, err := client.ViewAccount(context.Background(), "nobody.testnet", FinalityFinal())
+	if !IsUnknownAccount(err) {
+		t.Fatalf("Expected UNKNOWN_ACCOUNT error, got %v", err)
+	}
+	if IsUnknownAccessKey(err) {
+		t.Errorf("Error must not match a different cause")
+	}
+	expected := "(RPC_ERROR): HANDLER_ERROR: UNKNOWN_ACCOUNT: account nobody.testnet does not exist while viewing"
+	if err.Error() != expected {
+		t.Errorf("Error() = %q; want %q", err.Error(), expected)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Block(context.Background(), FinalityFinal())
+	if err == nil {
+		t.Fatalf("Expected error for HTTP 429")
+	}
+}
+
+func TestViewAccessKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission string
+		fullAccess bool
+		receiver   string
+	}{
+		{"full access", `"FullAccess"`, true, ""},
+		{"function call", `{"FunctionCall":{"allowance":"100","receiver_id":"app.testnet","method_names":["vote"]}}`, false, "app.testnet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+				params := decodeParams(t, req)
+				if params["request_type"] != "view_access_key" || params["public_key"] != "ed25519:abc" {
+					t.Errorf("Unexpected params: %s", req.Params)
+				}
+				return `{"nonce":85,"permission":` + tt.permission + `,"block_height":1,"block_hash":"h"}`, ""
+			})
+
+			key, err := client.ViewAccessKey(context.Background(), "alice.testnet", "ed25519:abc", FinalityFinal())
+			if err != nil {
+				t.Fatalf("ViewAccessKey failed: %v", err)
+			}
+			if key.Nonce != 85 || key.Permission.FullAccess != tt.fullAccess {
+				t.Errorf("Unexpected access key: %+v", key)
+			}
+			if !tt.fullAccess && key.Permission.FunctionCall.ReceiverID != tt.receiver {
+				t.Errorf("Unexpected function call permission: %+v", key.Permission.FunctionCall)
+			}
+		})
+	}
+}
+
+func TestViewState(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		params := decodeParams(t, req)
+		if params["prefix_base64"] != "U1RBVEU=" {
+			t.Errorf("Expected STATE prefix, got %v", params["prefix_base64"])
+		}
+		return `{"values":[{"key":"U1RBVEU=","value":"e30="}],"block_height":1,"block_hash":"h"}`, ""
+	})
+
+	state, err := client.ViewState(context.Background(), "counter.testnet", []byte("STATE"), FinalityFinal())
+	if err != nil {
+		t.Fatalf("ViewState failed: %v", err)
+	}
+	value, err := state.Values[0].DecodedValue()
+	if err != nil || string(value) != "{}" {
+		t.Errorf("Unexpected state value %q: %v", value, err)
+	}
+}
+
+const finalOutcomeJSON = `{
+	"status": {"SuccessValue": "IjEyIg=="},
+	"transaction": {"signer_id":"alice.testnet","public_key":"ed25519:abc","nonce":2,"receiver_id":"counter.testnet","actions":[],"signature":"ed25519:sig","hash":"txhash"},
+	"transaction_outcome": {"id":"txhash","block_hash":"b","outcome":{"logs":[],"receipt_ids":["r1"],"gas_burnt":100,"tokens_burnt":"0","executor_id":"alice.testnet","status":{"SuccessReceiptId":"r1"}}},
+	"receipts_outcome": [
+		{"id":"r1","block_hash":"b","outcome":{"logs":["incremented"],"receipt_ids":[],"gas_burnt":200,"tokens_burnt":"0","executor_id":"counter.testnet","status":{"SuccessValue":"IjEyIg=="}}},
+		{"id":"r2","block_hash":"b","outcome":{"logs":[],"receipt_ids":[],"gas_burnt":5,"tokens_burnt":"0","executor_id":"alice.testnet","status":"Unknown"}}
+	]
+}`
+
+func TestBroadcastTxCommit(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		var params []string
+		if err := json.Unmarshal(req.Params, &params); err != nil || req.Method != "broadcast_tx_commit" || params[0] != "c2lnbmVk" {
+			t.Errorf("Unexpected request: %s %s", req.Method, req.Params)
+		}
+		return finalOutcomeJSON, ""
+	})
+
+	outcome, err := client.BroadcastTxCommit(context.Background(), "c2lnbmVk")
+	if err != nil {
+		t.Fatalf("BroadcastTxCommit failed: %v", err)
+	}
+	if err := outcome.Status.Err(); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	value, err := outcome.Status.Value()
+	if err != nil || string(value) != `"12"` {
+		t.Errorf("Unexpected success value %q: %v", value, err)
+	}
+	if !reflect.DeepEqual(outcome.Logs(), []string{"incremented"}) {
+		t.Errorf("Unexpected logs: %v", outcome.Logs())
+	}
+	if outcome.TotalGasBurnt() != 305 {
+		t.Errorf("Expected 305 gas burnt, got %d", outcome.TotalGasBurnt())
+	}
+	if outcome.ReceiptsOutcome[1].Outcome.Status.Other != "Unknown" {
+		t.Errorf("Expected string status to be preserved")
+	}
+}
+
+func TestTxStatus_Failure(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		var params []string
+		if err := json.Unmarshal(req.Params, &params); err != nil || req.Method != "tx" || params[0] != "txhash" || params[1] != "alice.testnet" {
+			t.Errorf("Unexpected request: %s %s", req.Method, req.Params)
+		}
+		return `{"status":{"Failure":{"ActionError":{"index":0,"kind":{"FunctionCallError":{"ExecutionError":"Smart contract panicked: boom"}}}}},"transaction":{},"transaction_outcome":{"id":"txhash","outcome":{"status":{}}},"receipts_outcome":[]}`, ""
+	})
+
+	outcome, err := client.TxStatus(context.Background(), "txhash", "alice.testnet")
+	if err != nil {
+		t.Fatalf("TxStatus failed: %v", err)
+	}
+	if _, ok := outcome.Status.Err().(*ExecutionError); !ok {
+		t.Errorf("Expected execution failure, got %v", outcome.Status.Err())
+	}
+}
+
+func TestBlockAndGasPrice(t *testing.T) {
+	client := newFakeRPC(t, func(req fakeRequest) (string, string) {
+		switch req.Method {
+		case "block":
+			return `{"author":"validator.testnet","header":{"height":77,"hash":"blockhash","prev_hash":"prev","epoch_id":"e","timestamp":1,"gas_price":"100000000"}}`, ""
+		case "gas_price":
+			if string(req.Params) != "[null]" {
+				t.Errorf("Expected [null] params for latest gas price, got %s", req.Params)
+			}
+			return `{"gas_price":"100000000"}`, ""
+		}
+		t.Fatalf("Unexpected method %s", req.Method)
+		return "", ""
+	})
+
+	block, err := client.Block(context.Background(), FinalityFinal())
+	if err != nil || block.Header.Height != 77 || block.Header.Hash != "blockhash" {
+		t.Fatalf("Unexpected block %+v: %v", block, err)
+	}
+
+	price, err := client.GasPrice(context.Background(), nil)
+	if err != nil || price != "100000000" {
+		t.Fatalf("Unexpected gas price %q: %v", price, err)
+	}
+}
@@ -0,0 +1,239 @@
+package rpc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// BlockReference selects the block a query runs against. It is merged into the request params.
+type BlockReference map[string]interface{}
+
+func FinalityFinal() BlockReference {
+	return BlockReference{"finality": "final"}
+}
+
+func FinalityOptimistic() BlockReference {
+	return BlockReference{"finality": "optimistic"}
+}
+
+func BlockHeight(height uint64) BlockReference {
+	return BlockReference{"block_id": height}
+}
+
+func BlockHash(hash string) BlockReference {
+	return BlockReference{"block_id": hash}
+}
+
+type AccountView struct {
+	Amount        string `json:"amount"`
+	Locked        string `json:"locked"`
+	CodeHash      string `json:"code_hash"`
+	StorageUsage  uint64 `json:"storage_usage"`
+	StoragePaidAt uint64 `json:"storage_paid_at"`
+	BlockHeight   uint64 `json:"block_height"`
+	BlockHash     string `json:"block_hash"`
+}
+
+// ByteArray decodes the JSON number arrays the RPC uses for raw call results.
+type ByteArray []byte
+
+func (b *ByteArray) UnmarshalJSON(data []byte) error {
+	var values []int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	out := make([]byte, len(values))
+	for i, v := range values {
+		if v < 0 || v > 255 {
+			return fmt.Errorf("byte value %d out of range", v)
+		}
+		out[i] = byte(v)
+	}
+	*b = out
+	return nil
+}
+
+type CallResult struct {
+	Result      ByteArray `json:"result"`
+	Logs        []string  `json:"logs"`
+	BlockHeight uint64    `json:"block_height"`
+	BlockHash   string    `json:"block_hash"`
+}
+
+type StateItem struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// DecodedKey returns the raw bytes of the base64 encoded key.
+func (s StateItem) DecodedKey() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(s.Key)
+}
+
+// DecodedValue returns the raw bytes of the base64 encoded value.
+func (s StateItem) DecodedValue() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(s.Value)
+}
+
+type StateView struct {
+	Values      []StateItem `json:"values"`
+	BlockHeight uint64      `json:"block_height"`
+	BlockHash   string      `json:"block_hash"`
+}
+
+type FunctionCallPermission struct {
+	Allowance   *string  `json:"allowance"`
+	ReceiverID  string   `json:"receiver_id"`
+	MethodNames []string `json:"method_names"`
+}
+
+// AccessKeyPermission is either "FullAccess" or a FunctionCall restriction.
+type AccessKeyPermission struct {
+	FullAccess   bool
+	FunctionCall *FunctionCallPermission
+}
+
+func (p *AccessKeyPermission) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		if name != "FullAccess" {
+			return fmt.Errorf("unknown access key permission '%s'", name)
+		}
+		p.FullAccess = true
+		return nil
+	}
+	var fc struct {
+		FunctionCall *FunctionCallPermission `json:"FunctionCall"`
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return err
+	}
+	if fc.FunctionCall == nil {
+		return errors.New("unknown access key permission")
+	}
+	p.FunctionCall = fc.FunctionCall
+	return nil
+}
+
+func (p AccessKeyPermission) MarshalJSON() ([]byte, error) {
+	if p.FullAccess {
+		return json.Marshal("FullAccess")
+	}
+	return json.Marshal(map[string]interface{}{"FunctionCall": p.FunctionCall})
+}
+
+type AccessKeyView struct {
+	Nonce       uint64              `json:"nonce"`
+	Permission  AccessKeyPermission `json:"permission"`
+	BlockHeight uint64              `json:"block_height"`
+	BlockHash   string              `json:"block_hash"`
+}
+
+type BlockHeader struct {
+	Height    uint64 `json:"height"`
+	Hash      string `json:"hash"`
+	PrevHash  string `json:"prev_hash"`
+	EpochID   string `json:"epoch_id"`
+	Timestamp uint64 `json:"timestamp"`
+	GasPrice  string `json:"gas_price"`
+}
+
+type BlockView struct {
+	Author string      `json:"author"`
+	Header BlockHeader `json:"header"`
+}
+
+// ExecutionStatus is the status of a transaction or receipt. Only one field is set;
+// Pending/Unknown statuses are reported as plain strings by the node.
+type ExecutionStatus struct {
+	SuccessValue     *string         `json:"SuccessValue,omitempty"`
+	SuccessReceiptID string          `json:"SuccessReceiptId,omitempty"`
+	Failure          json.RawMessage `json:"Failure,omitempty"`
+	Other            string          `json:"-"`
+}
+
+func (s *ExecutionStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		s.Other = name
+		return nil
+	}
+	type plain ExecutionStatus
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// Err returns the failure as an error, or nil when the execution did not fail.
+func (s ExecutionStatus) Err() error {
+	if len(s.Failure) == 0 {
+		return nil
+	}
+	return &ExecutionError{Failure: s.Failure}
+}
+
+// Value decodes the base64 SuccessValue.
+func (s ExecutionStatus) Value() ([]byte, error) {
+	if s.SuccessValue == nil {
+		return nil, nil
+	}
+	return base64.StdEncoding.DecodeString(*s.SuccessValue)
+}
+
+type ExecutionError struct {
+	Failure json.RawMessage
+}
+
+func (e *ExecutionError) Error() string {
+	return "(TX_ERROR): execution failed: " + string(e.Failure)
+}
+
+type ExecutionOutcome struct {
+	Logs        []string        `json:"logs"`
+	ReceiptIDs  []string        `json:"receipt_ids"`
+	GasBurnt    uint64          `json:"gas_burnt"`
+	TokensBurnt string          `json:"tokens_burnt"`
+	ExecutorID  string          `json:"executor_id"`
+	Status      ExecutionStatus `json:"status"`
+}
+
+type ExecutionOutcomeWithID struct {
+	ID        string           `json:"id"`
+	BlockHash string           `json:"block_hash"`
+	Outcome   ExecutionOutcome `json:"outcome"`
+}
+
+type TransactionView struct {
+	SignerID   string          `json:"signer_id"`
+	PublicKey  string          `json:"public_key"`
+	Nonce      uint64          `json:"nonce"`
+	ReceiverID string          `json:"receiver_id"`
+	Actions    json.RawMessage `json:"actions"`
+	Signature  string          `json:"signature"`
+	Hash       string          `json:"hash"`
+}
+
+type FinalExecutionOutcome struct {
+	Status             ExecutionStatus          `json:"status"`
+	Transaction        TransactionView          `json:"transaction"`
+	TransactionOutcome ExecutionOutcomeWithID   `json:"transaction_outcome"`
+	ReceiptsOutcome    []ExecutionOutcomeWithID `json:"receipts_outcome"`
+}
+
+// Logs collects the logs of the transaction and all of its receipts in execution order.
+func (o *FinalExecutionOutcome) Logs() []string {
+	logs := append([]string{}, o.TransactionOutcome.Outcome.Logs...)
+	for _, r := range o.ReceiptsOutcome {
+		logs = append(logs, r.Outcome.Logs...)
+	}
+	return logs
+}
+
+// TotalGasBurnt sums the gas burnt by the transaction and all of its receipts.
+func (o *FinalExecutionOutcome) TotalGasBurnt() uint64 {
+	total := o.TransactionOutcome.Outcome.GasBurnt
+	for _, r := range o.ReceiptsOutcome {
+		total += r.Outcome.GasBurnt
+	}
+	return total
+}
@@ -0,0 +1,705 @@
+package sim
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+// Gas costs from the protocol fee schedule (ext_costs and action fees).
+const (
+	gasBase                    = 264_768_111
+	gasContractLoadingBase     = 35_445_963
+	gasContractLoadingByte     = 216_719
+	gasReadMemoryBase          = 2_609_863_200
+	gasReadMemoryByte          = 3_801_333
+	gasWriteMemoryBase         = 2_803_794_861
+	gasWriteMemoryByte         = 2_723_772
+	gasReadRegisterBase        = 2_517_165_186
+	gasReadRegisterByte        = 98_562
+	gasWriteRegisterBase       = 2_865_522_486
+	gasWriteRegisterByte       = 3_801_564
+	gasUtf8DecodingBase        = 3_111_779_061
+	gasUtf8DecodingByte        = 291_580_479
+	gasUtf16DecodingBase       = 3_543_313_050
+	gasUtf16DecodingByte       = 163_577_493
+	gasLogBase                 = 3_543_313_050
+	gasLogByte                 = 13_198_791
+	gasSha256Base              = 4_540_970_250
+	gasSha256Byte              = 24_117_351
+	gasKeccak256Base           = 5_879_491_275
+	gasKeccak256Byte           = 21_471_105
+	gasKeccak512Base           = 5_811_388_236
+	gasKeccak512Byte           = 36_649_701
+	gasRipemd160Base           = 853_675_086
+	gasRipemd160Block          = 680_107_584
+	gasEd25519VerifyBase       = 210_000_000_000
+	gasEd25519VerifyByte       = 9_000_000
+	gasStorageWriteBase        = 64_196_736_000
+	gasStorageWriteKeyByte     = 70_482_867
+	gasStorageWriteValueByte   = 31_018_539
+	gasStorageWriteEvictedByte = 32_117_307
+	gasStorageReadBase         = 56_356_845_750
+	gasStorageReadKeyByte      = 30_952_533
+	gasStorageReadValueByte    = 5_611_005
+	gasStorageRemoveBase       = 53_473_030_500
+	gasStorageRemoveKeyByte    = 38_220_384
+	gasStorageRemoveValueByte  = 11_531_556
+	gasStorageHasKeyBase       = 54_039_896_625
+	gasStorageHasKeyByte       = 30_790_845
+	gasPromiseAndBase          = 1_465_013_400
+	gasPromiseAndPerPromise    = 5_452_176
+	gasPromiseReturn           = 560_152_386
+	gasActionReceipt           = 108_059_500_000
+	gasActionFunctionCall      = 2_319_861_500_000
+	gasActionFunctionCallByte  = 2_235_934
+	gasRegularOp               = 822_756
+
+	// storageUsageAccount and storageUsageRecord match the protocol's storage_usage_config.
+	storageUsageAccount = 100
+	storageUsageRecord  = 40
+)
+
+type stateKey struct{}
+
+// callState is everything a single call can see and change.
+type callState struct {
+	contract *Contract
+	view     bool
+	opts     callOptions
+
+	input           []byte
+	registers       map[uint64][]byte
+	storage         map[string][]byte
+	logs            []string
+	returnValue     []byte
+	receipts        []Receipt
+	promises        [][]int
+	returnedPromise int
+
+	gasUsed uint64
+	err     *Error
+
+	// category receives the gas charged by the running host function, computeCategory
+	// the gas of metered instructions.
+	category        string
+	computeCategory string
+	gasByCategory   map[string]uint64
+	ops             api.Global
+	opsLimit        api.MutableGlobal
+	opsCounted      uint64
+	jsonDepth       int
+}
+
+func newCallState(c *Contract, input []byte, view bool, opts callOptions) *callState {
+	storage := make(map[string][]byte, len(c.storage))
+	for k, v := range c.storage {
+		storage[k] = v
+	}
+	return &callState{
+		contract:        c,
+		view:            view,
+		opts:            opts,
+		input:           input,
+		registers:       make(map[uint64][]byte),
+		storage:         storage,
+		returnedPromise: -1,
+		category:        CategoryHost,
+		computeCategory: CategoryCompute,
+		gasByCategory:   make(map[string]uint64),
+	}
+}
+
+// abort stops the guest. The panic is recovered by wazero and turned into the call error.
+func (s *callState) abort(kind, format string, args ...interface{}) {
+	s.err = &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+	panic(s.err)
+}
+
+// failure converts a wazero error into the error recorded by the host, or a trap.
+func (s *callState) failure(err error) error {
+	if s.err != nil {
+		return s.err
+	}
+	return &Error{Kind: KindWasmTrap, Message: err.Error()}
+}
+
+func (s *callState) charge(gas uint64) {
+	if !s.consume(s.category, gas) {
+		s.abort(KindGasExceeded, "exceeded the prepaid gas")
+	}
+}
+
+// consume adds gas to category, or burns all prepaid gas and reports false when it
+// doesn't fit.
+func (s *callState) consume(category string, gas uint64) bool {
+	if gas > math.MaxUint64-s.gasUsed || s.gasUsed+gas > s.opts.gas {
+		s.gasByCategory[category] += s.opts.gas - s.gasUsed
+		s.gasUsed = s.opts.gas
+		return false
+	}
+	s.gasByCategory[category] += gas
+	s.gasUsed += gas
+	return true
+}
+
+func (s *callState) chargeLoading(codeLen int) error {
+	if !s.consume(CategoryLoading, uint64(gasContractLoadingBase+gasContractLoadingByte*codeLen)) {
+		return &Error{Kind: KindGasExceeded, Message: "exceeded the prepaid gas"}
+	}
+	return nil
+}
+
+// attach connects the instruction counter of a new instance.
+func (s *callState) attach(mod api.Module) {
+	s.ops = mod.ExportedGlobal(opsGlobal)
+	s.opsLimit = mod.ExportedGlobal(opsLimitGlobal).(api.MutableGlobal)
+	s.updateOpsLimit()
+}
+
+// syncCompute charges the instructions executed since the last sync. On failure
+// it records GasExceeded unless the call already failed.
+func (s *callState) syncCompute() bool {
+	ops := s.ops.Get()
+	delta := ops - s.opsCounted
+	s.opsCounted = ops
+	if delta > math.MaxUint64/gasRegularOp || !s.consume(s.computeCategory, delta*gasRegularOp) {
+		if s.err == nil {
+			s.err = &Error{Kind: KindGasExceeded, Message: "exceeded the prepaid gas"}
+		}
+		return false
+	}
+	return true
+}
+
+// updateOpsLimit lets the guest run as many instructions as the remaining gas pays for.
+func (s *callState) updateOpsLimit() {
+	s.opsLimit.Set(s.opsCounted + (s.opts.gas-s.gasUsed)/gasRegularOp)
+}
+
+func (s *callState) prohibitedInView(name string) {
+	if s.view {
+		s.abort(KindProhibitedInView, "%s is not allowed in view calls", name)
+	}
+}
+
+func (s *callState) readMemory(mem api.Memory, ptr, length uint64) []byte {
+	s.charge(gasReadMemoryBase + gasReadMemoryByte*length)
+	if ptr > math.MaxUint32 || length > math.MaxUint32 {
+		s.abort(KindHostError, "MemoryAccessViolation")
+	}
+	data, ok := mem.Read(uint32(ptr), uint32(length))
+	if !ok {
+		s.abort(KindHostError, "MemoryAccessViolation")
+	}
+	return append([]byte(nil), data...)
+}
+
+func (s *callState) writeMemory(mem api.Memory, ptr uint64, data []byte) {
+	s.charge(gasWriteMemoryBase + gasWriteMemoryByte*uint64(len(data)))
+	if ptr > math.MaxUint32 || !mem.Write(uint32(ptr), data) {
+		s.abort(KindHostError, "MemoryAccessViolation")
+	}
+}
+
+func (s *callState) writeRegister(id uint64, data []byte) {
+	s.charge(gasWriteRegisterBase + gasWriteRegisterByte*uint64(len(data)))
+	s.registers[id] = append([]byte(nil), data...)
+}
+
+func (s *callState) readU128(mem api.Memory, ptr uint64) *big.Int {
+	data := s.readMemory(mem, ptr, 16)
+	hi := new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[8:]))
+	lo := new(big.Int).SetUint64(binary.LittleEndian.Uint64(data[:8]))
+	return hi.Lsh(hi, 64).Or(hi, lo)
+}
+
+func (s *callState) writeU128(mem api.Memory, ptr uint64, value *big.Int) {
+	data := make([]byte, 16)
+	b := value.Bytes()
+	for i := 0; i < len(b) && i < 16; i++ {
+		data[i] = b[len(b)-1-i]
+	}
+	s.writeMemory(mem, ptr, data)
+}
+
+func (s *callState) readString(mem api.Memory, length, ptr uint64) string {
+	data := s.readMemory(mem, ptr, length)
+	s.charge(gasUtf8DecodingBase + gasUtf8DecodingByte*length)
+	if !utf8.Valid(data) {
+		s.abort(KindHostError, "BadUTF8")
+	}
+	return string(data)
+}
+
+func (s *callState) storageUsage() uint64 {
+	usage := uint64(storageUsageAccount + len(s.contract.code))
+	for k, v := range s.storage {
+		usage += uint64(len(k) + len(v) + storageUsageRecord)
+	}
+	return usage
+}
+
+// newReceipt adds a receipt and a promise index pointing to it.
+func (s *callState) newReceipt(receiverID string, dependsOn []int) uint64 {
+	s.charge(gasActionReceipt)
+	s.receipts = append(s.receipts, Receipt{ReceiverID: receiverID, DependsOn: dependsOn})
+	s.promises = append(s.promises, []int{len(s.receipts) - 1})
+	return uint64(len(s.promises) - 1)
+}
+
+func (s *callState) promise(index uint64) []int {
+	if index >= uint64(len(s.promises)) {
+		s.abort(KindHostError, "InvalidPromiseIndex: %d", index)
+	}
+	return s.promises[index]
+}
+
+// receipt returns the receipt an action is added to. Joint promises can't take actions.
+func (s *callState) receipt(index uint64) *Receipt {
+	receipts := s.promise(index)
+	if len(receipts) != 1 {
+		s.abort(KindHostError, "CannotAppendActionToJointPromise")
+	}
+	return &s.receipts[receipts[0]]
+}
+
+func (s *callState) addFunctionCall(r *Receipt, mem api.Memory, nameLen, namePtr, argsLen, argsPtr, amountPtr, gas uint64) {
+	name := s.readString(mem, nameLen, namePtr)
+	args := s.readMemory(mem, argsPtr, argsLen)
+	deposit := s.readU128(mem, amountPtr)
+	s.charge(gasActionFunctionCall + gasActionFunctionCallByte*(nameLen+argsLen))
+	s.charge(gas)
+	r.Actions = append(r.Actions, Action{Type: "FunctionCall", MethodName: name, Args: args, Deposit: deposit, Gas: gas})
+}
+
+// hostFunc is an env import. All parameters and results are i64.
+type hostFunc struct {
+	params  int
+	results int
+	fn      func(s *callState, mem api.Memory, args []uint64) uint64
+}
+
+var hostFuncs = map[string]hostFunc{
+	// Registers
+	"read_register": {2, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data, ok := s.registers[a[0]]
+		if !ok {
+			s.abort(KindHostError, "InvalidRegisterId: %d", a[0])
+		}
+		s.charge(gasReadRegisterBase + gasReadRegisterByte*uint64(len(data)))
+		s.writeMemory(mem, a[1], data)
+		return 0
+	}},
+	"register_len": {1, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data, ok := s.registers[a[0]]
+		if !ok {
+			return math.MaxUint64
+		}
+		return uint64(len(data))
+	}},
+	"write_register": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeRegister(a[0], s.readMemory(mem, a[2], a[1]))
+		return 0
+	}},
+
+	// Storage
+	"storage_write": {5, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("storage_write")
+		key := s.readMemory(mem, a[1], a[0])
+		value := s.readMemory(mem, a[3], a[2])
+		s.charge(gasStorageWriteBase + gasStorageWriteKeyByte*a[0] + gasStorageWriteValueByte*a[2])
+		old, existed := s.storage[string(key)]
+		s.storage[string(key)] = value
+		if !existed {
+			return 0
+		}
+		s.charge(gasStorageWriteEvictedByte * uint64(len(old)))
+		s.writeRegister(a[4], old)
+		return 1
+	}},
+	"storage_read": {3, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		key := s.readMemory(mem, a[1], a[0])
+		s.charge(gasStorageReadBase + gasStorageReadKeyByte*a[0])
+		value, ok := s.storage[string(key)]
+		if !ok {
+			return 0
+		}
+		s.charge(gasStorageReadValueByte * uint64(len(value)))
+		s.writeRegister(a[2], value)
+		return 1
+	}},
+	"storage_remove": {3, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("storage_remove")
+		key := s.readMemory(mem, a[1], a[0])
+		s.charge(gasStorageRemoveBase + gasStorageRemoveKeyByte*a[0])
+		old, ok := s.storage[string(key)]
+		if !ok {
+			return 0
+		}
+		s.charge(gasStorageRemoveValueByte * uint64(len(old)))
+		delete(s.storage, string(key))
+		s.writeRegister(a[2], old)
+		return 1
+	}},
+	"storage_has_key": {2, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		key := s.readMemory(mem, a[1], a[0])
+		s.charge(gasStorageHasKeyBase + gasStorageHasKeyByte*a[0])
+		if _, ok := s.storage[string(key)]; ok {
+			return 1
+		}
+		return 0
+	}},
+
+	// Context
+	"current_account_id": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeRegister(a[0], []byte(s.contract.AccountID))
+		return 0
+	}},
+	"signer_account_id": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("signer_account_id")
+		s.writeRegister(a[0], []byte(s.opts.signer))
+		return 0
+	}},
+	"signer_account_pk": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("signer_account_pk")
+		s.writeRegister(a[0], s.opts.signerPK)
+		return 0
+	}},
+	"predecessor_account_id": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("predecessor_account_id")
+		s.writeRegister(a[0], []byte(s.opts.predecessor))
+		return 0
+	}},
+	"input": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeRegister(a[0], s.input)
+		return 0
+	}},
+	"block_index": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		return s.contract.BlockHeight
+	}},
+	"block_timestamp": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		return s.contract.BlockTimestamp
+	}},
+	"epoch_height": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		return s.contract.EpochHeight
+	}},
+	"storage_usage": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		return s.storageUsage()
+	}},
+
+	// Economics
+	"account_balance": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeU128(mem, a[0], new(big.Int).Add(s.contract.Balance, s.opts.deposit))
+		return 0
+	}},
+	"account_locked_balance": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeU128(mem, a[0], s.contract.LockedBalance)
+		return 0
+	}},
+	"attached_deposit": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("attached_deposit")
+		s.writeU128(mem, a[0], s.opts.deposit)
+		return 0
+	}},
+	"prepaid_gas": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("prepaid_gas")
+		return s.opts.gas
+	}},
+	"used_gas": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("used_gas")
+		return s.gasUsed
+	}},
+
+	// Math
+	"random_seed": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeRegister(a[0], s.contract.RandomSeed)
+		return 0
+	}},
+	"sha256": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data := s.readMemory(mem, a[1], a[0])
+		s.charge(gasSha256Base + gasSha256Byte*a[0])
+		sum := sha256.Sum256(data)
+		s.writeRegister(a[2], sum[:])
+		return 0
+	}},
+	"keccak256": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data := s.readMemory(mem, a[1], a[0])
+		s.charge(gasKeccak256Base + gasKeccak256Byte*a[0])
+		h := sha3.NewLegacyKeccak256()
+		h.Write(data)
+		s.writeRegister(a[2], h.Sum(nil))
+		return 0
+	}},
+	"keccak512": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data := s.readMemory(mem, a[1], a[0])
+		s.charge(gasKeccak512Base + gasKeccak512Byte*a[0])
+		h := sha3.NewLegacyKeccak512()
+		h.Write(data)
+		s.writeRegister(a[2], h.Sum(nil))
+		return 0
+	}},
+	"ripemd160": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data := s.readMemory(mem, a[1], a[0])
+		s.charge(gasRipemd160Base + gasRipemd160Block*((a[0]+9+63)/64))
+		h := ripemd160.New()
+		h.Write(data)
+		s.writeRegister(a[2], h.Sum(nil))
+		return 0
+	}},
+	"ed25519_verify": {6, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		sig := s.readMemory(mem, a[1], a[0])
+		msg := s.readMemory(mem, a[3], a[2])
+		key := s.readMemory(mem, a[5], a[4])
+		s.charge(gasEd25519VerifyBase + gasEd25519VerifyByte*a[2])
+		if len(sig) != ed25519.SignatureSize {
+			s.abort(KindHostError, "Ed25519VerifyInvalidInput: invalid signature length")
+		}
+		if len(key) != ed25519.PublicKeySize {
+			s.abort(KindHostError, "Ed25519VerifyInvalidInput: invalid public key length")
+		}
+		if ed25519.Verify(key, msg, sig) {
+			return 1
+		}
+		return 0
+	}},
+	"validator_stake": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeU128(mem, a[2], new(big.Int))
+		return 0
+	}},
+	"validator_total_stake": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.writeU128(mem, a[0], new(big.Int))
+		return 0
+	}},
+
+	// Miscellaneous
+	"value_return": {2, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.returnValue = s.readMemory(mem, a[1], a[0])
+		return 0
+	}},
+	"panic": {0, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.abort(KindGuestPanic, "explicit guest panic")
+		return 0
+	}},
+	"panic_utf8": {2, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.abort(KindGuestPanic, "%s", s.readString(mem, a[0], a[1]))
+		return 0
+	}},
+	"log_utf8": {2, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		msg := s.readString(mem, a[0], a[1])
+		s.charge(gasLogBase + gasLogByte*a[0])
+		s.logs = append(s.logs, msg)
+		return 0
+	}},
+	"log_utf16": {2, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		data := s.readMemory(mem, a[1], a[0])
+		s.charge(gasUtf16DecodingBase + gasUtf16DecodingByte*a[0])
+		if len(data)%2 != 0 {
+			s.abort(KindHostError, "BadUTF16")
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(data[2*i:])
+		}
+		s.charge(gasLogBase + gasLogByte*a[0])
+		s.logs = append(s.logs, string(utf16.Decode(units)))
+		return 0
+	}},
+
+	// Promises
+	"promise_create": {8, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_create")
+		index := s.newReceipt(s.readString(mem, a[0], a[1]), nil)
+		s.addFunctionCall(s.receipt(index), mem, a[2], a[3], a[4], a[5], a[6], a[7])
+		return index
+	}},
+	"promise_then": {9, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_then")
+		dependsOn := s.promise(a[0])
+		index := s.newReceipt(s.readString(mem, a[1], a[2]), dependsOn)
+		s.addFunctionCall(s.receipt(index), mem, a[3], a[4], a[5], a[6], a[7], a[8])
+		return index
+	}},
+	"promise_and": {2, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_and")
+		data := s.readMemory(mem, a[0], 8*a[1])
+		s.charge(gasPromiseAndBase + gasPromiseAndPerPromise*a[1])
+		var joint []int
+		for i := uint64(0); i < a[1]; i++ {
+			joint = append(joint, s.promise(binary.LittleEndian.Uint64(data[8*i:]))...)
+		}
+		s.promises = append(s.promises, joint)
+		return uint64(len(s.promises) - 1)
+	}},
+	"promise_batch_create": {2, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_create")
+		return s.newReceipt(s.readString(mem, a[0], a[1]), nil)
+	}},
+	"promise_batch_then": {3, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_then")
+		dependsOn := s.promise(a[0])
+		return s.newReceipt(s.readString(mem, a[1], a[2]), dependsOn)
+	}},
+	"promise_batch_action_create_account": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_create_account")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "CreateAccount"})
+		return 0
+	}},
+	"promise_batch_action_deploy_contract": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_deploy_contract")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "DeployContract", Code: s.readMemory(mem, a[2], a[1])})
+		return 0
+	}},
+	"promise_batch_action_function_call": {7, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_function_call")
+		s.addFunctionCall(s.receipt(a[0]), mem, a[1], a[2], a[3], a[4], a[5], a[6])
+		return 0
+	}},
+	"promise_batch_action_function_call_weight": {8, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_function_call_weight")
+		s.addFunctionCall(s.receipt(a[0]), mem, a[1], a[2], a[3], a[4], a[5], a[6])
+		return 0
+	}},
+	"promise_batch_action_transfer": {2, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_transfer")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "Transfer", Deposit: s.readU128(mem, a[1])})
+		return 0
+	}},
+	"promise_batch_action_stake": {4, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_stake")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "Stake", Deposit: s.readU128(mem, a[1]), PublicKey: s.readMemory(mem, a[3], a[2])})
+		return 0
+	}},
+	"promise_batch_action_add_key_with_full_access": {4, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_add_key_with_full_access")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "AddKey", PublicKey: s.readMemory(mem, a[2], a[1])})
+		return 0
+	}},
+	"promise_batch_action_add_key_with_function_call": {9, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_add_key_with_function_call")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{
+			Type:        "AddKey",
+			PublicKey:   s.readMemory(mem, a[2], a[1]),
+			Deposit:     s.readU128(mem, a[4]),
+			Beneficiary: s.readString(mem, a[5], a[6]),
+			MethodName:  s.readString(mem, a[7], a[8]),
+		})
+		return 0
+	}},
+	"promise_batch_action_delete_key": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_delete_key")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "DeleteKey", PublicKey: s.readMemory(mem, a[2], a[1])})
+		return 0
+	}},
+	"promise_batch_action_delete_account": {3, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_batch_action_delete_account")
+		r := s.receipt(a[0])
+		r.Actions = append(r.Actions, Action{Type: "DeleteAccount", Beneficiary: s.readString(mem, a[1], a[2])})
+		return 0
+	}},
+	"promise_results_count": {0, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_results_count")
+		return uint64(len(s.opts.promiseResults))
+	}},
+	"promise_result": {2, 1, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_result")
+		if a[0] >= uint64(len(s.opts.promiseResults)) {
+			s.abort(KindHostError, "InvalidPromiseResultIndex: %d", a[0])
+		}
+		result := s.opts.promiseResults[a[0]]
+		if !result.Success {
+			return 2
+		}
+		s.writeRegister(a[1], result.Data)
+		return 1
+	}},
+	"promise_return": {1, 0, func(s *callState, mem api.Memory, a []uint64) uint64 {
+		s.prohibitedInView("promise_return")
+		s.charge(gasPromiseReturn)
+		receipts := s.promise(a[0])
+		s.returnedPromise = receipts[len(receipts)-1]
+		return 0
+	}},
+}
+
+// instantiateEnv provides the 'env' module. Imports the simulator doesn't implement
+// (e.g. alt_bn128, ecrecover, yield/resume) fail with a HostError when called.
+func instantiateEnv(ctx context.Context, runtime wazero.Runtime, guest wazero.CompiledModule) error {
+	builder := runtime.NewHostModuleBuilder("env")
+	for _, def := range guest.ImportedFunctions() {
+		module, name, _ := def.Import()
+		if module != "env" {
+			return fmt.Errorf("unsupported import module '%s' (%s)", module, name)
+		}
+
+		params, results := def.ParamTypes(), def.ResultTypes()
+		host, ok := hostFuncs[name]
+		if ok && !matchesSignature(host, params, results) {
+			return fmt.Errorf("import '%s' has an unexpected signature", name)
+		}
+
+		var fn api.GoModuleFunction
+		if ok {
+			fn = wrapHostFunc(hostCategory(name), host)
+		} else {
+			fn = unsupportedHostFunc(name)
+		}
+		builder.NewFunctionBuilder().WithGoModuleFunction(fn, params, results).Export(name)
+	}
+	_, err := builder.Instantiate(ctx)
+	return err
+}
+
+func matchesSignature(host hostFunc, params, results []api.ValueType) bool {
+	if len(params) != host.params || len(results) != host.results {
+		return false
+	}
+	for _, t := range append(params, results...) {
+		if t != api.ValueTypeI64 {
+			return false
+		}
+	}
+	return true
+}
+
+func wrapHostFunc(category string, host hostFunc) api.GoModuleFunction {
+	return api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		s := ctx.Value(stateKey{}).(*callState)
+		if !s.syncCompute() {
+			panic(s.err)
+		}
+		s.category = category
+		s.charge(gasBase)
+		args := append([]uint64(nil), stack[:host.params]...)
+		result := host.fn(s, mod.Memory(), args)
+		if host.results == 1 {
+			stack[0] = result
+		}
+		s.updateOpsLimit()
+	})
+}
+
+func unsupportedHostFunc(name string) api.GoModuleFunction {
+	return api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+		s := ctx.Value(stateKey{}).(*callState)
+		s.abort(KindHostError, "%s is not supported by the simulator", name)
+	})
+}
@@ -0,0 +1,126 @@
+package sim
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// Gas categories reported in Profile.Gas.
+const (
+	CategoryLoading      = "loading"
+	CategoryCompute      = "compute"
+	CategoryJSON         = "json"
+	CategoryStorageRead  = "storage_read"
+	CategoryStorageWrite = "storage_write"
+	CategoryLog          = "log"
+	CategoryPromise      = "promise"
+	CategoryHost         = "host"
+)
+
+// Categories lists the gas categories in report order.
+var Categories = []string{
+	CategoryLoading, CategoryCompute, CategoryJSON, CategoryStorageRead,
+	CategoryStorageWrite, CategoryLog, CategoryPromise, CategoryHost,
+}
+
+// StorageCostPerByte is the storage staking price in yoctoNEAR (storage_amount_per_byte).
+var StorageCostPerByte = new(big.Int).Exp(big.NewInt(10), big.NewInt(19), nil)
+
+// Exports added by wasm.Meter to count executed instructions.
+const (
+	opsGlobal      = "__near_go_sim_ops"
+	opsLimitGlobal = "__near_go_sim_ops_limit"
+)
+
+// Profile breaks down the gas burnt and the storage changed by a call.
+type Profile struct {
+	Gas            map[string]uint64
+	StorageAdded   uint64
+	StorageRemoved uint64
+}
+
+// StorageCost is the change of the balance locked for storage in yoctoNEAR,
+// negative when the call freed storage.
+func (p Profile) StorageCost() *big.Int {
+	bytes := new(big.Int).SetUint64(p.StorageAdded)
+	bytes.Sub(bytes, new(big.Int).SetUint64(p.StorageRemoved))
+	return bytes.Mul(bytes, StorageCostPerByte)
+}
+
+func hostCategory(name string) string {
+	switch {
+	case name == "storage_write" || name == "storage_remove":
+		return CategoryStorageWrite
+	case name == "storage_read" || name == "storage_has_key":
+		return CategoryStorageRead
+	case strings.HasPrefix(name, "log_"):
+		return CategoryLog
+	case strings.HasPrefix(name, "promise_"):
+		return CategoryPromise
+	}
+	return CategoryHost
+}
+
+// storageDelta returns the bytes of storage_usage added and removed between two states.
+func storageDelta(before, after map[string][]byte) (added, removed uint64) {
+	for k, v := range after {
+		old, ok := before[k]
+		switch {
+		case !ok:
+			added += uint64(len(k) + len(v) + storageUsageRecord)
+		case len(v) > len(old):
+			added += uint64(len(v) - len(old))
+		default:
+			removed += uint64(len(old) - len(v))
+		}
+	}
+	for k, v := range before {
+		if _, ok := after[k]; !ok {
+			removed += uint64(len(k) + len(v) + storageUsageRecord)
+		}
+	}
+	return added, removed
+}
+
+// isJSONFunction matches the JSON packages used by contracts and near-sdk-go.
+// Names are only available when the contract keeps its name section.
+func isJSONFunction(name string) bool {
+	return strings.Contains(name, "encoding/json.") || strings.Contains(name, "jsonparser.")
+}
+
+// jsonListener moves the instructions executed inside JSON functions, including
+// everything they call, from CategoryCompute to CategoryJSON.
+type jsonListener struct{}
+
+func (jsonListener) NewFunctionListener(def api.FunctionDefinition) experimental.FunctionListener {
+	if !isJSONFunction(def.Name()) {
+		return nil
+	}
+	return jsonListener{}
+}
+
+func (jsonListener) Before(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	s := ctx.Value(stateKey{}).(*callState)
+	if s.jsonDepth == 0 {
+		s.syncCompute()
+		s.computeCategory = CategoryJSON
+	}
+	s.jsonDepth++
+}
+
+func (l jsonListener) After(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64) {
+	s := ctx.Value(stateKey{}).(*callState)
+	s.jsonDepth--
+	if s.jsonDepth == 0 {
+		s.syncCompute()
+		s.computeCategory = CategoryCompute
+	}
+}
+
+func (l jsonListener) Abort(ctx context.Context, mod api.Module, def api.FunctionDefinition, _ error) {
+	l.After(ctx, mod, def, nil)
+}
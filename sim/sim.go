@@ -0,0 +1,387 @@
+// Package sim runs compiled NEAR contracts in-process.
+//
+// The contract WASM is executed with wazero and the NEAR 'env' host functions are
+// implemented in Go: registers, storage, context, economics, logs, hashing and
+// promises. Every call runs in a fresh instance like on chain, storage persists
+// between calls of the same Contract and is rolled back when a call fails.
+// Promises are recorded as receipts but not executed; callbacks are tested by
+// calling them with WithPromiseResults.
+//
+// Gas is charged for contract loading, host function calls and WASM instructions
+// using the protocol fee schedule. Instructions are metered by instrumenting the
+// code with wasm.Meter, so loops that run out of gas stop with GasExceeded.
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/vlmoon99/near-cli-go/wasm"
+)
+
+const (
+	// WasmEnv holds the path of the contract built by 'near-go test sim'.
+	WasmEnv = "NEAR_GO_SIM_WASM"
+
+	DefaultAccountID   = "contract.test.near"
+	DefaultPredecessor = "alice.test.near"
+	DefaultGas         = 300_000_000_000_000
+)
+
+const (
+	ErrLoadFailed    = "(SIM_ERROR): failed to load contract"
+	ErrInvalidArgs   = "(SIM_ERROR): failed to encode args"
+	ErrInvalidResult = "(SIM_ERROR): failed to decode result"
+)
+
+// Error kinds reported in *Error.
+const (
+	KindGuestPanic       = "GuestPanic"
+	KindWasmTrap         = "WasmTrap"
+	KindHostError        = "HostError"
+	KindGasExceeded      = "GasExceeded"
+	KindProhibitedInView = "ProhibitedInView"
+	KindMethodNotFound   = "MethodNotFound"
+)
+
+// Error is a failed contract execution.
+type Error struct {
+	Kind    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("(SIM_ERROR): %s: %s", e.Kind, e.Message)
+}
+
+// IsPanic reports whether err is a contract panic whose message equals msg.
+func IsPanic(err error, msg string) bool {
+	var simErr *Error
+	return errors.As(err, &simErr) && simErr.Kind == KindGuestPanic && simErr.Message == msg
+}
+
+// Contract is a deployed contract with its own storage, balance and block context.
+type Contract struct {
+	AccountID      string
+	Balance        *big.Int
+	LockedBalance  *big.Int
+	BlockHeight    uint64
+	BlockTimestamp uint64
+	EpochHeight    uint64
+	RandomSeed     []byte
+
+	code []byte
+	// loadingSize excludes DWARF sections, which release builds don't have.
+	loadingSize int
+	profiling   bool
+	storage     map[string][]byte
+	runtime     wazero.Runtime
+	compiled    wazero.CompiledModule
+}
+
+// Option configures a Contract.
+type Option func(*Contract)
+
+func WithAccountID(accountID string) Option {
+	return func(c *Contract) { c.AccountID = accountID }
+}
+
+func WithBalance(yocto *big.Int) Option {
+	return func(c *Contract) { c.Balance = new(big.Int).Set(yocto) }
+}
+
+// WithProfiling attributes the instructions executed by JSON functions to
+// CategoryJSON. It needs a build with the name section, i.e. without -no-debug.
+func WithProfiling() Option {
+	return func(c *Contract) { c.profiling = true }
+}
+
+// New compiles code and prepares an empty account for it.
+func New(code []byte, opts ...Option) (*Contract, error) {
+	c := &Contract{
+		AccountID:      DefaultAccountID,
+		Balance:        new(big.Int),
+		LockedBalance:  new(big.Int),
+		BlockHeight:    1,
+		BlockTimestamp: 1_700_000_000_000_000_000,
+		EpochHeight:    1,
+		RandomSeed:     make([]byte, 32),
+		code:           code,
+		storage:        make(map[string][]byte),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	module, err := wasm.Parse(code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrLoadFailed, err)
+	}
+	c.loadingSize = len(code)
+	for _, s := range module.Sections {
+		if s.ID == wasm.SectionCustom && strings.HasPrefix(s.Name, ".debug") {
+			c.loadingSize -= s.Offset + s.Size - s.Start
+		}
+	}
+	metered, err := wasm.Meter(code, opsGlobal, opsLimitGlobal)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrLoadFailed, err)
+	}
+
+	ctx := context.Background()
+	c.runtime = wazero.NewRuntime(ctx)
+	compileCtx := ctx
+	if c.profiling {
+		compileCtx = experimental.WithFunctionListenerFactory(ctx, jsonListener{})
+	}
+	compiled, err := c.runtime.CompileModule(compileCtx, metered)
+	if err != nil {
+		c.runtime.Close(ctx)
+		return nil, fmt.Errorf("%s: %w", ErrLoadFailed, err)
+	}
+	c.compiled = compiled
+
+	if err := instantiateEnv(ctx, c.runtime, compiled); err != nil {
+		c.runtime.Close(ctx)
+		return nil, fmt.Errorf("%s: %w", ErrLoadFailed, err)
+	}
+	return c, nil
+}
+
+// Load reads and compiles the WASM file at path.
+func Load(path string, opts ...Option) (*Contract, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrLoadFailed, err)
+	}
+	return New(code, opts...)
+}
+
+// DefaultWasmPath returns the contract built by 'near-go test sim', or '../main.wasm'
+// when the tests run on their own from the 'sim' folder.
+func DefaultWasmPath() string {
+	if path := os.Getenv(WasmEnv); path != "" {
+		return path
+	}
+	return "../main.wasm"
+}
+
+func (c *Contract) Close() error {
+	return c.runtime.Close(context.Background())
+}
+
+// Methods lists the exported functions of the contract.
+func (c *Contract) Methods() []string {
+	var names []string
+	for name := range c.compiled.ExportedFunctions() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StorageRead returns the raw value stored under key.
+func (c *Contract) StorageRead(key []byte) ([]byte, bool) {
+	value, ok := c.storage[string(key)]
+	return value, ok
+}
+
+// StorageWrite sets a raw storage entry, e.g. to prepare state for a migration.
+func (c *Contract) StorageWrite(key, value []byte) {
+	c.storage[string(key)] = append([]byte(nil), value...)
+}
+
+// Storage returns a copy of all storage entries.
+func (c *Contract) Storage() map[string][]byte {
+	out := make(map[string][]byte, len(c.storage))
+	for k, v := range c.storage {
+		out[k] = append([]byte(nil), v...)
+	}
+	return out
+}
+
+// PromiseResult is the outcome of a promise as seen by a callback.
+type PromiseResult struct {
+	Success bool
+	Data    []byte
+}
+
+func Successful(data []byte) PromiseResult {
+	return PromiseResult{Success: true, Data: data}
+}
+
+func Failed() PromiseResult {
+	return PromiseResult{}
+}
+
+type callOptions struct {
+	predecessor    string
+	signer         string
+	signerPK       []byte
+	deposit        *big.Int
+	gas            uint64
+	promiseResults []PromiseResult
+}
+
+// CallOption configures a single call.
+type CallOption func(*callOptions)
+
+// WithPredecessor sets the calling account. It is also the signer unless WithSigner is given.
+func WithPredecessor(accountID string) CallOption {
+	return func(o *callOptions) { o.predecessor = accountID }
+}
+
+func WithSigner(accountID string) CallOption {
+	return func(o *callOptions) { o.signer = accountID }
+}
+
+func WithSignerPublicKey(key []byte) CallOption {
+	return func(o *callOptions) { o.signerPK = key }
+}
+
+// WithDeposit attaches yocto yoctoNEAR to the call.
+func WithDeposit(yocto *big.Int) CallOption {
+	return func(o *callOptions) { o.deposit = new(big.Int).Set(yocto) }
+}
+
+func WithGas(gas uint64) CallOption {
+	return func(o *callOptions) { o.gas = gas }
+}
+
+// WithPromiseResults runs the call as a callback receiving results.
+func WithPromiseResults(results ...PromiseResult) CallOption {
+	return func(o *callOptions) { o.promiseResults = results }
+}
+
+// Action is a single action of an outgoing receipt.
+type Action struct {
+	Type        string
+	MethodName  string
+	Args        []byte
+	Deposit     *big.Int
+	Gas         uint64
+	Code        []byte
+	PublicKey   []byte
+	Beneficiary string
+}
+
+// Receipt is an outgoing receipt created by the promise host functions.
+type Receipt struct {
+	ReceiverID string
+	Actions    []Action
+	// DependsOn lists the receipts that must finish first (promise_then).
+	DependsOn []int
+}
+
+// Result is the outcome of a call. On failure it still carries the logs and gas.
+type Result struct {
+	Value    []byte
+	Logs     []string
+	GasBurnt uint64
+	Receipts []Receipt
+	// ReturnedPromise is the receipt index passed to promise_return, or -1.
+	ReturnedPromise int
+	Profile         Profile
+}
+
+// Unmarshal decodes the JSON return value into v.
+func (r *Result) Unmarshal(v interface{}) error {
+	if err := json.Unmarshal(r.Value, v); err != nil {
+		return fmt.Errorf("%s: %w", ErrInvalidResult, err)
+	}
+	return nil
+}
+
+// Call runs a change method. args are sent as-is when they are []byte or
+// json.RawMessage, otherwise JSON encoded; nil sends no input.
+func (c *Contract) Call(method string, args interface{}, opts ...CallOption) (*Result, error) {
+	return c.run(method, args, false, opts)
+}
+
+// View runs a view method. Host functions that change state or depend on the
+// caller fail with ProhibitedInView, and storage is never modified.
+func (c *Contract) View(method string, args interface{}, opts ...CallOption) (*Result, error) {
+	return c.run(method, args, true, opts)
+}
+
+func (c *Contract) run(method string, args interface{}, view bool, opts []CallOption) (*Result, error) {
+	input, err := encodeArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	o := callOptions{predecessor: DefaultPredecessor, deposit: new(big.Int), gas: DefaultGas}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.signer == "" {
+		o.signer = o.predecessor
+	}
+
+	st := newCallState(c, input, view, o)
+	result := &Result{ReturnedPromise: -1}
+	defer func() {
+		result.Logs = st.logs
+		result.GasBurnt = st.gasUsed
+		result.Profile.Gas = st.gasByCategory
+	}()
+
+	if _, ok := c.compiled.ExportedFunctions()[method]; !ok {
+		return result, &Error{Kind: KindMethodNotFound, Message: method}
+	}
+	if err := st.chargeLoading(c.loadingSize); err != nil {
+		return result, err
+	}
+
+	ctx := context.WithValue(context.Background(), stateKey{}, st)
+	mod, err := c.runtime.InstantiateModule(ctx, c.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions())
+	if err != nil {
+		return result, st.failure(err)
+	}
+	defer mod.Close(ctx)
+	st.attach(mod)
+
+	fn := mod.ExportedFunction(method)
+	if len(fn.Definition().ParamTypes()) != 0 {
+		return result, &Error{Kind: KindMethodNotFound, Message: method + " takes parameters"}
+	}
+	_, err = fn.Call(ctx)
+	st.syncCompute()
+	if err != nil || st.err != nil {
+		return result, st.failure(err)
+	}
+
+	result.Value = st.returnValue
+	result.Receipts = st.receipts
+	result.ReturnedPromise = st.returnedPromise
+	if !view {
+		result.Profile.StorageAdded, result.Profile.StorageRemoved = storageDelta(c.storage, st.storage)
+		c.storage = st.storage
+		c.Balance.Add(c.Balance, o.deposit)
+	}
+	return result, nil
+}
+
+func encodeArgs(args interface{}) ([]byte, error) {
+	switch v := args.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case json.RawMessage:
+		return v, nil
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrInvalidArgs, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,390 @@
+package sim
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// Host imports of the test module, in function index order.
+const (
+	fnInput = iota
+	fnRegisterLen
+	fnReadRegister
+	fnValueReturn
+	fnStorageWrite
+	fnStorageRead
+	fnLogUtf8
+	fnPanicUtf8
+	fnAttachedDeposit
+	fnPredecessorAccountID
+	fnPromiseBatchCreate
+	fnPromiseBatchActionTransfer
+	fnPromiseReturn
+	fnAltBn128PairingCheck
+	fnPromiseResult
+)
+
+type testImport struct {
+	name            string
+	params, results int
+}
+
+var testImports = []testImport{
+	{"input", 1, 0},
+	{"register_len", 1, 1},
+	{"read_register", 2, 0},
+	{"value_return", 2, 0},
+	{"storage_write", 5, 1},
+	{"storage_read", 3, 1},
+	{"log_utf8", 2, 0},
+	{"panic_utf8", 2, 0},
+	{"attached_deposit", 1, 0},
+	{"predecessor_account_id", 1, 0},
+	{"promise_batch_create", 2, 1},
+	{"promise_batch_action_transfer", 2, 0},
+	{"promise_return", 1, 0},
+	{"alt_bn128_pairing_check", 2, 1},
+	{"promise_result", 2, 1},
+}
+
+// testData is placed at offset 0: "boom" at 0, "bob.near" at 4.
+const testData = "boombob.near"
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+// Instructions
+func i64(v int64) []byte        { return append([]byte{0x42}, sleb(v)...) }
+func call(fn int) []byte        { return append([]byte{0x10}, uleb(uint64(fn))...) }
+func localGet(i int) []byte     { return []byte{0x20, byte(i)} }
+func localSet(i int) []byte     { return []byte{0x21, byte(i)} }
+func code(ins ...[]byte) []byte { return bytes.Join(ins, nil) }
+
+var drop = []byte{0x1a}
+
+// readInput copies the input to memory offset 1024 and keeps its length in local 0.
+var readInput = code(i64(0), call(fnInput), i64(0), i64(1024), call(fnReadRegister), i64(0), call(fnRegisterLen), localSet(0))
+
+var testFuncs = []struct {
+	export string
+	body   []byte
+}{
+	{"echo", code(readInput, localGet(0), i64(1024), call(fnValueReturn))},
+	{"put", code(readInput, localGet(0), i64(1024), localGet(0), i64(1024), i64(1), call(fnStorageWrite), drop)},
+	{"get", code(readInput, localGet(0), i64(1024), i64(1), call(fnStorageRead), drop,
+		i64(1), i64(2048), call(fnReadRegister), i64(1), call(fnRegisterLen), i64(2048), call(fnValueReturn))},
+	{"log", code(readInput, localGet(0), i64(1024), call(fnLogUtf8))},
+	{"fail", code(i64(4), i64(0), i64(4), i64(0), i64(0), call(fnStorageWrite), drop, i64(4), i64(0), call(fnPanicUtf8))},
+	{"deposit", code(i64(1024), call(fnAttachedDeposit), i64(16), i64(1024), call(fnValueReturn))},
+	{"whoami", code(i64(0), call(fnPredecessorAccountID), i64(0), i64(1024), call(fnReadRegister),
+		i64(0), call(fnRegisterLen), i64(1024), call(fnValueReturn))},
+	{"pay", code(i64(8), i64(4), call(fnPromiseBatchCreate), localSet(0), i64(1024), call(fnAttachedDeposit),
+		localGet(0), i64(1024), call(fnPromiseBatchActionTransfer), localGet(0), call(fnPromiseReturn))},
+	{"unsupported", code(i64(0), i64(0), call(fnAltBn128PairingCheck), drop)},
+	{"trap", []byte{0x00}},
+	{"callback", code(i64(0), i64(0), call(fnPromiseResult), drop, i64(0), i64(1024), call(fnReadRegister),
+		i64(0), call(fnRegisterLen), i64(1024), call(fnValueReturn))},
+	{"spin", []byte{0x03, 0x40, 0x0c, 0x00, 0x0b}},
+	{"parse", code(i64(1), drop, i64(2), drop, i64(3), drop)},
+}
+
+// jsonFunc is the name section entry of 'parse', so profiling counts it as JSON.
+const jsonFunc = "encoding/json.Unmarshal"
+
+func testModule() []byte {
+	var types, imports [][]byte
+	for i, imp := range testImports {
+		params := bytes.Repeat([]byte{0x7e}, imp.params)
+		results := bytes.Repeat([]byte{0x7e}, imp.results)
+		types = append(types, append(append([]byte{0x60}, append(uleb(uint64(imp.params)), params...)...), append(uleb(uint64(imp.results)), results...)...))
+		imports = append(imports, append(append(name("env"), name(imp.name)...), append([]byte{0x00}, uleb(uint64(i))...)...))
+	}
+	types = append(types, []byte{0x60, 0x00, 0x00})
+	voidType := uleb(uint64(len(testImports)))
+
+	var funcs, exports, bodies [][]byte
+	exports = append(exports, append(name("memory"), 0x02, 0x00))
+	for i, fn := range testFuncs {
+		funcs = append(funcs, voidType)
+		exports = append(exports, append(append(name(fn.export), 0x00), uleb(uint64(len(testImports)+i))...))
+		body := append([]byte{0x01, 0x01, 0x7e}, fn.body...)
+		body = append(body, 0x0b)
+		bodies = append(bodies, append(uleb(uint64(len(body))), body...))
+	}
+
+	data := append([]byte{0x00, 0x41, 0x00, 0x0b}, name(testData)...)
+
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	module = append(module, section(1, vec(types...))...)
+	module = append(module, section(2, vec(imports...))...)
+	module = append(module, section(3, vec(funcs...))...)
+	module = append(module, section(5, vec([]byte{0x00, 0x01}))...)
+	module = append(module, section(7, vec(exports...))...)
+	module = append(module, section(10, vec(bodies...))...)
+	module = append(module, section(11, vec(data))...)
+
+	parse := uleb(uint64(len(testImports) + len(testFuncs) - 1))
+	functionNames := vec(append(parse, name(jsonFunc)...))
+	module = append(module, section(0, append(name("name"), section(1, functionNames)...))...)
+	return module
+}
+
+func newTestContract(t *testing.T) *Contract {
+	t.Helper()
+	c, err := New(testModule())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestCallReturnsValue(t *testing.T) {
+	c := newTestContract(t)
+
+	result, err := c.Call("echo", map[string]string{"key": "value"})
+	if err != nil {
+		t.Fatalf("Call() failed: %v", err)
+	}
+	var decoded map[string]string
+	if err := result.Unmarshal(&decoded); err != nil || decoded["key"] != "value" {
+		t.Errorf("Unmarshal() = %v, %v", decoded, err)
+	}
+	if result.GasBurnt == 0 {
+		t.Error("GasBurnt = 0, want the host calls to be charged")
+	}
+
+	result, err = c.Call("echo", []byte("raw"))
+	if err != nil || string(result.Value) != "raw" {
+		t.Errorf("Call(raw) = %q, %v", result.Value, err)
+	}
+}
+
+func TestStoragePersists(t *testing.T) {
+	c := newTestContract(t)
+
+	if _, err := c.Call("put", []byte("k")); err != nil {
+		t.Fatalf("Call(put) failed: %v", err)
+	}
+	result, err := c.View("get", []byte("k"))
+	if err != nil || string(result.Value) != "k" {
+		t.Errorf("View(get) = %q, %v", result.Value, err)
+	}
+	if value, ok := c.StorageRead([]byte("k")); !ok || string(value) != "k" {
+		t.Errorf("StorageRead(k) = %q, %v", value, ok)
+	}
+}
+
+func TestPanicRollsBackStorage(t *testing.T) {
+	c := newTestContract(t)
+
+	_, err := c.Call("fail", nil)
+	if !IsPanic(err, "boom") {
+		t.Fatalf("Call(fail) error = %v, want a 'boom' panic", err)
+	}
+	if _, ok := c.StorageRead([]byte("boom")); ok {
+		t.Error("storage written by a failed call was kept")
+	}
+}
+
+func TestViewProhibitsStateChanges(t *testing.T) {
+	c := newTestContract(t)
+
+	for _, method := range []string{"put", "whoami", "deposit"} {
+		_, err := c.View(method, []byte("k"))
+		simErr, ok := err.(*Error)
+		if !ok || simErr.Kind != KindProhibitedInView {
+			t.Errorf("View(%s) error = %v, want %s", method, err, KindProhibitedInView)
+		}
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	c := newTestContract(t)
+
+	result, err := c.Call("whoami", nil, WithPredecessor("bob.near"))
+	if err != nil || string(result.Value) != "bob.near" {
+		t.Errorf("Call(whoami) = %q, %v", result.Value, err)
+	}
+
+	deposit, _ := new(big.Int).SetString("1000000000000000000000001", 10)
+	result, err = c.Call("deposit", nil, WithDeposit(deposit))
+	if err != nil {
+		t.Fatalf("Call(deposit) failed: %v", err)
+	}
+	want := []byte{0x01, 0x00, 0x00, 0xa1, 0xed, 0xcc, 0xce, 0x1b, 0xc2, 0xd3, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	if !bytes.Equal(result.Value, want) {
+		t.Errorf("attached_deposit = %x, want %x", result.Value, want)
+	}
+	if c.Balance.Cmp(deposit) != 0 {
+		t.Errorf("Balance = %s, want %s", c.Balance, deposit)
+	}
+}
+
+func TestLogsAndPromises(t *testing.T) {
+	c := newTestContract(t)
+
+	result, err := c.Call("log", []byte("hello"))
+	if err != nil || len(result.Logs) != 1 || result.Logs[0] != "hello" {
+		t.Errorf("Call(log) logs = %v, %v", result.Logs, err)
+	}
+
+	result, err = c.Call("pay", nil, WithDeposit(big.NewInt(5)))
+	if err != nil {
+		t.Fatalf("Call(pay) failed: %v", err)
+	}
+	if len(result.Receipts) != 1 || result.ReturnedPromise != 0 {
+		t.Fatalf("Receipts = %+v, ReturnedPromise = %d", result.Receipts, result.ReturnedPromise)
+	}
+	receipt := result.Receipts[0]
+	if receipt.ReceiverID != "bob.near" || len(receipt.Actions) != 1 || receipt.Actions[0].Type != "Transfer" || receipt.Actions[0].Deposit.Int64() != 5 {
+		t.Errorf("Receipt = %+v, want a 5 yoctoNEAR transfer to bob.near", receipt)
+	}
+}
+
+func TestPromiseResults(t *testing.T) {
+	c := newTestContract(t)
+
+	result, err := c.Call("callback", nil, WithPromiseResults(Successful([]byte(`"ok"`))))
+	if err != nil || string(result.Value) != `"ok"` {
+		t.Errorf("Call(callback) = %q, %v", result.Value, err)
+	}
+
+	if _, err := c.Call("callback", nil); err == nil || !strings.Contains(err.Error(), "InvalidPromiseResultIndex") {
+		t.Errorf("Call(callback) without results error = %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newTestContract(t)
+
+	tests := []struct {
+		method string
+		opts   []CallOption
+		kind   string
+	}{
+		{"missing", nil, KindMethodNotFound},
+		{"trap", nil, KindWasmTrap},
+		{"unsupported", nil, KindHostError},
+		{"log", []CallOption{WithGas(1_000_000_000)}, KindGasExceeded},
+	}
+
+	for _, tt := range tests {
+		_, err := c.Call(tt.method, []byte("x"), tt.opts...)
+		simErr, ok := err.(*Error)
+		if !ok || simErr.Kind != tt.kind {
+			t.Errorf("Call(%s) error = %v, want %s", tt.method, err, tt.kind)
+		}
+	}
+}
+
+func TestGasMetering(t *testing.T) {
+	c := newTestContract(t)
+
+	result, err := c.Call("spin", nil)
+	if simErr, ok := err.(*Error); !ok || simErr.Kind != KindGasExceeded {
+		t.Fatalf("Call(spin) error = %v, want %s", err, KindGasExceeded)
+	}
+	if result.GasBurnt != DefaultGas || result.Profile.Gas[CategoryCompute] == 0 {
+		t.Errorf("GasBurnt = %d, Profile = %v", result.GasBurnt, result.Profile.Gas)
+	}
+
+	result, err = c.Call("echo", []byte("x"))
+	if err != nil {
+		t.Fatalf("Call(echo) failed: %v", err)
+	}
+	var total uint64
+	for _, category := range Categories {
+		total += result.Profile.Gas[category]
+	}
+	if total != result.GasBurnt || result.Profile.Gas[CategoryLoading] == 0 || result.Profile.Gas[CategoryCompute] == 0 || result.Profile.Gas[CategoryHost] == 0 {
+		t.Errorf("Profile = %v, GasBurnt = %d", result.Profile.Gas, result.GasBurnt)
+	}
+}
+
+func TestProfileStorage(t *testing.T) {
+	c := newTestContract(t)
+
+	result, err := c.Call("put", []byte("key"))
+	if err != nil {
+		t.Fatalf("Call(put) failed: %v", err)
+	}
+	if result.Profile.StorageAdded != 3+3+40 || result.Profile.StorageRemoved != 0 || result.Profile.Gas[CategoryStorageWrite] == 0 {
+		t.Errorf("Profile = %+v", result.Profile)
+	}
+	if cost := result.Profile.StorageCost().String(); cost != "460000000000000000000" {
+		t.Errorf("StorageCost() = %s", cost)
+	}
+
+	result, err = c.View("get", []byte("key"))
+	if err != nil || result.Profile.StorageAdded != 0 || result.Profile.Gas[CategoryStorageRead] == 0 {
+		t.Errorf("View(get) profile = %+v, %v", result.Profile, err)
+	}
+}
+
+func TestProfilingJSON(t *testing.T) {
+	result, err := newTestContract(t).Call("parse", nil)
+	if err != nil || result.Profile.Gas[CategoryJSON] != 0 {
+		t.Errorf("Call(parse) without profiling = %v, %v", result.Profile.Gas, err)
+	}
+
+	c, err := New(testModule(), WithProfiling())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer c.Close()
+	result, err = c.Call("parse", nil)
+	if err != nil || result.Profile.Gas[CategoryJSON] == 0 || result.Profile.Gas[CategoryCompute] != 0 {
+		t.Errorf("Call(parse) with profiling = %v, %v", result.Profile.Gas, err)
+	}
+}
+
+func TestUnsupportedImportModule(t *testing.T) {
+	module := []byte("\x00asm\x01\x00\x00\x00")
+	module = append(module, section(1, vec([]byte{0x60, 0x00, 0x00}))...)
+	module = append(module, section(2, vec(append(append(name("wasi_snapshot_preview1"), name("proc_exit")...), 0x00, 0x00)))...)
+
+	if _, err := New(module); err == nil || !strings.Contains(err.Error(), "wasi_snapshot_preview1") {
+		t.Errorf("New() error = %v, want the unsupported import module", err)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/vlmoon99/near-cli-go/sim"
+	"github.com/vlmoon99/near-cli-go/wasm"
+)
+
+// sizeSnapshot is saved next to the WASM so the next report can show what changed.
+type sizeSnapshot struct {
+	WasmSha256 string           `json:"wasm_sha256"`
+	Report     *wasm.SizeReport `json:"report"`
+	Previous   *wasm.SizeReport `json:"previous,omitempty"`
+}
+
+// sizeReportPathForWasm mirrors abiPathForWasm: 'size.json' for main.wasm,
+// '<name>.size.json' otherwise.
+func sizeReportPathForWasm(wasmPath string) string {
+	dir, base := filepath.Split(wasmPath)
+	if base == "main.wasm" {
+		return filepath.Join(dir, SizeReportFileName)
+	}
+	return filepath.Join(dir, strings.TrimSuffix(base, ".wasm")+"."+SizeReportFileName)
+}
+
+// generatedExports returns the names of the '//go:export' functions in the
+// generated glue code, i.e. the exports backed by @contract annotations.
+func generatedExports(generatedCode string) []string {
+	var exports []string
+	for _, line := range strings.Split(generatedCode, "\n") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "//go:export "); ok {
+			exports = append(exports, strings.TrimSpace(name))
+		}
+	}
+	return exports
+}
+
+// optimizeWasm shrinks the WASM at wasmPath in place: exports without an
+// annotation are dropped with the code only they reach, wasm-opt runs when
+// allowed and found on PATH, and custom sections are stripped. The size report
+// is printed against the previous build and saved for the next one.
+func optimizeWasm(wasmPath string, exports []string, useWasmOpt bool) error {
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	original := len(data)
+
+	keep := map[string]bool{}
+	for _, name := range exports {
+		keep[name] = true
+	}
+	data, stats, err := wasm.EliminateDeadCode(data, func(name string) bool { return keep[name] })
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrOptimizeFailed, err)
+	}
+	if len(stats.RemovedExports) > 0 {
+		fmt.Printf("✂️  Removed exports without annotation: %s\n", strings.Join(stats.RemovedExports, ", "))
+	}
+	if stats.RemovedFunctions > 0 {
+		fmt.Printf("✂️  Removed %d unreachable function(s)\n", stats.RemovedFunctions)
+	}
+
+	if useWasmOpt {
+		data = runWasmOpt(data)
+	}
+
+	named, err := wasm.Sizes(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrOptimizeFailed, err)
+	}
+	if data, err = wasm.StripCustomSections(data); err != nil {
+		return fmt.Errorf("%s: %w", ErrOptimizeFailed, err)
+	}
+	report, err := wasm.Sizes(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrOptimizeFailed, err)
+	}
+	// Function sizes are unchanged by stripping, but only the named module knows their names.
+	report.Functions = named.Functions
+
+	if err := os.WriteFile(wasmPath, data, 0644); err != nil {
+		return fmt.Errorf("%s: %w", ErrOptimizeFailed, err)
+	}
+	fmt.Printf("🗜️  Optimized %s: %s -> %s\n", filepath.Base(wasmPath), formatBytes(original), formatBytes(len(data)))
+
+	snapshotPath := sizeReportPathForWasm(wasmPath)
+	var previous *wasm.SizeReport
+	if saved, err := readSizeSnapshot(snapshotPath); err == nil {
+		previous = saved.Report
+	}
+	printSizeReport(filepath.Base(wasmPath), report, previous, "previous build", DefaultSizeReportTop)
+	return writeSizeSnapshot(snapshotPath, &sizeSnapshot{WasmSha256: sha256Hex(data), Report: report, Previous: previous})
+}
+
+// runWasmOpt applies Binaryen's size passes. The input is returned unchanged
+// when wasm-opt is missing or fails.
+func runWasmOpt(data []byte) []byte {
+	path, err := exec.LookPath("wasm-opt")
+	if err != nil {
+		fmt.Println("ℹ️  wasm-opt not found on PATH, skipping its passes")
+		return data
+	}
+	tmpDir, err := os.MkdirTemp("", "near-go-wasm-opt")
+	if err != nil {
+		return data
+	}
+	defer os.RemoveAll(tmpDir)
+
+	in, out := filepath.Join(tmpDir, "in.wasm"), filepath.Join(tmpDir, "out.wasm")
+	if err := os.WriteFile(in, data, 0644); err != nil {
+		return data
+	}
+	fmt.Println("🔧 Running wasm-opt -Oz...")
+	if _, err := ExecuteCommand(path, "-Oz", in, "-o", out); err != nil {
+		fmt.Printf("⚠️ Warning: wasm-opt failed, keeping the unoptimized code: %v\n", err)
+		return data
+	}
+	optimized, err := os.ReadFile(out)
+	if err != nil {
+		return data
+	}
+	return optimized
+}
+
+// HandleSize prints the section and function sizes of a WASM file. The diff is
+// against the 'base' WASM when given, else against the previous build recorded
+// in the saved size report.
+func HandleSize(file, base string, top int) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrToReadFile, err)
+	}
+	report, err := wasm.Sizes(data)
+	if err != nil {
+		return err
+	}
+
+	var previous *wasm.SizeReport
+	against := "previous build"
+	if base != "" {
+		against = filepath.Base(base)
+		baseData, err := os.ReadFile(base)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ErrToReadFile, err)
+		}
+		if previous, err = wasm.Sizes(baseData); err != nil {
+			return err
+		}
+	} else if saved, err := readSizeSnapshot(sizeReportPathForWasm(file)); err == nil {
+		if saved.WasmSha256 == sha256Hex(data) {
+			// Written by 'build --optimize' for this exact file, with function names.
+			report, previous = saved.Report, saved.Previous
+		} else {
+			previous = saved.Report
+		}
+	}
+
+	printSizeReport(filepath.Base(file), report, previous, against, top)
+	return nil
+}
+
+func printSizeReport(name string, report, previous *wasm.SizeReport, against string, top int) {
+	total := fmt.Sprintf("📦 %s: %s", name, formatBytes(report.Total))
+	if previous != nil {
+		total += fmt.Sprintf(" (%s vs %s)", formatByteDiff(report.Total-previous.Total), against)
+	}
+	fmt.Println(total)
+	stake := new(big.Int).Mul(big.NewInt(int64(report.Total)), sim.StorageCostPerByte)
+	fmt.Printf("💰 Storage staking for the code: %s NEAR\n", formatYoctoNear(stake.String()))
+	if report.Total > MaxContractSize {
+		fmt.Printf("⚠️ Warning: above the %s contract size limit\n", formatBytes(MaxContractSize))
+	}
+
+	previousSections := map[string]int{}
+	previousFunctions := map[string]int{}
+	if previous != nil {
+		for _, s := range previous.Sections {
+			previousSections[s.Name] += s.Size
+		}
+		for _, f := range previous.Functions {
+			previousFunctions[f.Name] = f.Size
+		}
+	}
+	diff := func(before map[string]int, key string, size int) string {
+		if previous == nil {
+			return ""
+		}
+		old, ok := before[key]
+		if !ok {
+			return "  (new)"
+		}
+		if old == size {
+			return ""
+		}
+		return "  (" + formatByteDiff(size-old) + ")"
+	}
+
+	fmt.Println("   Sections:")
+	for _, s := range report.Sections {
+		fmt.Printf("   %-24s %10s  %5.1f%%%s\n", s.Name, formatBytes(s.Size),
+			100*float64(s.Size)/float64(report.Total), diff(previousSections, s.Name, s.Size))
+	}
+
+	if top <= 0 || len(report.Functions) == 0 {
+		return
+	}
+	if top > len(report.Functions) {
+		top = len(report.Functions)
+	}
+	fmt.Printf("   Largest %d of %d functions:\n", top, len(report.Functions))
+	for _, f := range report.Functions[:top] {
+		fmt.Printf("   %10s  %s%s\n", formatBytes(f.Size), f.Name, diff(previousFunctions, f.Name, f.Size))
+	}
+}
+
+func formatBytes(n int) string {
+	if n < 1024 && n > -1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
+
+func formatByteDiff(n int) string {
+	if n > 0 {
+		return "+" + formatBytes(n)
+	}
+	return formatBytes(n)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func readSizeSnapshot(path string) (*sizeSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot sizeSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.Report == nil {
+		return nil, fmt.Errorf("%s: '%s' is not a size report", ErrToReadFile, path)
+	}
+	return &snapshot, nil
+}
+
+func writeSizeSnapshot(path string, snapshot *sizeSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := WriteToFile(path, string(data)+"\n"); err != nil {
+		return fmt.Errorf("failed to write size report '%s': %w", path, err)
+	}
+	return nil
+}
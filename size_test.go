@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedExports(t *testing.T) {
+	code := "package main\n\n// Export: get (from main.go:3)\n//go:export get\nfunc get() {\n}\n\n//go:export contract_source_metadata\n"
+	exports := generatedExports(code)
+	if strings.Join(exports, ",") != "get,contract_source_metadata" {
+		t.Errorf("generatedExports() = %v", exports)
+	}
+}
+
+func TestOptimizeWasmAndSize(t *testing.T) {
+	dir := t.TempDir()
+	wasmPath := filepath.Join(dir, "token.wasm")
+	if err := os.WriteFile(wasmPath, noopWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := optimizeWasm(wasmPath, []string{"noop"}, false); err != nil {
+		t.Fatalf("optimizeWasm() failed: %v", err)
+	}
+	snapshotPath := filepath.Join(dir, "token.size.json")
+	first, err := readSizeSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatalf("Size report was not saved: %v", err)
+	}
+	if first.Previous != nil || first.Report.Total != len(noopWasm) || len(first.Report.Functions) != 1 {
+		t.Errorf("Unexpected first report: %+v", first)
+	}
+
+	// The export is not annotated anymore, so it goes away with its body.
+	if err := optimizeWasm(wasmPath, nil, false); err != nil {
+		t.Fatalf("optimizeWasm() failed: %v", err)
+	}
+	second, err := readSizeSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Previous == nil || second.Previous.Total != first.Report.Total || second.Report.Total >= first.Report.Total {
+		t.Errorf("Unexpected second report: %+v", second)
+	}
+	optimized, err := os.ReadFile(wasmPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.WasmSha256 != sha256Hex(optimized) {
+		t.Error("Size report does not describe the written WASM")
+	}
+
+	if err := HandleSize(wasmPath, "", DefaultSizeReportTop); err != nil {
+		t.Errorf("HandleSize() failed: %v", err)
+	}
+	base := filepath.Join(dir, "base.wasm")
+	if err := os.WriteFile(base, noopWasm, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := HandleSize(wasmPath, base, 1); err != nil {
+		t.Errorf("HandleSize(base) failed: %v", err)
+	}
+	if err := HandleSize(filepath.Join(dir, "missing.wasm"), "", 1); err == nil {
+		t.Error("HandleSize() on a missing file succeeded")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KB", -2048: "-2.0 KB"}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+	if got := formatByteDiff(120); got != "+120 B" {
+		t.Errorf("formatByteDiff(120) = %q", got)
+	}
+}
@@ -0,0 +1,142 @@
+package transaction
+
+import (
+	"math/big"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+)
+
+// Action is one step of a transaction. The Borsh enum index of each action is
+// fixed by the protocol and must not change.
+type Action interface {
+	encode(w *writer) error
+}
+
+type CreateAccount struct{}
+
+type DeployContract struct {
+	Code []byte
+}
+
+type FunctionCall struct {
+	MethodName string
+	Args       []byte
+	Gas        uint64
+	Deposit    *big.Int
+}
+
+type Transfer struct {
+	Deposit *big.Int
+}
+
+type Stake struct {
+	Stake     *big.Int
+	PublicKey keystore.PublicKey
+}
+
+type AddKey struct {
+	PublicKey keystore.PublicKey
+	AccessKey AccessKey
+}
+
+type DeleteKey struct {
+	PublicKey keystore.PublicKey
+}
+
+type DeleteAccount struct {
+	BeneficiaryID string
+}
+
+// AccessKey grants full access when FunctionCall is nil.
+type AccessKey struct {
+	Nonce        uint64
+	FunctionCall *FunctionCallPermission
+}
+
+type FunctionCallPermission struct {
+	// Allowance is the amount of yoctoNEAR the key may spend on gas, nil means unlimited.
+	Allowance   *big.Int
+	ReceiverID  string
+	MethodNames []string
+}
+
+func (CreateAccount) encode(w *writer) error {
+	w.u8(0)
+	return nil
+}
+
+func (a DeployContract) encode(w *writer) error {
+	w.u8(1)
+	w.bytes(a.Code)
+	return nil
+}
+
+func (a FunctionCall) encode(w *writer) error {
+	w.u8(2)
+	w.string(a.MethodName)
+	w.bytes(a.Args)
+	w.u64(a.Gas)
+	return w.u128(a.Deposit)
+}
+
+func (a Transfer) encode(w *writer) error {
+	w.u8(3)
+	return w.u128(a.Deposit)
+}
+
+func (a Stake) encode(w *writer) error {
+	w.u8(4)
+	if err := w.u128(a.Stake); err != nil {
+		return err
+	}
+	return encodePublicKey(w, a.PublicKey)
+}
+
+func (a AddKey) encode(w *writer) error {
+	w.u8(5)
+	if err := encodePublicKey(w, a.PublicKey); err != nil {
+		return err
+	}
+	w.u64(a.AccessKey.Nonce)
+	if a.AccessKey.FunctionCall == nil {
+		w.u8(1)
+		return nil
+	}
+
+	permission := a.AccessKey.FunctionCall
+	w.u8(0)
+	if permission.Allowance == nil {
+		w.u8(0)
+	} else {
+		w.u8(1)
+		if err := w.u128(permission.Allowance); err != nil {
+			return err
+		}
+	}
+	w.string(permission.ReceiverID)
+	w.u32(uint32(len(permission.MethodNames)))
+	for _, name := range permission.MethodNames {
+		w.string(name)
+	}
+	return nil
+}
+
+func (a DeleteKey) encode(w *writer) error {
+	w.u8(6)
+	return encodePublicKey(w, a.PublicKey)
+}
+
+func (a DeleteAccount) encode(w *writer) error {
+	w.u8(7)
+	w.string(a.BeneficiaryID)
+	return nil
+}
+
+func encodePublicKey(w *writer, key keystore.PublicKey) error {
+	if key.Type != keystore.KeyTypeED25519 || len(key.Data) != 32 {
+		return errInvalidPublicKey
+	}
+	w.u8(uint8(key.Type))
+	w.fixed(key.Data)
+	return nil
+}
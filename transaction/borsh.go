@@ -0,0 +1,52 @@
+package transaction
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+// writer is a minimal Borsh encoder covering the types used by transactions.
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) u8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+func (w *writer) u32(v uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+func (w *writer) u64(v uint64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, v)
+}
+
+func (w *writer) u128(v *big.Int) error {
+	if v == nil {
+		v = new(big.Int)
+	}
+	if v.Sign() < 0 || v.BitLen() > 128 {
+		return fmt.Errorf("%s: amount %s does not fit into u128", ErrInvalidTransaction, v)
+	}
+	var be [16]byte
+	v.FillBytes(be[:])
+	for i := len(be) - 1; i >= 0; i-- {
+		w.buf = append(w.buf, be[i])
+	}
+	return nil
+}
+
+func (w *writer) fixed(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) bytes(b []byte) {
+	w.u32(uint32(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) string(s string) {
+	w.bytes([]byte(s))
+}
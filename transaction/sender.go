@@ -0,0 +1,96 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+	"github.com/vlmoon99/near-cli-go/rpc"
+)
+
+const (
+	ErrAccessKeyNotFound = "(TX_ERROR): access key not found on chain"
+	ErrSendFailed        = "(TX_ERROR): failed to send transaction"
+
+	nonceRetries = 3
+)
+
+// Sender signs transactions for one account and submits them through an RPC client.
+type Sender struct {
+	Client   *rpc.Client
+	SignerID string
+	Key      *keystore.KeyPair
+}
+
+func NewSender(client *rpc.Client, signerID string, key *keystore.KeyPair) *Sender {
+	return &Sender{Client: client, SignerID: signerID, Key: key}
+}
+
+// Build creates an unsigned transaction using the next access key nonce and a
+// recent final block hash.
+func (s *Sender) Build(ctx context.Context, receiverID string, actions ...Action) (*Transaction, error) {
+	accessKey, err := s.Client.ViewAccessKey(ctx, s.SignerID, s.Key.PublicKey.String(), rpc.FinalityFinal())
+	if err != nil {
+		if rpc.IsUnknownAccessKey(err) {
+			return nil, fmt.Errorf("%s: %s for %s", ErrAccessKeyNotFound, s.Key.PublicKey, s.SignerID)
+		}
+		return nil, err
+	}
+
+	block, err := s.Client.Block(ctx, rpc.FinalityFinal())
+	if err != nil {
+		return nil, err
+	}
+	blockHash, err := DecodeBlockHash(block.Header.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		SignerID:   s.SignerID,
+		PublicKey:  s.Key.PublicKey,
+		Nonce:      accessKey.Nonce + 1,
+		ReceiverID: receiverID,
+		BlockHash:  blockHash,
+		Actions:    actions,
+	}, nil
+}
+
+// Send builds, signs and broadcasts a transaction and waits for its final outcome.
+// Transactions rejected for a stale nonce are rebuilt and resent. When the node
+// times out waiting for the outcome, the status is looked up by hash once.
+func (s *Sender) Send(ctx context.Context, receiverID string, actions ...Action) (*rpc.FinalExecutionOutcome, error) {
+	var lastErr error
+	for attempt := 0; attempt < nonceRetries; attempt++ {
+		tx, err := s.Build(ctx, receiverID, actions...)
+		if err != nil {
+			return nil, err
+		}
+		signed, err := Sign(tx, s.Key)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := signed.Base64()
+		if err != nil {
+			return nil, err
+		}
+
+		outcome, err := s.Client.BroadcastTxCommit(ctx, payload)
+		if err == nil {
+			return outcome, nil
+		}
+		if rpc.IsTimeout(err) {
+			return s.Client.TxStatus(ctx, signed.HashString(), s.SignerID)
+		}
+		if !isInvalidNonce(err) {
+			return nil, fmt.Errorf("%s: %w", ErrSendFailed, err)
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%s: %w", ErrSendFailed, lastErr)
+}
+
+func isInvalidNonce(err error) bool {
+	return rpc.HasCause(err, "INVALID_TRANSACTION") && strings.Contains(err.Error(), "InvalidNonce")
+}
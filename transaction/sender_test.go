@@ -0,0 +1,92 @@
+package transaction
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+	"github.com/vlmoon99/near-cli-go/rpc"
+)
+
+func TestSender_Send(t *testing.T) {
+	key := keystore.NewKeyPair(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	broadcasts := 0
+	var lastPayload []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		var result string
+		switch req.Method {
+		case "query":
+			result = `{"nonce":10,"permission":"FullAccess","block_height":1,"block_hash":"h"}`
+		case "block":
+			result = `{"header":{"height":1,"hash":"` + goldenBlockHash + `"}}`
+		case "broadcast_tx_commit":
+			broadcasts++
+			var params []string
+			json.Unmarshal(req.Params, &params)
+			lastPayload, _ = base64.StdEncoding.DecodeString(params[0])
+			if broadcasts == 1 {
+				w.Write([]byte(`{"jsonrpc":"2.0","id":"1","error":{"name":"HANDLER_ERROR","cause":{"name":"INVALID_TRANSACTION"},"data":{"TxExecutionError":{"InvalidTxError":{"InvalidNonce":{"tx_nonce":11,"ak_nonce":11}}}}}}`))
+				return
+			}
+			result = `{"status":{"SuccessValue":""},"transaction":{},"transaction_outcome":{"id":"x","outcome":{"status":{}}},"receipts_outcome":[]}`
+		default:
+			t.Fatalf("Unexpected method %s", req.Method)
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":"1","result":` + result + `}`))
+	}))
+	defer server.Close()
+
+	sender := NewSender(rpc.NewClient(server.URL), "alice.near", key)
+	outcome, err := sender.Send(context.Background(), "bob.near", CreateAccount{})
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if outcome.Status.Err() != nil {
+		t.Errorf("Unexpected failure status")
+	}
+	if broadcasts != 2 {
+		t.Errorf("Expected the stale nonce transaction to be resent, got %d broadcasts", broadcasts)
+	}
+
+	expected, _ := (&Transaction{
+		SignerID:   "alice.near",
+		PublicKey:  key.PublicKey,
+		Nonce:      11,
+		ReceiverID: "bob.near",
+		BlockHash:  mustBlockHash(t, goldenBlockHash),
+		Actions:    []Action{CreateAccount{}},
+	}).Serialize()
+	if string(lastPayload[:len(expected)]) != string(expected) {
+		t.Errorf("Broadcast payload does not contain the expected transaction")
+	}
+	hash := sha256.Sum256(expected)
+	if !ed25519.Verify(ed25519.PublicKey(key.PublicKey.Data), hash[:], lastPayload[len(expected)+1:]) {
+		t.Errorf("Broadcast signature does not verify")
+	}
+}
+
+func TestSender_UnknownAccessKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":"1","error":{"name":"HANDLER_ERROR","cause":{"name":"UNKNOWN_ACCESS_KEY"}}}`))
+	}))
+	defer server.Close()
+
+	key := keystore.NewKeyPair(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)))
+	_, err := NewSender(rpc.NewClient(server.URL), "alice.near", key).Send(context.Background(), "bob.near", CreateAccount{})
+	if err == nil {
+		t.Fatalf("Expected error for unknown access key")
+	}
+}
@@ -0,0 +1,130 @@
+// Package transaction builds, Borsh-serializes and signs NEAR transactions so
+// they can be submitted with the rpc package.
+package transaction
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+)
+
+const (
+	ErrInvalidTransaction = "(TX_ERROR): invalid transaction"
+	ErrInvalidBlockHash   = "(TX_ERROR): invalid block hash"
+	ErrKeyMismatch        = "(TX_ERROR): transaction public key does not match the signing key"
+)
+
+var errInvalidPublicKey = errors.New(ErrInvalidTransaction + ": only ed25519 public keys are supported")
+
+type Transaction struct {
+	SignerID   string
+	PublicKey  keystore.PublicKey
+	Nonce      uint64
+	ReceiverID string
+	BlockHash  [32]byte
+	Actions    []Action
+}
+
+// Serialize returns the Borsh encoding of the transaction.
+func (t *Transaction) Serialize() ([]byte, error) {
+	w := &writer{}
+	if err := t.encode(w); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+func (t *Transaction) encode(w *writer) error {
+	if len(t.Actions) == 0 {
+		return fmt.Errorf("%s: no actions", ErrInvalidTransaction)
+	}
+	w.string(t.SignerID)
+	if err := encodePublicKey(w, t.PublicKey); err != nil {
+		return err
+	}
+	w.u64(t.Nonce)
+	w.string(t.ReceiverID)
+	w.fixed(t.BlockHash[:])
+	w.u32(uint32(len(t.Actions)))
+	for _, action := range t.Actions {
+		if err := action.encode(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hash is the sha256 of the serialized transaction, which is what gets signed.
+func (t *Transaction) Hash() ([32]byte, error) {
+	data, err := t.Serialize()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+type SignedTransaction struct {
+	Transaction *Transaction
+	Signature   []byte
+	Hash        [32]byte
+}
+
+// Sign signs tx with key. An empty tx.PublicKey is filled from the key pair.
+func Sign(tx *Transaction, key *keystore.KeyPair) (*SignedTransaction, error) {
+	if len(tx.PublicKey.Data) == 0 {
+		tx.PublicKey = key.PublicKey
+	} else if tx.PublicKey.String() != key.PublicKey.String() {
+		return nil, errors.New(ErrKeyMismatch)
+	}
+
+	hash, err := tx.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTransaction{
+		Transaction: tx,
+		Signature:   key.Sign(hash[:]),
+		Hash:        hash,
+	}, nil
+}
+
+func (s *SignedTransaction) Serialize() ([]byte, error) {
+	w := &writer{}
+	if err := s.Transaction.encode(w); err != nil {
+		return nil, err
+	}
+	w.u8(uint8(keystore.KeyTypeED25519))
+	w.fixed(s.Signature)
+	return w.buf, nil
+}
+
+// Base64 returns the payload expected by broadcast_tx_commit.
+func (s *SignedTransaction) Base64() (string, error) {
+	data, err := s.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// HashString is the base58 transaction hash shown by explorers.
+func (s *SignedTransaction) HashString() string {
+	return keystore.Base58Encode(s.Hash[:])
+}
+
+// DecodeBlockHash parses a base58 block hash as returned by the RPC.
+func DecodeBlockHash(hash string) ([32]byte, error) {
+	var out [32]byte
+	data, err := keystore.Base58Decode(hash)
+	if err != nil {
+		return out, fmt.Errorf("%s: %w", ErrInvalidBlockHash, err)
+	}
+	if len(data) != len(out) {
+		return out, fmt.Errorf("%s: expected %d bytes, got %d", ErrInvalidBlockHash, len(out), len(data))
+	}
+	copy(out[:], data)
+	return out, nil
+}
@@ -0,0 +1,176 @@
+package transaction
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/vlmoon99/near-cli-go/keystore"
+)
+
+// Vectors were produced by an independent Borsh encoder following the nearcore schema.
+// The transfer vector is the one used by near-api-js.
+const (
+	goldenPublicKey = "ed25519:Anu7LYDfpLtkP7E16LT9imXF694BdQaa9ufVkQiwTQxC"
+	goldenBlockHash = "244ZQ9cgj3CQ6bWBdytfrJMuMQ1jdXLFGnr4HhvtCTnM"
+
+	goldenTransferTx = "09000000746573742e6e65617200917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d01000000000000000d00000077686174657665722e6e6561720fa473fd26901df296be6adc4cc4df34d040efa2435224b6986910e630c2fef6010000000301000000000000000000000000000000"
+
+	goldenAllActionsTx = "0a000000616c6963652e6e65617200917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d2a000000000000000a000000616c6963652e6e6561720fa473fd26901df296be6adc4cc4df34d040efa2435224b6986910e630c2fef60a0000000001040000000061736d020c0000007365745f6772656574696e67020000007b7d00e057eb481b0000000000a1edccce1bc2d300000000000003050000000000000000000000000000000400000042db999d3784a701000000000000917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d0500917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d0000000000000000010500917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d07000000000000000001000040683bb3f386f034000000000000080000006170702e6e6561720200000004000000766f746506000000756e766f74650500917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d00000000000000000000080000006170702e6e656172000000000600917b3d268d4b58f7fec1b150bd68d69be3ee5d4cc39855e341538465bb77860d0708000000626f622e6e656172"
+	goldenAllActionsHash = "32d086c17988d5904ba17e84d8fff33af65305c85c6403929ad4d6d7a1a4e8f8"
+)
+
+func mustPublicKey(t *testing.T, s string) keystore.PublicKey {
+	key, err := keystore.ParsePublicKey(s)
+	if err != nil {
+		t.Fatalf("ParsePublicKey failed: %v", err)
+	}
+	return key
+}
+
+func mustBlockHash(t *testing.T, s string) [32]byte {
+	hash, err := DecodeBlockHash(s)
+	if err != nil {
+		t.Fatalf("DecodeBlockHash failed: %v", err)
+	}
+	return hash
+}
+
+func yocto(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 10)
+	return v
+}
+
+func TestSerialize_Transfer(t *testing.T) {
+	tx := &Transaction{
+		SignerID:   "test.near",
+		PublicKey:  mustPublicKey(t, goldenPublicKey),
+		Nonce:      1,
+		ReceiverID: "whatever.near",
+		BlockHash:  mustBlockHash(t, goldenBlockHash),
+		Actions:    []Action{Transfer{Deposit: big.NewInt(1)}},
+	}
+
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if hex.EncodeToString(data) != goldenTransferTx {
+		t.Errorf("Serialized transfer does not match golden vector:\n got  %x\n want %s", data, goldenTransferTx)
+	}
+}
+
+func allActionsTransaction(t *testing.T) *Transaction {
+	key := mustPublicKey(t, goldenPublicKey)
+	return &Transaction{
+		SignerID:   "alice.near",
+		PublicKey:  key,
+		Nonce:      42,
+		ReceiverID: "alice.near",
+		BlockHash:  mustBlockHash(t, goldenBlockHash),
+		Actions: []Action{
+			CreateAccount{},
+			DeployContract{Code: []byte("\x00asm")},
+			FunctionCall{MethodName: "set_greeting", Args: []byte("{}"), Gas: 30_000_000_000_000, Deposit: yocto("1000000000000000000000000")},
+			Transfer{Deposit: big.NewInt(5)},
+			Stake{Stake: yocto("2000000000000000000000000"), PublicKey: key},
+			AddKey{PublicKey: key, AccessKey: AccessKey{}},
+			AddKey{PublicKey: key, AccessKey: AccessKey{Nonce: 7, FunctionCall: &FunctionCallPermission{
+				Allowance:   yocto("250000000000000000000000"),
+				ReceiverID:  "app.near",
+				MethodNames: []string{"vote", "unvote"},
+			}}},
+			AddKey{PublicKey: key, AccessKey: AccessKey{FunctionCall: &FunctionCallPermission{ReceiverID: "app.near"}}},
+			DeleteKey{PublicKey: key},
+			DeleteAccount{BeneficiaryID: "bob.near"},
+		},
+	}
+}
+
+func TestSerialize_AllActions(t *testing.T) {
+	tx := allActionsTransaction(t)
+
+	data, err := tx.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize failed: %v", err)
+	}
+	if hex.EncodeToString(data) != goldenAllActionsTx {
+		t.Errorf("Serialized transaction does not match golden vector:\n got  %x\n want %s", data, goldenAllActionsTx)
+	}
+
+	hash, err := tx.Hash()
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if hex.EncodeToString(hash[:]) != goldenAllActionsHash {
+		t.Errorf("Hash = %x; want %s", hash, goldenAllActionsHash)
+	}
+}
+
+func TestSign(t *testing.T) {
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	key := keystore.NewKeyPair(ed25519.NewKeyFromSeed(seed))
+
+	tx := allActionsTransaction(t)
+	tx.PublicKey = keystore.PublicKey{}
+
+	signed, err := Sign(tx, key)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if tx.PublicKey.String() != key.PublicKey.String() {
+		t.Errorf("Expected empty public key to be filled from the key pair")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key.PublicKey.Data), signed.Hash[:], signed.Signature) {
+		t.Errorf("Signature does not verify against the transaction hash")
+	}
+
+	payload, err := signed.Base64()
+	if err != nil {
+		t.Fatalf("Base64 failed: %v", err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(payload)
+	txBytes, _ := tx.Serialize()
+	if string(data[:len(txBytes)]) != string(txBytes) || data[len(txBytes)] != 0 || string(data[len(txBytes)+1:]) != string(signed.Signature) {
+		t.Errorf("Signed transaction must be the transaction followed by an ed25519 signature")
+	}
+	if signed.HashString() != keystore.Base58Encode(signed.Hash[:]) {
+		t.Errorf("Unexpected hash string %s", signed.HashString())
+	}
+
+	other := allActionsTransaction(t)
+	if _, err := Sign(other, key); err == nil || !strings.Contains(err.Error(), ErrKeyMismatch) {
+		t.Errorf("Expected key mismatch error, got %v", err)
+	}
+}
+
+func TestSerialize_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   *Transaction
+	}{
+		{"no actions", &Transaction{PublicKey: mustPublicKey(t, goldenPublicKey)}},
+		{"missing public key", &Transaction{Actions: []Action{CreateAccount{}}}},
+		{"negative deposit", &Transaction{PublicKey: mustPublicKey(t, goldenPublicKey), Actions: []Action{Transfer{Deposit: big.NewInt(-1)}}}},
+		{"u128 overflow", &Transaction{PublicKey: mustPublicKey(t, goldenPublicKey), Actions: []Action{Transfer{Deposit: new(big.Int).Lsh(big.NewInt(1), 128)}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.tx.Serialize(); err == nil {
+				t.Errorf("Expected serialization error")
+			}
+		})
+	}
+}
+
+func TestDecodeBlockHash_Invalid(t *testing.T) {
+	for _, input := range []string{"0OIl", "abc"} {
+		if _, err := DecodeBlockHash(input); err == nil {
+			t.Errorf("DecodeBlockHash(%q) should fail", input)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package transaction
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	ErrInvalidGas    = "(USER_INPUT_ERROR): invalid gas amount"
+	ErrInvalidAmount = "(USER_INPUT_ERROR): invalid NEAR amount"
+
+	// MaxGas is the protocol limit of prepaid gas per transaction.
+	MaxGas uint64 = 300_000_000_000_000
+)
+
+var gasUnits = map[string]int{
+	"gas":  0,
+	"ggas": 9,
+	"tgas": 12,
+	"pgas": 15,
+}
+
+var nearUnits = map[string]int{
+	"near":      24,
+	"millinear": 21,
+	"yoctonear": 0,
+}
+
+// ParseGas parses amounts like "100 Tgas", "30000000000000 gas" or a bare number of gas units.
+func ParseGas(s string) (uint64, error) {
+	value, unit := splitAmount(s)
+	if unit == "" {
+		unit = "gas"
+	}
+	exp, ok := gasUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("%s: unknown unit '%s' in '%s'", ErrInvalidGas, unit, s)
+	}
+
+	amount, err := scaleDecimal(value, exp)
+	if err != nil || !amount.IsUint64() {
+		return 0, fmt.Errorf("%s: '%s'", ErrInvalidGas, s)
+	}
+	if amount.Uint64() > MaxGas {
+		return 0, fmt.Errorf("%s: '%s' exceeds the 300 Tgas limit", ErrInvalidGas, s)
+	}
+	return amount.Uint64(), nil
+}
+
+// ParseNearAmount parses amounts like "1.5 NEAR", "0 NEAR" or "1 yoctoNEAR" into yoctoNEAR.
+// The unit is required, so a bare number is never silently read as NEAR or yoctoNEAR.
+func ParseNearAmount(s string) (*big.Int, error) {
+	value, unit := splitAmount(s)
+	exp, ok := nearUnits[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("%s: '%s', expected a unit like '1 NEAR' or '1 yoctoNEAR'", ErrInvalidAmount, s)
+	}
+
+	amount, err := scaleDecimal(value, exp)
+	if err != nil || amount.BitLen() > 128 {
+		return nil, fmt.Errorf("%s: '%s'", ErrInvalidAmount, s)
+	}
+	return amount, nil
+}
+
+func splitAmount(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '_'
+	})
+	if i < 0 {
+		return strings.ReplaceAll(s, "_", ""), ""
+	}
+	return strings.ReplaceAll(strings.TrimSpace(s[:i]), "_", ""), strings.TrimSpace(s[i:])
+}
+
+// scaleDecimal converts a non-negative decimal string to an integer multiplied by 10^exp.
+func scaleDecimal(value string, exp int) (*big.Int, error) {
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" && frac == "" {
+		return nil, fmt.Errorf("empty amount")
+	}
+	if len(frac) > exp {
+		if strings.Trim(frac[exp:], "0") != "" {
+			return nil, fmt.Errorf("too many decimal places")
+		}
+		frac = frac[:exp]
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number")
+	}
+	return amount, nil
+}
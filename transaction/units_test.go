@@ -0,0 +1,55 @@
+package transaction
+
+import "testing"
+
+func TestParseGas(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+		wantErr  bool
+	}{
+		{"100 Tgas", 100_000_000_000_000, false},
+		{"100 TGas", 100_000_000_000_000, false},
+		{"30000000000000", 30_000_000_000_000, false},
+		{"2.5 Tgas", 2_500_000_000_000, false},
+		{"300 Tgas", MaxGas, false},
+		{"301 Tgas", 0, true},
+		{"10 Ygas", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseGas(tt.input)
+		if (err != nil) != tt.wantErr || result != tt.expected {
+			t.Errorf("ParseGas(%q) = %d, %v; want %d, error=%v", tt.input, result, err, tt.expected, tt.wantErr)
+		}
+	}
+}
+
+func TestParseNearAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"0 NEAR", "0", false},
+		{"1 NEAR", "1000000000000000000000000", false},
+		{"1.5 near", "1500000000000000000000000", false},
+		{"1 yoctoNEAR", "1", false},
+		{"0.000000000000000000000001 NEAR", "1", false},
+		{"0.0000000000000000000000001 NEAR", "", true},
+		{"1", "", true},
+		{"1.2.3 NEAR", "", true},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseNearAmount(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNearAmount(%q) error = %v; want error=%v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && result.String() != tt.expected {
+			t.Errorf("ParseNearAmount(%q) = %s; want %s", tt.input, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// checkContractTypes type-checks the contract package with go/types and
+// validates the whole type graph of every exported method's JSON parameters and
+// results, and of every event. Struct types of other packages reached on the
+// way are returned so the ABI can describe them.
+//
+// Compile errors are left to TinyGo, which reports them with their position,
+// and types of imports that can't be resolved here are not checked.
+func checkContractTypes(contract *ContractInfo) ([]*FileContent, error) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	var dir string
+	for _, content := range contract.Files {
+		if content.Qualifier != "" {
+			continue
+		}
+		dir = filepath.Dir(content.FilePath)
+		file, err := parser.ParseFile(fset, content.FilePath, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	imports := map[string]bool{}
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			imports[path] = true
+		}
+	}
+	imp := newPackageImporter(fset, dir, imports)
+	conf := types.Config{Importer: imp, Error: func(error) {}}
+	pkg, _ := conf.Check("main", fset, files, nil)
+	if pkg == nil {
+		return nil, nil
+	}
+
+	checker := &jsonTypeChecker{
+		pkg:      pkg,
+		known:    collectStructs(contract.Files),
+		found:    map[string]*FileContent{},
+		visiting: map[*types.Named]bool{},
+	}
+	for _, m := range contract.Methods {
+		if !m.IsExported() || m.IsMigrate {
+			continue
+		}
+		if err := checker.method(m); err != nil {
+			return nil, sourceError(m.FilePath, m.RelativePath, m.Line, err)
+		}
+	}
+	for _, e := range contract.Events() {
+		if err := checker.event(e); err != nil {
+			return nil, sourceError(e.FilePath, e.RelativePath, e.Line, err)
+		}
+	}
+
+	var external []*FileContent
+	for _, content := range checker.found {
+		external = append(external, content)
+	}
+	sort.Slice(external, func(i, j int) bool { return external[i].RelativePath < external[j].RelativePath })
+	return external, nil
+}
+
+// listedPackage is the part of 'go list -json' output the importer uses.
+type listedPackage struct {
+	ImportPath string
+	Dir        string
+	GoFiles    []string
+	Export     string
+	ImportMap  map[string]string
+}
+
+// packageImporter resolves the contract's imports the way the build sees them,
+// from one 'go list -export' run for wasm: from the compiler's export data where
+// the package compiles with Go, and from source where it doesn't, which is the
+// case for the SDK whose host functions only TinyGo links. Function bodies of
+// packages read from source are not checked.
+type packageImporter struct {
+	fset     *token.FileSet
+	listed   map[string]*listedPackage
+	export   types.Importer
+	packages map[string]*types.Package
+}
+
+func newPackageImporter(fset *token.FileSet, dir string, imports map[string]bool) *packageImporter {
+	i := &packageImporter{fset: fset, listed: map[string]*listedPackage{}, packages: map[string]*types.Package{}}
+	i.export = importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		return os.Open(i.listed[path].Export)
+	})
+	if len(imports) == 0 {
+		return i
+	}
+
+	args := []string{"list", "-e", "-export", "-deps", "-json=ImportPath,Dir,GoFiles,Export,ImportMap"}
+	for path := range imports {
+		args = append(args, path)
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	// Packages that can't be listed, or a failing go command, leave types unresolved.
+	output, _ := cmd.Output()
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		listed := &listedPackage{}
+		if err := decoder.Decode(listed); err != nil {
+			break
+		}
+		i.listed[listed.ImportPath] = listed
+	}
+	return i
+}
+
+func (i *packageImporter) Import(path string) (*types.Package, error) {
+	if pkg, ok := i.packages[path]; ok {
+		return pkg, nil
+	}
+	listed, ok := i.listed[path]
+	if !ok {
+		return nil, fmt.Errorf("package %s not found", path)
+	}
+	if listed.Export != "" {
+		pkg, err := i.export.Import(path)
+		i.packages[path] = pkg
+		return pkg, err
+	}
+
+	var files []*ast.File
+	for _, name := range listed.GoFiles {
+		file, err := parser.ParseFile(i.fset, filepath.Join(listed.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(imported string) (*types.Package, error) {
+			if mapped, ok := listed.ImportMap[imported]; ok {
+				imported = mapped
+			}
+			return i.Import(imported)
+		}),
+		IgnoreFuncBodies: true,
+		Error:            func(error) {},
+	}
+	pkg, _ := conf.Check(path, i.fset, files, nil)
+	i.packages[path] = pkg
+	return pkg, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+type jsonTypeChecker struct {
+	pkg      *types.Package
+	known    map[string]*StructInfo
+	found    map[string]*FileContent
+	visiting map[*types.Named]bool
+}
+
+// unsupportedType is a type that encoding/json can't handle, found at path.
+type unsupportedType struct {
+	path   string
+	typ    types.Type
+	reason string
+}
+
+func (c *jsonTypeChecker) qualifier(p *types.Package) string {
+	if p == c.pkg {
+		return ""
+	}
+	return p.Name()
+}
+
+func (c *jsonTypeChecker) method(m *MethodInfo) error {
+	receiver, ok := c.pkg.Scope().Lookup(m.ReceiverType).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	selection := types.NewMethodSet(types.NewPointer(receiver.Type())).Lookup(c.pkg, m.Name)
+	if selection == nil {
+		return nil
+	}
+	sig := selection.Obj().Type().(*types.Signature)
+	if sig.Variadic() {
+		return fmt.Errorf("method '%s' has a variadic parameter; take a slice instead", m.Name)
+	}
+
+	if m.ArgsSerializer == SerializerJSON {
+		for i := 0; i < sig.Params().Len(); i++ {
+			param := sig.Params().At(i)
+			if m.IsPromiseCallback && isPromiseResultType(types.TypeString(param.Type(), c.qualifier)) {
+				continue
+			}
+			if err := c.checkNear(param.Type()); err != nil {
+				return fmt.Errorf("method '%s' parameter '%s' %w", m.Name, param.Name(), err)
+			} else if c.isNear(param.Type()) {
+				continue
+			}
+			if bad := c.check(param.Type(), param.Name(), true); bad != nil {
+				return bad.error(fmt.Sprintf("method '%s' parameter '%s'", m.Name, param.Name()), param.Name())
+			}
+		}
+	}
+
+	if m.ResultSerializer == SerializerJSON {
+		for i := 0; i < sig.Results().Len(); i++ {
+			result := sig.Results().At(i).Type()
+			if i == sig.Results().Len()-1 && types.Identical(result, types.Universe.Lookup("error").Type()) {
+				continue
+			}
+			if err := c.checkNear(result); err != nil {
+				return fmt.Errorf("method '%s' result %w", m.Name, err)
+			} else if c.isNear(result) {
+				continue
+			}
+			if bad := c.check(result, "result", false); bad != nil {
+				return bad.error(fmt.Sprintf("method '%s' result", m.Name), "result")
+			}
+		}
+	}
+	return nil
+}
+
+func (c *jsonTypeChecker) isNear(t types.Type) bool {
+	_, _, _, ok := splitNearType(types.TypeString(t, c.qualifier))
+	return ok
+}
+
+// checkNear verifies that a type passed in NEAR string form because of its name
+// has the underlying type the generated conversion expects.
+func (c *jsonTypeChecker) checkNear(t types.Type) error {
+	typeStr := types.TypeString(t, c.qualifier)
+	prefix, elem, near, ok := splitNearType(typeStr)
+	if !ok {
+		return nil
+	}
+	switch prefix {
+	case "*":
+		t = t.Underlying().(*types.Pointer).Elem()
+	case "[]":
+		t = t.Underlying().(*types.Slice).Elem()
+	}
+
+	if !nearBaseValid(t, near) {
+		return fmt.Errorf("has type %s, which is passed in NEAR string form because of its name, but %s is not based on %s", typeStr, elem, near.base)
+	}
+	return nil
+}
+
+// nearBaseValid reports whether t has the underlying type near.base, which the
+// generated conversion of the NEAR type expects.
+func nearBaseValid(t types.Type, near nearType) bool {
+	var valid bool
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch near.base {
+		case "uint64":
+			valid = u.Kind() == types.Uint64
+		case "string":
+			valid = u.Info()&types.IsString != 0
+		}
+		valid = valid || u.Kind() == types.Invalid
+	case *types.Slice:
+		b, isBasic := u.Elem().Underlying().(*types.Basic)
+		valid = near.base == "[]byte" && isBasic && b.Kind() == types.Byte
+	case *types.Struct:
+		// The layout of types.Uint128, which conversions between the types rely on.
+		valid = near.base == "types.Uint128" &&
+			types.TypeString(u, nil) == "struct{Hi uint64; Lo uint64}"
+	}
+	return valid
+}
+
+func (u *unsupportedType) error(subject, root string) error {
+	if u.path == root {
+		return fmt.Errorf("%s has unsupported type %s: %s", subject, u.typ, u.reason)
+	}
+	return fmt.Errorf("%s has unsupported type %s at %s: %s", subject, u.typ, u.path, u.reason)
+}
+
+// check walks t the way encoding/json does; decode selects Unmarshal rules over
+// Marshal rules.
+func (c *jsonTypeChecker) check(t types.Type, path string, decode bool) *unsupportedType {
+	t = types.Unalias(t)
+	if named, ok := t.(*types.Named); ok {
+		if near, ok := lookupNearType(types.TypeString(named, c.qualifier)); ok {
+			// Passed in NEAR string form by the generated code, wherever it is nested.
+			if !nearBaseValid(named, near) {
+				return &unsupportedType{path: path, typ: t, reason: "it is passed in NEAR string form because of its name, but is not based on " + near.base}
+			}
+			return nil
+		}
+		if implementsJSON(named, decode) {
+			return nil
+		}
+		if c.visiting[named] {
+			return nil
+		}
+		c.visiting[named] = true
+		defer delete(c.visiting, named)
+		if _, isStruct := named.Underlying().(*types.Struct); isStruct {
+			c.record(named)
+		}
+	}
+
+	unsupported := func(reason string) *unsupportedType {
+		return &unsupportedType{path: path, typ: t, reason: reason}
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Kind() == types.Invalid:
+			// Unresolved import.
+			return nil
+		case u.Info()&types.IsComplex != 0:
+			return unsupported("complex numbers have no JSON encoding")
+		case u.Kind() == types.UnsafePointer:
+			return unsupported("unsafe pointers have no JSON encoding")
+		}
+	case *types.Pointer:
+		return c.check(u.Elem(), path, decode)
+	case *types.Slice:
+		return c.check(u.Elem(), path+"[]", decode)
+	case *types.Array:
+		return c.check(u.Elem(), path+"[]", decode)
+	case *types.Map:
+		if !validJSONMapKey(u.Key(), decode) {
+			return &unsupportedType{path: path, typ: t, reason: "map keys must be strings, integers or implement encoding.TextMarshaler"}
+		}
+		return c.check(u.Elem(), path+"[...]", decode)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			field := u.Field(i)
+			name := reflect.StructTag(u.Tag(i)).Get("json")
+			if !field.Exported() && !field.Embedded() || name == "-" {
+				continue
+			}
+			fieldPath := path + "." + field.Name()
+			if field.Embedded() {
+				fieldPath = path
+			}
+			if bad := c.check(field.Type(), fieldPath, decode); bad != nil {
+				return bad
+			}
+		}
+	case *types.Interface:
+		if decode && u.NumMethods() > 0 {
+			return unsupported("interfaces with methods can't be decoded from JSON")
+		}
+	case *types.Chan:
+		return unsupported("channels have no JSON encoding")
+	case *types.Signature:
+		return unsupported("functions have no JSON encoding")
+	case *types.TypeParam:
+		return unsupported("type parameters are not supported")
+	}
+	return nil
+}
+
+// record adds a struct type of another package that the scanned files don't
+// declare, so the ABI can describe it.
+func (c *jsonTypeChecker) record(named *types.Named) {
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg() == c.pkg || named.TypeArgs().Len() > 0 {
+		return
+	}
+	name := obj.Pkg().Name() + "." + obj.Name()
+	if _, ok := c.known[name]; ok {
+		return
+	}
+	content, ok := c.found[obj.Pkg().Path()]
+	if !ok {
+		content = &FileContent{RelativePath: obj.Pkg().Path(), Package: obj.Pkg().Name(), Qualifier: obj.Pkg().Name()}
+		c.found[obj.Pkg().Path()] = content
+	}
+	st := named.Underlying().(*types.Struct)
+	info := &StructInfo{Name: name}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		info.Fields = append(info.Fields, FieldInfo{
+			Name:     field.Name(),
+			Type:     types.TypeString(field.Type(), c.qualifier),
+			Tag:      st.Tag(i),
+			Embedded: field.Embedded(),
+		})
+	}
+	content.Structs = append(content.Structs, info)
+	c.known[name] = info
+}
+
+// implementsJSON reports whether the type encodes itself, as JSON or as text.
+func implementsJSON(t types.Type, decode bool) bool {
+	if decode {
+		return hasMethod(t, "UnmarshalJSON") || hasMethod(t, "UnmarshalText")
+	}
+	return hasMethod(t, "MarshalJSON") || hasMethod(t, "MarshalText")
+}
+
+// validJSONMapKey follows encoding/json: string and integer keys, or keys encoding
+// themselves as text.
+func validJSONMapKey(key types.Type, decode bool) bool {
+	if basic, ok := key.Underlying().(*types.Basic); ok {
+		return basic.Info()&(types.IsString|types.IsInteger) != 0 || basic.Kind() == types.Invalid
+	}
+	if decode {
+		return hasMethod(key, "UnmarshalText")
+	}
+	return hasMethod(key, "MarshalText")
+}
+
+// hasMethod reports whether t or *t has the method name.
+func hasMethod(t types.Type, name string) bool {
+	set := types.NewMethodSet(types.NewPointer(t))
+	for i := 0; i < set.Len(); i++ {
+		if set.At(i).Obj().Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCode_StructParameters(t *testing.T) {
+	contractCode := `
+package main
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+type Base struct {
+	ID uint64
+}
+
+type Item struct {
+	Base
+	Name string
+	Hash [32]byte
+}
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating
+func (c *Contract) Put(pair Pair[string, uint64], byName map[string]Item, hash [32]byte) {}
+
+// @contract:mutating
+func (c *Contract) AddItems(items []Item) {}
+
+// @contract:mutating
+func (c *Contract) AddItem(item *Item) {}
+`
+	dir := setupTestProject(t, contractCode)
+	generated, err := GenerateCode(dir)
+	if err != nil {
+		t.Fatalf("GenerateCode failed: %v", err)
+	}
+
+	expected := []string{
+		"Pair Pair[string, uint64] `json:\"pair\"`",
+		"ByName map[string]Item `json:\"byName\"`",
+		"Hash [32]byte `json:\"hash\"`",
+		"Items []Item `json:\"items\"`",
+		"state.AddItems(params.Items)",
+		"var params *Item",
+		"state.AddItem(params)",
+	}
+	for _, exp := range expected {
+		if !strings.Contains(generated, exp) {
+			t.Errorf("Expected generated code to contain %q", exp)
+		}
+	}
+
+	abi := generateTestABI(t, contractCode)
+	put := findAbiFunction(abi, "put")
+	if put == nil || put.Params == nil || len(put.Params.Args) != 3 {
+		t.Fatalf("Expected put with 3 args, got %+v", put)
+	}
+	hash := put.Params.Args[2].TypeSchema
+	if hash["type"] != "array" || hash["minItems"] != float64(32) || hash["maxItems"] != float64(32) {
+		t.Errorf("Expected a 32 item array schema for hash, got %v", hash)
+	}
+	if addItem := findAbiFunction(abi, "add_item"); addItem == nil || addItem.Params == nil || len(addItem.Params.Args) != 3 {
+		t.Errorf("Expected the fields of Item, with Base flattened, as add_item args, got %+v", addItem)
+	}
+}
+
+func TestScanContract_ExternalStructs(t *testing.T) {
+	dir := setupTestProject(t, `
+package main
+
+import "image"
+
+// @contract:state
+type Contract struct {}
+
+// @contract:view
+func (c *Contract) Origin() image.Point { return image.Point{} }
+`)
+	contract, err := ScanContract(dir)
+	if err != nil {
+		t.Fatalf("ScanContract failed: %v", err)
+	}
+	point, ok := contract.Structs()["image.Point"]
+	if !ok {
+		t.Fatalf("image.Point was not recorded for the ABI")
+	}
+	if len(point.Fields) != 2 || point.Fields[0].Name != "X" || point.Fields[0].Type != "int" {
+		t.Errorf("Unexpected fields of image.Point: %+v", point.Fields)
+	}
+}
+
+func TestScanContract_UnsupportedTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected string
+	}{
+		{
+			name:     "function parameter",
+			code:     "// @contract:mutating\nfunc (c *Contract) Run(cb func()) {}",
+			expected: "method 'Run' parameter 'cb' has unsupported type func(): functions have no JSON encoding",
+		},
+		{
+			name:     "nested channel",
+			code:     "type Req struct {\n\tDone chan bool\n}\n\n// @contract:mutating\nfunc (c *Contract) Submit(req Req) {}",
+			expected: "has unsupported type chan bool at req.Done: channels have no JSON encoding",
+		},
+		{
+			name:     "struct map key",
+			code:     "type Key struct{ A int }\n\n// @contract:mutating\nfunc (c *Contract) Index(m map[Key]string) {}",
+			expected: "map keys must be strings, integers or implement encoding.TextMarshaler",
+		},
+		{
+			name:     "interface parameter",
+			code:     "type Shape interface{ Area() float64 }\n\n// @contract:mutating\nfunc (c *Contract) Draw(s []Shape) {}",
+			expected: "at s[]: interfaces with methods can't be decoded from JSON",
+		},
+		{
+			name:     "complex result",
+			code:     "// @contract:view\nfunc (c *Contract) Z() complex128 { return 0 }",
+			expected: "method 'Z' result has unsupported type complex128",
+		},
+		{
+			name:     "variadic",
+			code:     "// @contract:mutating\nfunc (c *Contract) Sum(xs ...int) {}",
+			expected: "method 'Sum' has a variadic parameter; take a slice instead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupTestProject(t, "package main\n\n// @contract:state\ntype Contract struct {}\n\n"+tt.code+"\n")
+			_, err := ScanContract(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) || !strings.HasPrefix(err.Error(), "main.go:") {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestScanContract_SupportedTypes(t *testing.T) {
+	// Types encoding themselves are not looked into, interfaces are fine in
+	// results, and unexported or skipped fields are never encoded.
+	dir := setupTestProject(t, `
+package main
+
+import "time"
+
+type Event struct {
+	At       time.Time
+	Callback func() `+"`json:\"-\"`"+`
+	done     chan bool
+}
+
+type Shape interface{ Area() float64 }
+
+// @contract:state
+type Contract struct {}
+
+// @contract:mutating
+func (c *Contract) Record(event Event, at map[int64]string) {}
+
+// @contract:view
+func (c *Contract) Shapes() ([]Shape, error) { return nil, nil }
+
+// @contract:mutating args=borsh
+func (c *Contract) Raw(data [4]byte) {}
+`)
+	if _, err := ScanContract(dir); err != nil {
+		t.Errorf("ScanContract failed: %v", err)
+	}
+}
+
+func TestTypeToString(t *testing.T) {
+	tests := map[string]string{
+		"collections.UnorderedMap[string, string]": "collections.UnorderedMap[string, string]",
+		"[32]byte":            "[32]byte",
+		"func(int) error":     "func(int) error",
+		"chan<- string":       "chan<- string",
+		"map[string][]*Item":  "map[string][]*Item",
+		"Pair[K, V]":          "Pair[K, V]",
+		"struct{ A int }":     "struct{A int}",
+		"interface{}":         "interface{}",
+		"[]map[int64]float64": "[]map[int64]float64",
+	}
+	for input, want := range tests {
+		expr, err := parser.ParseExpr(input)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q) failed: %v", input, err)
+		}
+		if got := typeToString(expr); got != want {
+			t.Errorf("typeToString(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
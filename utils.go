@@ -3,8 +3,11 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -42,25 +45,28 @@ func InitEmbeddedBins() {
 		}
 	}
 
-	tinyGoDir := filepath.Join(toolHome, "tinygo")
-	tinyGoBinDir := filepath.Join(tinyGoDir, "bin")
-	tinyGoMainBin := filepath.Join(tinyGoBinDir, "tinygo")
-
-	if _, err := os.Stat(tinyGoMainBin); err == nil {
+	if _, err := os.Stat(GetTinyGoPath()); err == nil {
 		return
 	}
 
 	fmt.Println("Extracting embedded TinyGo... (this happens once)")
-	if err := Unzip(bindata.TinyGoZip, toolHome); err != nil {
+	if err := extractTinyGo(toolHome); err != nil {
 		panic("failed to extract tinygo: " + err.Error())
 	}
+}
+
+// extractTinyGo unpacks the bundled TinyGo and records the bundle hash, so
+// reproducible builds can tell whether ~/.near-go/tinygo still matches this CLI.
+func extractTinyGo(toolHome string) error {
+	if err := Unzip(bindata.TinyGoZip, toolHome); err != nil {
+		return err
+	}
 
+	tinyGoBinDir := filepath.Join(toolHome, "tinygo", "bin")
 	entries, err := os.ReadDir(tinyGoBinDir)
 	if err != nil {
 		fmt.Printf("Warning: could not read tinygo bin directory to set permissions: %v\n", err)
-		return
 	}
-
 	for _, entry := range entries {
 		binPath := filepath.Join(tinyGoBinDir, entry.Name())
 
@@ -68,6 +74,36 @@ func InitEmbeddedBins() {
 			fmt.Printf("Warning: failed to chmod %s: %v\n", entry.Name(), err)
 		}
 	}
+
+	return os.WriteFile(tinyGoBundleMarker(), []byte(tinyGoBundleHash()), 0644)
+}
+
+func tinyGoBundleMarker() string {
+	return filepath.Join(getToolHome(), "tinygo", TinyGoBundleMarkerName)
+}
+
+// tinyGoBundleHash is the sha256 of the TinyGo archive embedded in this binary.
+func tinyGoBundleHash() string {
+	sum := sha256.Sum256(bindata.TinyGoZip)
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureBundledTinyGo re-extracts TinyGo when the installed copy was extracted
+// from another bundle, e.g. by an older near-go.
+func ensureBundledTinyGo() error {
+	marker, err := os.ReadFile(tinyGoBundleMarker())
+	if err == nil && strings.TrimSpace(string(marker)) == tinyGoBundleHash() {
+		return nil
+	}
+
+	fmt.Println("♻️  Installed TinyGo differs from the bundled one, extracting it again...")
+	if err := os.RemoveAll(filepath.Join(getToolHome(), "tinygo")); err != nil {
+		return fmt.Errorf("%s: %w", ErrTinyGoBundle, err)
+	}
+	if err := extractTinyGo(getToolHome()); err != nil {
+		return fmt.Errorf("%s: %w", ErrTinyGoBundle, err)
+	}
+	return nil
 }
 
 func CheckDependencies() {
@@ -163,6 +199,12 @@ func ExecuteCommand(name string, args ...string) ([]byte, error) {
 }
 
 func ExecuteWithRetry(name string, args []string, dir string, retries int, debug bool) error {
+	return executeWithRetryEnv(name, args, dir, nil, retries, debug, nil)
+}
+
+// executeWithRetryEnv is ExecuteWithRetry with extra 'KEY=value' environment variables.
+// mapOutput, when set, rewrites the output of a failed attempt before it is printed.
+func executeWithRetryEnv(name string, args []string, dir string, env []string, retries int, debug bool, mapOutput func(string) string) error {
 	var lastErr error
 	for i := range retries {
 		cmd := exec.Command(name, args...)
@@ -170,6 +212,9 @@ func ExecuteWithRetry(name string, args []string, dir string, retries int, debug
 		if dir != "" {
 			cmd.Dir = dir
 		}
+		if len(env) > 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
 
 		output, err := cmd.CombinedOutput()
 		if err == nil {
@@ -180,8 +225,29 @@ func ExecuteWithRetry(name string, args []string, dir string, retries int, debug
 		}
 		lastErr = err
 		if debug || i == retries-1 {
-			fmt.Printf("Attempt %d failed: %s\nOutput: %s\n", i+1, err, string(output))
+			text := string(output)
+			if mapOutput != nil {
+				text = mapOutput(text)
+			}
+			fmt.Printf("Attempt %d failed: %s\nOutput: %s\n", i+1, err, text)
 		}
 	}
 	return fmt.Errorf("%s: %v", ErrBuildFailed, lastErr)
 }
+
+const yoctoPerNear = "1000000000000000000000000"
+
+// formatYoctoNear renders a yoctoNEAR amount as NEAR with trailing zeros trimmed.
+func formatYoctoNear(yocto string) string {
+	amount, ok := new(big.Int).SetString(yocto, 10)
+	if !ok {
+		return yocto
+	}
+	base, _ := new(big.Int).SetString(yoctoPerNear, 10)
+	whole, frac := new(big.Int).QuoRem(amount, base, new(big.Int))
+	if frac.Sign() == 0 {
+		return whole.String()
+	}
+	fracStr := strings.TrimRight(fmt.Sprintf("%024s", frac.String()), "0")
+	return whole.String() + "." + fracStr
+}
@@ -41,3 +41,23 @@ func TestToSnakeCase(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatYoctoNear(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0", "0"},
+		{"1000000000000000000000000", "1"},
+		{"1500000000000000000000000", "1.5"},
+		{"1", "0.000000000000000000000001"},
+		{"not-a-number", "not-a-number"},
+	}
+
+	for _, tt := range tests {
+		result := formatYoctoNear(tt.input)
+		if result != tt.expected {
+			t.Errorf("formatYoctoNear(%q) = %q; want %q", tt.input, result, tt.expected)
+		}
+	}
+}
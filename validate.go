@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vlmoon99/near-cli-go/wasm"
+)
+
+// validateContractWasm lists everything that would make NEAR reject or break the
+// contract: a malformed module, code over the size limit, imports other than
+// 'env' host functions and missing exports. expectedExports may be nil.
+func validateContractWasm(code []byte, expectedExports []string) []string {
+	var problems []string
+	if len(code) > MaxContractSize {
+		problems = append(problems, fmt.Sprintf("contract is %d bytes, the protocol limit is %d bytes", len(code), MaxContractSize))
+	}
+
+	module, err := wasm.Parse(code)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+
+	for _, imp := range module.Imports {
+		if imp.Module != "env" {
+			problems = append(problems, fmt.Sprintf("imports '%s.%s', only 'env' host functions are available on NEAR", imp.Module, imp.Name))
+		} else if imp.Kind != wasm.KindFunction {
+			problems = append(problems, fmt.Sprintf("imports non-function 'env.%s'", imp.Name))
+		}
+	}
+
+	exported := make(map[string]bool)
+	for _, name := range module.FunctionExports() {
+		exported[name] = true
+	}
+	for _, name := range expectedExports {
+		if !exported[name] {
+			problems = append(problems, fmt.Sprintf("missing export '%s' declared in the contract sources", name))
+		}
+	}
+	return problems
+}
+
+// checkContractWasm prints the validation report and fails if there are problems.
+// When contract is nil the exports are not checked.
+func checkContractWasm(code []byte, contract *ContractInfo) error {
+	var expected []string
+	if contract != nil {
+		expected = contract.ExportNames()
+	}
+
+	problems := validateContractWasm(code, expected)
+	if len(problems) == 0 {
+		fmt.Printf("✅ WASM is valid (%d bytes, %d expected exports found)\n", len(code), len(expected))
+		return nil
+	}
+
+	fmt.Println("❌ WASM validation failed:")
+	for _, p := range problems {
+		fmt.Printf("   - %s\n", p)
+	}
+	return errors.New(ErrWasmValidationFailed + ": " + strings.Join(problems, "; "))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// testWasm assembles a module with one '() -> ()' function per export and
+// function imports given as "module.name".
+func testWasm(imports []string, exports ...string) []byte {
+	section := func(id byte, payload []byte) []byte {
+		return append(append([]byte{id}, uleb(len(payload))...), payload...)
+	}
+	name := func(s string) []byte {
+		return append(uleb(len(s)), s...)
+	}
+
+	out := []byte("\x00asm\x01\x00\x00\x00")
+	out = append(out, section(1, []byte{1, 0x60, 0, 0})...)
+
+	if len(imports) > 0 {
+		payload := uleb(len(imports))
+		for _, imp := range imports {
+			module, field, _ := strings.Cut(imp, ".")
+			payload = append(payload, name(module)...)
+			payload = append(payload, name(field)...)
+			payload = append(payload, 0, 0)
+		}
+		out = append(out, section(2, payload)...)
+	}
+
+	functions := uleb(len(exports))
+	exportPayload := uleb(len(exports))
+	code := uleb(len(exports))
+	for i, export := range exports {
+		functions = append(functions, 0)
+		exportPayload = append(exportPayload, name(export)...)
+		exportPayload = append(exportPayload, 0)
+		exportPayload = append(exportPayload, uleb(len(imports)+i)...)
+		code = append(code, 2, 0, 0x0b)
+	}
+	out = append(out, section(3, functions)...)
+	out = append(out, section(7, exportPayload)...)
+	out = append(out, section(10, code)...)
+	return out
+}
+
+func uleb(v int) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func TestValidateContractWasm(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     []byte
+		expected []string
+		problems []string
+	}{
+		{
+			name:     "valid",
+			code:     testWasm([]string{"env.input", "env.value_return"}, "get_count", "increment"),
+			expected: []string{"get_count", "increment"},
+		},
+		{
+			name:     "bad magic",
+			code:     []byte("\x7fELF\x01\x00\x00\x00"),
+			problems: []string{"magic header"},
+		},
+		{
+			name:     "disallowed import",
+			code:     testWasm([]string{"env.input", "wasi_snapshot_preview1.fd_write"}, "get_count"),
+			problems: []string{"imports 'wasi_snapshot_preview1.fd_write'"},
+		},
+		{
+			name:     "missing export",
+			code:     testWasm(nil, "get_count"),
+			expected: []string{"get_count", "increment"},
+			problems: []string{"missing export 'increment'"},
+		},
+		{
+			name:     "too large",
+			code:     append(append(testWasm(nil), append([]byte{0}, uleb(MaxContractSize+1)...)...), make([]byte, MaxContractSize+1)...),
+			problems: []string{"protocol limit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateContractWasm(tt.code, tt.expected)
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("Expected %d problems, got %v", len(tt.problems), problems)
+			}
+			for i, p := range tt.problems {
+				if !strings.Contains(problems[i], p) {
+					t.Errorf("Problem %q does not mention %q", problems[i], p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package wasm
+
+import (
+	"fmt"
+)
+
+// Meter instruments every function body with an instruction counter, the way the
+// NEAR runtime injects gas metering before executing a contract.
+//
+// Two mutable i64 globals are added and exported as counter and limit. Each
+// straight-line run of instructions first adds its length to the counter, then
+// traps with 'unreachable' once the counter is above the limit. The limit starts
+// at the maximum value, the host lowers it to stop runaway loops.
+func Meter(data []byte, counter, limit string) ([]byte, error) {
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	globals := uint32(0)
+	for _, imp := range m.Imports {
+		if imp.Kind == KindGlobal {
+			globals++
+		}
+	}
+	var globalEntries, exportEntries []byte
+	var globalCount, exportCount uint32
+	if s := m.Section(SectionGlobal); s != nil {
+		r := &reader{data: data[:s.Offset+s.Size], pos: s.Offset}
+		globalCount = r.u32()
+		globalEntries = data[r.pos : s.Offset+s.Size]
+	}
+	if s := m.Section(SectionExport); s != nil {
+		r := &reader{data: data[:s.Offset+s.Size], pos: s.Offset}
+		exportCount = r.u32()
+		exportEntries = data[r.pos : s.Offset+s.Size]
+	}
+	counterIndex := globals + globalCount
+	limitIndex := counterIndex + 1
+
+	// i64 mut, initialized to 0 and to -1 (the maximum as unsigned).
+	global := appendU32(nil, globalCount+2)
+	global = append(global, globalEntries...)
+	global = append(global, 0x7e, 0x01, 0x42, 0x00, 0x0b)
+	global = append(global, 0x7e, 0x01, 0x42, 0x7f, 0x0b)
+
+	export := appendU32(nil, exportCount+2)
+	export = append(export, exportEntries...)
+	export = appendName(export, counter)
+	export = appendU32(append(export, KindGlobal), counterIndex)
+	export = appendName(export, limit)
+	export = appendU32(append(export, KindGlobal), limitIndex)
+
+	var code []byte
+	if s := m.Section(SectionCode); s != nil {
+		if code, err = meterCode(data[:s.Offset+s.Size], s.Offset, counterIndex, limitIndex); err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrInvalidModule, err)
+		}
+	}
+
+	out := append(append([]byte(nil), Magic...), Version...)
+	pending := map[byte][]byte{SectionGlobal: global, SectionExport: export}
+	flush := func(before byte) {
+		for _, id := range []byte{SectionGlobal, SectionExport} {
+			if content, ok := pending[id]; ok && sectionOrder(id) < sectionOrder(before) {
+				out = appendSection(out, id, content)
+				delete(pending, id)
+			}
+		}
+	}
+	for _, s := range m.Sections {
+		if s.ID != SectionCustom {
+			flush(s.ID)
+		}
+		switch s.ID {
+		case SectionGlobal, SectionExport:
+			out = appendSection(out, s.ID, pending[s.ID])
+			delete(pending, s.ID)
+		case SectionCode:
+			out = appendSection(out, s.ID, code)
+		default:
+			out = append(out, data[s.Start:s.Offset+s.Size]...)
+		}
+	}
+	flush(0xff)
+	return out, nil
+}
+
+// sectionOrder is the position a section must appear at; the data count section
+// (12) sits between the element and code sections.
+func sectionOrder(id byte) int {
+	switch id {
+	case 12:
+		return int(SectionElement)*2 + 1
+	case 0xff:
+		return 0xff * 2
+	}
+	return int(id) * 2
+}
+
+func meterCode(data []byte, offset int, counter, limit uint32) ([]byte, error) {
+	r := &reader{data: data, pos: offset}
+	count := r.u32()
+	out := appendU32(nil, count)
+	for i := uint32(0); i < count; i++ {
+		size := int(r.u32())
+		if r.err != nil || size > len(data)-r.pos {
+			return nil, fmt.Errorf("function body %d is truncated", i)
+		}
+		body, err := meterBody(data[r.pos:r.pos+size], counter, limit)
+		if err != nil {
+			return nil, fmt.Errorf("function body %d: %w", i, err)
+		}
+		out = appendU32(out, uint32(len(body)))
+		out = append(out, body...)
+		r.pos += size
+	}
+	return out, r.err
+}
+
+// meterBody splits the body into runs ending at control instructions and
+// prefixes each run with its charge.
+func meterBody(body []byte, counter, limit uint32) ([]byte, error) {
+	r := &reader{data: body}
+	locals := r.u32()
+	for i := uint32(0); i < locals; i++ {
+		r.u32()
+		r.byte()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	out := append([]byte(nil), body[:r.pos]...)
+	start, ops := r.pos, uint64(0)
+	for r.pos < len(body) {
+		op, err := skipInstruction(r)
+		if err != nil {
+			return nil, err
+		}
+		ops++
+		if isControl(op) || r.pos == len(body) {
+			out = appendCharge(out, ops, counter, limit)
+			out = append(out, body[start:r.pos]...)
+			start, ops = r.pos, 0
+		}
+	}
+	return out, nil
+}
+
+func isControl(op byte) bool {
+	switch op {
+	case 0x00, 0x02, 0x03, 0x04, 0x05, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x12, 0x13:
+		return true
+	}
+	return false
+}
+
+// appendCharge adds ops to the counter and traps when it exceeds the limit.
+func appendCharge(out []byte, ops uint64, counter, limit uint32) []byte {
+	out = appendU32(append(out, 0x23), counter) // global.get
+	out = appendS64(append(out, 0x42), int64(ops))
+	out = append(out, 0x7c)                     // i64.add
+	out = appendU32(append(out, 0x24), counter) // global.set
+	out = appendU32(append(out, 0x23), counter)
+	out = appendU32(append(out, 0x23), limit)
+	return append(out, 0x56, 0x04, 0x40, 0x00, 0x0b) // i64.gt_u if unreachable end
+}
+
+// skipInstruction reads one instruction with its immediates and returns the opcode.
+func skipInstruction(r *reader) (byte, error) {
+	op := r.byte()
+	switch {
+	case op == 0x02 || op == 0x03 || op == 0x04: // block type
+		if b := r.peek(); b == 0x40 || (b >= 0x6f && b < 0x80) {
+			r.byte()
+		} else {
+			r.leb()
+		}
+	case op == 0x0c || op == 0x0d || op == 0x10 || op == 0x12 || op == 0xd2:
+		r.u32()
+	case op == 0x0e: // br_table
+		targets := r.u32()
+		for i := uint32(0); i <= targets && r.err == nil; i++ {
+			r.u32()
+		}
+	case op == 0x11 || op == 0x13: // call_indirect
+		r.u32()
+		r.u32()
+	case op == 0x1c: // select t*
+		types := r.u32()
+		for i := uint32(0); i < types && r.err == nil; i++ {
+			r.byte()
+		}
+	case op >= 0x20 && op <= 0x26: // locals, globals, table.get/set
+		r.u32()
+	case op >= 0x28 && op <= 0x3e: // memarg
+		r.u32()
+		r.u32()
+	case op == 0x3f || op == 0x40 || op == 0xd0:
+		r.byte()
+	case op == 0x41 || op == 0x42:
+		r.leb()
+	case op == 0x43:
+		r.skip(4)
+	case op == 0x44:
+		r.skip(8)
+	case op == 0xfc:
+		if err := skipPrefixed(r); err != nil {
+			return op, err
+		}
+	case op <= 0x01 || op == 0x05 || op == 0x0b || op == 0x0f || op == 0x1a || op == 0x1b ||
+		(op >= 0x45 && op <= 0xc4) || op == 0xd1:
+	default:
+		return op, fmt.Errorf("unsupported opcode 0x%02x at offset %d", op, r.pos-1)
+	}
+	return op, r.err
+}
+
+func skipPrefixed(r *reader) error {
+	switch sub := r.u32(); {
+	case sub <= 7: // saturating truncation
+	case sub == 8: // memory.init
+		r.u32()
+		r.byte()
+	case sub == 9 || sub == 13 || (sub >= 15 && sub <= 17):
+		r.u32()
+	case sub == 10:
+		r.byte()
+		r.byte()
+	case sub == 11:
+		r.byte()
+	case sub == 12 || sub == 14:
+		r.u32()
+		r.u32()
+	default:
+		return fmt.Errorf("unsupported opcode 0xfc %d at offset %d", sub, r.pos)
+	}
+	return nil
+}
+
+func (r *reader) peek() byte {
+	if r.pos >= len(r.data) {
+		return 0
+	}
+	return r.data[r.pos]
+}
+
+// leb skips a signed or unsigned LEB128 value of any width.
+func (r *reader) leb() {
+	for b := r.byte(); b&0x80 != 0 && r.err == nil; b = r.byte() {
+	}
+}
+
+func (r *reader) skip(n int) {
+	if r.err == nil && n > len(r.data)-r.pos {
+		r.err = errTruncated
+		return
+	}
+	r.pos += n
+}
+
+func appendU32(out []byte, v uint32) []byte {
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func appendS64(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func appendName(out []byte, name string) []byte {
+	return append(appendU32(out, uint32(len(name))), name...)
+}
+
+func appendSection(out []byte, id byte, content []byte) []byte {
+	out = appendU32(append(out, id), uint32(len(content)))
+	return append(out, content...)
+}
@@ -0,0 +1,90 @@
+package wasm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// header + type "() -> ()" + one function with the given body
+func moduleWithBody(body ...byte) []byte {
+	module := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+		0x03, 0x02, 0x01, 0x00,
+	}
+	code := append([]byte{0x01, byte(len(body))}, body...)
+	return append(append(module, SectionCode, byte(len(code))), code...)
+}
+
+func TestMeter(t *testing.T) {
+	metered, err := Meter(moduleWithBody(0x00, 0x01, 0x0b), "ops", "limit")
+	if err != nil {
+		t.Fatalf("Meter failed: %v", err)
+	}
+
+	m, err := Parse(metered)
+	if err != nil {
+		t.Fatalf("Parse of metered module failed: %v", err)
+	}
+	var ids []byte
+	for _, s := range m.Sections {
+		ids = append(ids, s.ID)
+	}
+	if !bytes.Equal(ids, []byte{SectionType, SectionFunction, SectionGlobal, SectionExport, SectionCode}) {
+		t.Errorf("Section order = %v", ids)
+	}
+	if len(m.Exports) != 2 || m.Exports[0] != (Export{"ops", KindGlobal, 0}) || m.Exports[1] != (Export{"limit", KindGlobal, 1}) {
+		t.Errorf("Exports = %+v", m.Exports)
+	}
+
+	// nop and end are one run of 2 instructions.
+	expected := []byte{
+		0x00,
+		0x23, 0x00, 0x42, 0x02, 0x7c, 0x24, 0x00,
+		0x23, 0x00, 0x23, 0x01, 0x56, 0x04, 0x40, 0x00, 0x0b,
+		0x01, 0x0b,
+	}
+	code := m.Section(SectionCode)
+	if body := metered[code.Offset+2 : code.Offset+code.Size]; !bytes.Equal(body, expected) {
+		t.Errorf("Metered body = %x; want %x", body, expected)
+	}
+}
+
+func TestMeter_ExistingGlobalsAndExports(t *testing.T) {
+	metered, err := Meter(testModule, "ops", "limit")
+	if err != nil {
+		t.Fatalf("Meter failed: %v", err)
+	}
+	m, err := Parse(metered)
+	if err != nil {
+		t.Fatalf("Parse of metered module failed: %v", err)
+	}
+	// The imported global takes index 0.
+	if len(m.Exports) != 4 || m.Exports[2] != (Export{"ops", KindGlobal, 1}) || m.Exports[3] != (Export{"limit", KindGlobal, 2}) {
+		t.Errorf("Exports = %+v", m.Exports)
+	}
+	if m.Sections[0].Name != "name" {
+		t.Errorf("Custom section was not kept in place: %+v", m.Sections)
+	}
+}
+
+func TestMeter_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"simd", moduleWithBody(0x00, 0xfd, 0x0c, 0x0b), "unsupported opcode 0xfd"},
+		{"truncated body", moduleWithBody(0x00, 0x41), "unexpected end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Meter(tt.data, "ops", "limit")
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package wasm
+
+import (
+	"fmt"
+)
+
+// stubBody is a function body without locals that traps when called.
+var stubBody = []byte{0x00, 0x00, 0x0b}
+
+// StripCustomSections drops every custom section ('name', 'producers',
+// 'target_features', DWARF...). They are ignored by the NEAR runtime but still
+// count towards the contract size.
+func StripCustomSections(data []byte) ([]byte, error) {
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	out := append(append([]byte(nil), Magic...), Version...)
+	for _, s := range m.Sections {
+		if s.ID != SectionCustom {
+			out = append(out, data[s.Start:s.Offset+s.Size]...)
+		}
+	}
+	return out, nil
+}
+
+// DeadCodeStats summarizes what EliminateDeadCode removed.
+type DeadCodeStats struct {
+	RemovedExports   []string
+	RemovedFunctions int
+}
+
+// EliminateDeadCode removes the function exports rejected by keep and replaces
+// the body of every function that is no longer reachable with a trap.
+//
+// Functions are reachable from the kept exports, the start function, element
+// segments and global initializers. Unreachable functions keep their index, so
+// calls, tables and the name section stay valid without renumbering.
+func EliminateDeadCode(data []byte, keep func(name string) bool) ([]byte, *DeadCodeStats, error) {
+	m, err := Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	stats := &DeadCodeStats{}
+
+	imported := uint32(0)
+	for _, imp := range m.Imports {
+		if imp.Kind == KindFunction {
+			imported++
+		}
+	}
+
+	var roots []uint32
+	export := []byte(nil)
+	kept := uint32(0)
+	for _, e := range m.Exports {
+		if e.Kind == KindFunction && !keep(e.Name) {
+			stats.RemovedExports = append(stats.RemovedExports, e.Name)
+			continue
+		}
+		if e.Kind == KindFunction {
+			roots = append(roots, e.Index)
+		}
+		export = appendName(export, e.Name)
+		export = appendU32(append(export, e.Kind), e.Index)
+		kept++
+	}
+	export = append(appendU32(nil, kept), export...)
+
+	for _, id := range []byte{SectionStart, SectionElement, SectionGlobal} {
+		s := m.Section(id)
+		if s == nil {
+			continue
+		}
+		refs, err := sectionRefs(data[:s.Offset+s.Size], s.Offset, id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: section %d: %w", ErrInvalidModule, id, err)
+		}
+		roots = append(roots, refs...)
+	}
+
+	var bodies [][]byte
+	if s := m.Section(SectionCode); s != nil {
+		if bodies, err = functionBodies(data[:s.Offset+s.Size], s.Offset); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", ErrInvalidModule, err)
+		}
+	}
+
+	reachable := make([]bool, int(imported)+len(bodies))
+	for len(roots) > 0 {
+		f := roots[len(roots)-1]
+		roots = roots[:len(roots)-1]
+		if int(f) >= len(reachable) || reachable[f] {
+			continue
+		}
+		reachable[f] = true
+		if f < imported {
+			continue
+		}
+		calls, err := bodyRefs(bodies[f-imported])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: function %d: %w", ErrInvalidModule, f, err)
+		}
+		roots = append(roots, calls...)
+	}
+
+	code := appendU32(nil, uint32(len(bodies)))
+	for i, body := range bodies {
+		if !reachable[int(imported)+i] && len(body) > len(stubBody) {
+			body = stubBody
+			stats.RemovedFunctions++
+		}
+		code = appendU32(code, uint32(len(body)))
+		code = append(code, body...)
+	}
+
+	out := append(append([]byte(nil), Magic...), Version...)
+	for _, s := range m.Sections {
+		switch s.ID {
+		case SectionExport:
+			out = appendSection(out, s.ID, export)
+		case SectionCode:
+			out = appendSection(out, s.ID, code)
+		default:
+			out = append(out, data[s.Start:s.Offset+s.Size]...)
+		}
+	}
+	return out, stats, nil
+}
+
+// functionBodies splits the code section into the raw function bodies.
+func functionBodies(data []byte, offset int) ([][]byte, error) {
+	r := &reader{data: data, pos: offset}
+	count := r.u32()
+	var bodies [][]byte
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		size := int(r.u32())
+		if r.err != nil || size > len(data)-r.pos {
+			return nil, fmt.Errorf("function body %d is truncated", i)
+		}
+		bodies = append(bodies, data[r.pos:r.pos+size])
+		r.pos += size
+	}
+	return bodies, r.err
+}
+
+// bodyRefs returns the functions a body calls directly or takes a reference to.
+func bodyRefs(body []byte) ([]uint32, error) {
+	r := &reader{data: body}
+	locals := r.u32()
+	for i := uint32(0); i < locals; i++ {
+		r.u32()
+		r.byte()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	refs, _, err := exprRefs(r, false)
+	return refs, err
+}
+
+// exprRefs reads instructions up to the end of the body, or of the constant
+// expression when untilEnd is set, collecting 'call' and 'ref.func' targets.
+func exprRefs(r *reader, untilEnd bool) ([]uint32, int, error) {
+	var refs []uint32
+	depth := 0
+	for r.pos < len(r.data) {
+		if op := r.peek(); op == 0x10 || op == 0xd2 {
+			r.byte()
+			refs = append(refs, r.u32())
+			continue
+		}
+		op, err := skipInstruction(r)
+		if err != nil {
+			return nil, r.pos, err
+		}
+		switch op {
+		case 0x02, 0x03, 0x04:
+			depth++
+		case 0x0b:
+			if depth == 0 && untilEnd {
+				return refs, r.pos, nil
+			}
+			depth--
+		}
+	}
+	if untilEnd {
+		return nil, r.pos, errTruncated
+	}
+	return refs, r.pos, r.err
+}
+
+// sectionRefs returns the functions referenced by the start, element or global section.
+func sectionRefs(data []byte, offset int, id byte) ([]uint32, error) {
+	r := &reader{data: data, pos: offset}
+	var refs []uint32
+	expr := func() {
+		if r.err != nil {
+			return
+		}
+		found, _, err := exprRefs(r, true)
+		if err != nil {
+			r.err = err
+		}
+		refs = append(refs, found...)
+	}
+
+	switch id {
+	case SectionStart:
+		refs = append(refs, r.u32())
+	case SectionGlobal:
+		count := r.u32()
+		for i := uint32(0); i < count && r.err == nil; i++ {
+			r.byte() // value type
+			r.byte() // mutability
+			expr()
+		}
+	case SectionElement:
+		count := r.u32()
+		for i := uint32(0); i < count && r.err == nil; i++ {
+			flags := r.u32()
+			if flags > 7 {
+				return nil, fmt.Errorf("unknown element segment kind %d", flags)
+			}
+			if flags&0x02 != 0 && flags&0x01 == 0 {
+				r.u32() // table index
+			}
+			if flags&0x01 == 0 {
+				expr() // offset
+			}
+			if flags&0x03 != 0 {
+				r.byte() // element kind or reference type
+			}
+			n := r.u32()
+			for j := uint32(0); j < n && r.err == nil; j++ {
+				if flags&0x04 != 0 {
+					expr()
+				} else {
+					refs = append(refs, r.u32())
+				}
+			}
+		}
+	}
+	return refs, r.err
+}
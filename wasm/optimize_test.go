@@ -0,0 +1,113 @@
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Six "() -> ()" functions: 'keep' (0) calls 1, 'drop' (2) calls 3, 4 sits in
+// the table and 5 is never referenced. Every function is named 'f<index>'.
+func callGraphModule() []byte {
+	body := func(code ...byte) []byte { return append([]byte{0x00}, append(code, 0x0b)...) }
+	bodies := [][]byte{body(0x10, 0x01), body(0x01, 0x01), body(0x10, 0x03), body(0x01, 0x01), body(0x01, 0x01), body(0x01, 0x01)}
+
+	out := append(append([]byte(nil), Magic...), Version...)
+	out = appendSection(out, SectionType, []byte{0x01, 0x60, 0x00, 0x00})
+	out = appendSection(out, SectionFunction, []byte{0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00})
+	out = appendSection(out, SectionTable, []byte{0x01, 0x70, 0x00, 0x01})
+	out = appendSection(out, SectionMemory, []byte{0x01, 0x00, 0x01})
+
+	export := append(appendName([]byte{0x03}, "memory"), KindMemory, 0x00)
+	export = append(appendName(export, "keep"), KindFunction, 0x00)
+	export = append(appendName(export, "drop"), KindFunction, 0x02)
+	out = appendSection(out, SectionExport, export)
+	out = appendSection(out, SectionElement, []byte{0x01, 0x00, 0x41, 0x00, 0x0b, 0x01, 0x04})
+
+	code := []byte{byte(len(bodies))}
+	for _, b := range bodies {
+		code = append(append(code, byte(len(b))), b...)
+	}
+	out = appendSection(out, SectionCode, code)
+
+	names := []byte{byte(len(bodies))}
+	for i := range bodies {
+		names = appendName(append(names, byte(i)), fmt.Sprintf("f%d", i))
+	}
+	name := appendName(nil, "name")
+	name = append(appendU32(append(name, 0x01), uint32(len(names))), names...)
+	return appendSection(out, SectionCustom, name)
+}
+
+func TestEliminateDeadCode(t *testing.T) {
+	module := callGraphModule()
+	optimized, stats, err := EliminateDeadCode(module, func(name string) bool { return name == "keep" })
+	if err != nil {
+		t.Fatalf("EliminateDeadCode failed: %v", err)
+	}
+	if len(stats.RemovedExports) != 1 || stats.RemovedExports[0] != "drop" || stats.RemovedFunctions != 3 {
+		t.Errorf("Stats = %+v", stats)
+	}
+
+	m, err := Parse(optimized)
+	if err != nil {
+		t.Fatalf("Parse of optimized module failed: %v", err)
+	}
+	if len(m.Exports) != 2 || m.Exports[0].Name != "memory" || m.Exports[1] != (Export{"keep", KindFunction, 0}) {
+		t.Errorf("Exports = %+v", m.Exports)
+	}
+
+	code := m.Section(SectionCode)
+	bodies, err := functionBodies(optimized[:code.Offset+code.Size], code.Offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, body := range bodies {
+		stubbed := i == 2 || i == 3 || i == 5
+		if bytes.Equal(body, stubBody) != stubbed {
+			t.Errorf("Function %d body = %x, stubbed = %v", i, body, stubbed)
+		}
+	}
+	if len(optimized) >= len(module) {
+		t.Errorf("Optimized size %d is not below %d", len(optimized), len(module))
+	}
+}
+
+func TestStripCustomSectionsAndSizes(t *testing.T) {
+	module := callGraphModule()
+	report, err := Sizes(module)
+	if err != nil {
+		t.Fatalf("Sizes failed: %v", err)
+	}
+	total := 8
+	for _, s := range report.Sections {
+		total += s.Size
+	}
+	if report.Total != len(module) || total != len(module) {
+		t.Errorf("Total = %d, sections add up to %d, want %d", report.Total, total, len(module))
+	}
+	if last := report.Sections[len(report.Sections)-1]; last.Name != "custom:name" {
+		t.Errorf("Last section = %+v", last)
+	}
+	if len(report.Functions) != 6 || report.Functions[0].Name != "f0" || report.Functions[0].Size != 4 {
+		t.Errorf("Functions = %+v", report.Functions)
+	}
+
+	stripped, err := StripCustomSections(module)
+	if err != nil {
+		t.Fatalf("StripCustomSections failed: %v", err)
+	}
+	report, err = Sizes(stripped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range report.Sections {
+		if strings.HasPrefix(s.Name, "custom:") {
+			t.Errorf("Custom section %s was kept", s.Name)
+		}
+	}
+	if report.Functions[0].Name != "func[0]" {
+		t.Errorf("Unnamed function = %+v", report.Functions[0])
+	}
+}
@@ -0,0 +1,123 @@
+package wasm
+
+import (
+	"fmt"
+	"sort"
+)
+
+var sectionNames = map[byte]string{
+	SectionType:     "type",
+	SectionImport:   "import",
+	SectionFunction: "function",
+	SectionTable:    "table",
+	SectionMemory:   "memory",
+	SectionGlobal:   "global",
+	SectionExport:   "export",
+	SectionStart:    "start",
+	SectionElement:  "element",
+	SectionCode:     "code",
+	SectionData:     "data",
+	12:              "datacount",
+}
+
+// SectionName returns 'code', 'data'... and 'custom:<name>' for custom sections.
+func (s Section) SectionName() string {
+	if s.ID == SectionCustom {
+		return "custom:" + s.Name
+	}
+	if name, ok := sectionNames[s.ID]; ok {
+		return name
+	}
+	return fmt.Sprintf("section %d", s.ID)
+}
+
+type SectionSize struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+type FunctionSize struct {
+	Index uint32 `json:"index"`
+	Name  string `json:"name"`
+	Size  int    `json:"size"`
+}
+
+// SizeReport breaks the module size down by section and by function body.
+type SizeReport struct {
+	Total    int           `json:"total"`
+	Sections []SectionSize `json:"sections"`
+	// Functions is sorted by decreasing size. Names come from the 'name'
+	// section, functions without one are called 'func[<index>]'.
+	Functions []FunctionSize `json:"functions"`
+}
+
+// Sizes measures every section, headers included, and every function body.
+func Sizes(data []byte) (*SizeReport, error) {
+	m, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	report := &SizeReport{Total: len(data)}
+	for _, s := range m.Sections {
+		report.Sections = append(report.Sections, SectionSize{Name: s.SectionName(), Size: s.Offset + s.Size - s.Start})
+	}
+
+	imported := uint32(0)
+	for _, imp := range m.Imports {
+		if imp.Kind == KindFunction {
+			imported++
+		}
+	}
+	names := map[uint32]string{}
+	for _, s := range m.Sections {
+		if s.ID == SectionCustom && s.Name == "name" {
+			names = functionNames(data[:s.Offset+s.Size], s.Offset)
+		}
+	}
+
+	if s := m.Section(SectionCode); s != nil {
+		bodies, err := functionBodies(data[:s.Offset+s.Size], s.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrInvalidModule, err)
+		}
+		for i, body := range bodies {
+			index := imported + uint32(i)
+			name, ok := names[index]
+			if !ok {
+				name = fmt.Sprintf("func[%d]", index)
+			}
+			report.Functions = append(report.Functions, FunctionSize{Index: index, Name: name, Size: len(body)})
+		}
+	}
+	sort.SliceStable(report.Functions, func(i, j int) bool { return report.Functions[i].Size > report.Functions[j].Size })
+	return report, nil
+}
+
+// functionNames reads the function names subsection of the 'name' section. A
+// malformed section yields the names decoded so far.
+func functionNames(data []byte, offset int) map[uint32]string {
+	names := map[uint32]string{}
+	r := &reader{data: data, pos: offset}
+	r.name()
+	for r.pos < len(data) && r.err == nil {
+		id := r.byte()
+		size := int(r.u32())
+		if r.err != nil || size > len(data)-r.pos {
+			break
+		}
+		if id != 1 {
+			r.pos += size
+			continue
+		}
+		sub := &reader{data: data[:r.pos+size], pos: r.pos}
+		count := sub.u32()
+		for i := uint32(0); i < count && sub.err == nil; i++ {
+			index, name := sub.u32(), sub.name()
+			if sub.err == nil {
+				names[index] = name
+			}
+		}
+		break
+	}
+	return names
+}
@@ -0,0 +1,228 @@
+// Package wasm reads the structure of WebAssembly binaries: the section layout,
+// imports and exports. Function bodies are only decoded by Meter, by
+// EliminateDeadCode to follow calls and by Sizes to measure them.
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	ErrInvalidModule = "(WASM_ERROR): invalid WebAssembly module"
+)
+
+var (
+	Magic   = []byte{0x00, 0x61, 0x73, 0x6d}
+	Version = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// Section IDs from the WebAssembly core specification.
+const (
+	SectionCustom   byte = 0
+	SectionType     byte = 1
+	SectionImport   byte = 2
+	SectionFunction byte = 3
+	SectionTable    byte = 4
+	SectionMemory   byte = 5
+	SectionGlobal   byte = 6
+	SectionExport   byte = 7
+	SectionStart    byte = 8
+	SectionElement  byte = 9
+	SectionCode     byte = 10
+	SectionData     byte = 11
+)
+
+// External kinds of imports and exports.
+const (
+	KindFunction byte = 0
+	KindTable    byte = 1
+	KindMemory   byte = 2
+	KindGlobal   byte = 3
+)
+
+type Section struct {
+	ID byte
+	// Name is only set for custom sections.
+	Name string
+	// Offset and Size locate the section payload, excluding the ID and size header.
+	Offset int
+	Size   int
+	// Start is the offset of the section ID byte.
+	Start int
+}
+
+type Import struct {
+	Module string
+	Name   string
+	Kind   byte
+}
+
+type Export struct {
+	Name  string
+	Kind  byte
+	Index uint32
+}
+
+type Module struct {
+	Size     int
+	Sections []Section
+	Imports  []Import
+	Exports  []Export
+}
+
+// Parse reads the section layout, imports and exports of a WebAssembly binary.
+func Parse(data []byte) (*Module, error) {
+	if len(data) < 8 || !bytes.Equal(data[:4], Magic) {
+		return nil, fmt.Errorf("%s: missing '\\0asm' magic header", ErrInvalidModule)
+	}
+	if !bytes.Equal(data[4:8], Version) {
+		return nil, fmt.Errorf("%s: unsupported version %x", ErrInvalidModule, data[4:8])
+	}
+
+	m := &Module{Size: len(data)}
+	r := &reader{data: data, pos: 8}
+	for r.pos < len(data) {
+		start := r.pos
+		id := r.byte()
+		size := int(r.u32())
+		if r.err != nil || size > len(data)-r.pos {
+			return nil, fmt.Errorf("%s: truncated section at offset %d", ErrInvalidModule, start)
+		}
+
+		section := Section{ID: id, Offset: r.pos, Size: size, Start: start}
+		payload := &reader{data: data[:r.pos+size], pos: r.pos}
+		var err error
+		switch id {
+		case SectionCustom:
+			section.Name = payload.name()
+			err = payload.err
+		case SectionImport:
+			m.Imports, err = readImports(payload)
+		case SectionExport:
+			m.Exports, err = readExports(payload)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: section %d at offset %d: %w", ErrInvalidModule, id, start, err)
+		}
+
+		m.Sections = append(m.Sections, section)
+		r.pos += size
+	}
+	return m, nil
+}
+
+// FunctionExports returns the names of exported functions in declaration order.
+func (m *Module) FunctionExports() []string {
+	var names []string
+	for _, e := range m.Exports {
+		if e.Kind == KindFunction {
+			names = append(names, e.Name)
+		}
+	}
+	return names
+}
+
+func (m *Module) Section(id byte) *Section {
+	for i := range m.Sections {
+		if m.Sections[i].ID == id {
+			return &m.Sections[i]
+		}
+	}
+	return nil
+}
+
+func readImports(r *reader) ([]Import, error) {
+	count := r.u32()
+	var imports []Import
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		imp := Import{Module: r.name(), Name: r.name(), Kind: r.byte()}
+		switch imp.Kind {
+		case KindFunction:
+			r.u32()
+		case KindTable:
+			r.byte()
+			r.limits()
+		case KindMemory:
+			r.limits()
+		case KindGlobal:
+			r.byte()
+			r.byte()
+		default:
+			return nil, fmt.Errorf("unknown import kind %d", imp.Kind)
+		}
+		imports = append(imports, imp)
+	}
+	return imports, r.err
+}
+
+func readExports(r *reader) ([]Export, error) {
+	count := r.u32()
+	var exports []Export
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		exports = append(exports, Export{Name: r.name(), Kind: r.byte(), Index: r.u32()})
+	}
+	return exports, r.err
+}
+
+var errTruncated = errors.New("unexpected end of data")
+
+// reader decodes the LEB128 based primitives of the binary format. The first
+// error sticks and makes every later read return zero values.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.err = errTruncated
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *reader) u32() uint32 {
+	var result uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b := r.byte()
+		if r.err != nil {
+			return 0
+		}
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result
+		}
+	}
+	r.err = errors.New("LEB128 value too long")
+	return 0
+}
+
+func (r *reader) name() string {
+	n := int(r.u32())
+	if r.err != nil {
+		return ""
+	}
+	if n > len(r.data)-r.pos {
+		r.err = errTruncated
+		return ""
+	}
+	s := string(r.data[r.pos : r.pos+n])
+	r.pos += n
+	return s
+}
+
+func (r *reader) limits() {
+	flags := r.byte()
+	r.u32()
+	if flags&1 != 0 {
+		r.u32()
+	}
+}
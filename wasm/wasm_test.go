@@ -0,0 +1,77 @@
+package wasm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// header + custom "name" section + imports of every kind + exports
+var testModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	// custom section "name" with one payload byte
+	0x00, 0x06, 0x04, 'n', 'a', 'm', 'e', 0xff,
+	// import section
+	0x02, 0x2c, 0x04,
+	0x03, 'e', 'n', 'v', 0x05, 'i', 'n', 'p', 'u', 't', 0x00, 0x00,
+	0x03, 'e', 'n', 'v', 0x03, 'm', 'e', 'm', 0x02, 0x01, 0x01, 0x02,
+	0x03, 'e', 'n', 'v', 0x03, 't', 'b', 'l', 0x01, 0x70, 0x00, 0x01,
+	0x01, 'x', 0x01, 'g', 0x03, 0x7f, 0x00,
+	// export section: function "run" (index 1) and memory "memory"
+	0x07, 0x10, 0x02,
+	0x03, 'r', 'u', 'n', 0x00, 0x01,
+	0x06, 'm', 'e', 'm', 'o', 'r', 'y', 0x02, 0x00,
+}
+
+func TestParse(t *testing.T) {
+	m, err := Parse(testModule)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expectedImports := []Import{
+		{Module: "env", Name: "input", Kind: KindFunction},
+		{Module: "env", Name: "mem", Kind: KindMemory},
+		{Module: "env", Name: "tbl", Kind: KindTable},
+		{Module: "x", Name: "g", Kind: KindGlobal},
+	}
+	if !reflect.DeepEqual(m.Imports, expectedImports) {
+		t.Errorf("Imports = %+v; want %+v", m.Imports, expectedImports)
+	}
+	if !reflect.DeepEqual(m.FunctionExports(), []string{"run"}) {
+		t.Errorf("FunctionExports = %v", m.FunctionExports())
+	}
+	if len(m.Sections) != 3 || m.Sections[0].Name != "name" || m.Sections[0].Start != 8 || m.Sections[0].Offset != 10 || m.Sections[0].Size != 6 {
+		t.Errorf("Unexpected sections: %+v", m.Sections)
+	}
+	if s := m.Section(SectionExport); s == nil || s.Offset+s.Size != len(testModule) {
+		t.Errorf("Unexpected export section: %+v", s)
+	}
+	if m.Section(SectionCode) != nil {
+		t.Errorf("Module has no code section")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"empty", nil, "magic"},
+		{"bad magic", []byte("\x7fELF\x01\x00\x00\x00"), "magic"},
+		{"bad version", []byte("\x00asm\x02\x00\x00\x00"), "version"},
+		{"truncated section", append(append([]byte{}, testModule[:8]...), 0x01, 0x10, 0x00), "truncated"},
+		{"truncated import", append(append([]byte{}, testModule[:8]...), 0x02, 0x03, 0x01, 0x03, 'e'), "unexpected end"},
+		{"unknown import kind", append(append([]byte{}, testModule[:8]...), 0x02, 0x06, 0x01, 0x01, 'a', 0x01, 'b', 0x09), "unknown import kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watcher reruns a build or test whenever a Go file, go.mod or go.sum of the
+// module under Dir changes. It polls, so it works the same on every platform
+// and file system.
+type watcher struct {
+	Dir string
+	// Tests also watches '_test.go' files.
+	Tests    bool
+	Interval time.Duration
+	Debounce time.Duration
+}
+
+func newWatcher(dir string, tests bool) (*watcher, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &watcher{Dir: findModuleDir(absDir), Tests: tests, Interval: WatchPollInterval, Debounce: WatchDebounce}, nil
+}
+
+// watchSnapshot maps each watched file, relative to the watched folder, to its
+// size and modification time.
+type watchSnapshot map[string]string
+
+// snapshot skips the folders the contract scanner skips (hidden, vendor,
+// node_modules, testdata) and the generated files, so builds never trigger
+// themselves.
+func (w *watcher) snapshot() watchSnapshot {
+	files := watchSnapshot{}
+	filepath.WalkDir(w.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files removed while walking show up as removed on the next poll.
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != w.Dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name != "go.mod" && name != "go.sum" {
+			if !strings.HasSuffix(name, ".go") || strings.HasPrefix(name, "generated_") ||
+				(!w.Tests && strings.HasSuffix(name, "_test.go")) {
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(w.Dir, path)
+		if err != nil {
+			return nil
+		}
+		files[filepath.ToSlash(rel)] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return files
+}
+
+// changes returns the files added, modified or removed in next, sorted.
+func (s watchSnapshot) changes(next watchSnapshot) []string {
+	var changed []string
+	for path, stamp := range next {
+		if s[path] != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range s {
+		if _, ok := next[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Run calls run once, then again after every change until stop is closed.
+// Failures are reported and watching goes on.
+func (w *watcher) Run(what string, run func() error, stop <-chan struct{}) {
+	runOnce := func() {
+		start := time.Now()
+		if err := run(); err != nil {
+			fmt.Printf("❌ %s failed after %.1fs: %v\n", what, time.Since(start).Seconds(), err)
+		} else {
+			fmt.Printf("✅ %s succeeded in %.1fs\n", what, time.Since(start).Seconds())
+		}
+		fmt.Printf("👀 Watching %s for changes (Ctrl+C to stop)...\n", w.Dir)
+	}
+
+	previous := w.snapshot()
+	runOnce()
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		current := w.snapshot()
+		if len(previous.changes(current)) == 0 {
+			continue
+		}
+		// Editors and formatters often write a file several times in a row.
+		for settled := false; !settled; {
+			select {
+			case <-stop:
+				return
+			case <-time.After(w.Debounce):
+			}
+			next := w.snapshot()
+			settled = len(current.changes(next)) == 0
+			current = next
+		}
+
+		changed := previous.changes(current)
+		previous = current
+		if len(changed) == 0 {
+			continue
+		}
+		shown := strings.Join(changed, ", ")
+		if len(changed) > 3 {
+			shown = fmt.Sprintf("%s and %d more", strings.Join(changed[:3], ", "), len(changed)-3)
+		}
+		fmt.Printf("\n🔄 Changed: %s\n", shown)
+		runOnce()
+	}
+}
+
+// watchUntilInterrupted runs w until Ctrl+C. The first Ctrl+C lets a running
+// build finish and clean up its generated file, a second one exits at once.
+func watchUntilInterrupted(w *watcher, what string, run func() error) error {
+	stop := make(chan struct{})
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		signal.Stop(interrupts)
+		fmt.Println("\n🛑 Stopping watch mode...")
+		close(stop)
+	}()
+	w.Run(what, run, stop)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeWatchedFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWatcherSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeWatchedFiles(t, dir, map[string]string{
+		"go.mod":                 "module example.com/watch\n",
+		"main.go":                "package main\n",
+		"main_test.go":           "package main\n",
+		"generated_build.go":     "package main\n",
+		"main.wasm":              "\x00asm",
+		"internal/shared/a.go":   "package shared\n",
+		".git/hooks/x.go":        "package hooks\n",
+		"vendor/lib/lib.go":      "package lib\n",
+		"testdata/fixture.go":    "package fixture\n",
+		"node_modules/pkg/p.go":  "package p\n",
+		"contract/nested/b.go":   "package nested\n",
+		"contract/nested/README": "docs\n",
+	})
+
+	build := &watcher{Dir: dir}
+	before := build.snapshot()
+	got := strings.Join(before.changes(watchSnapshot{}), ",")
+	if got != "contract/nested/b.go,go.mod,internal/shared/a.go,main.go" {
+		t.Errorf("Watched files = %s", got)
+	}
+	if _, ok := (&watcher{Dir: dir, Tests: true}).snapshot()["main_test.go"]; !ok {
+		t.Error("Test watch mode must watch _test.go files")
+	}
+
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(filepath.Join(dir, "main.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	writeWatchedFiles(t, dir, map[string]string{"internal/shared/c.go": "package shared\n", "main.wasm": "changed"})
+	if err := os.Remove(filepath.Join(dir, "contract", "nested", "b.go")); err != nil {
+		t.Fatal(err)
+	}
+	changed := before.changes(build.snapshot())
+	if want := []string{"contract/nested/b.go", "internal/shared/c.go", "main.go"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changes() = %v, want %v", changed, want)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+	writeWatchedFiles(t, dir, map[string]string{"go.mod": "module example.com/watch\n", "main.go": "package main\n"})
+	w := &watcher{Dir: dir, Interval: 10 * time.Millisecond, Debounce: 30 * time.Millisecond}
+
+	runs := make(chan int, 10)
+	count := 0
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.Run("Build", func() error {
+			count++
+			runs <- count
+			return nil
+		}, stop)
+		close(done)
+	}()
+	waitRun := func(want int) {
+		t.Helper()
+		select {
+		case got := <-runs:
+			if got != want {
+				t.Fatalf("Run %d, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Run %d did not happen", want)
+		}
+	}
+
+	waitRun(1)
+	// A burst of writes is debounced into one run.
+	for i := 0; i < 3; i++ {
+		writeWatchedFiles(t, dir, map[string]string{"main.go": "package main\n" + strings.Repeat("//\n", i+1)})
+		time.Sleep(5 * time.Millisecond)
+	}
+	waitRun(2)
+
+	writeWatchedFiles(t, dir, map[string]string{GeneratedBuildFileName: "package main\n", "main.wasm": "\x00asm"})
+	select {
+	case got := <-runs:
+		t.Errorf("Run %d triggered by build outputs", got)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after stop was closed")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// workspaceBuild is the outcome of one contract of 'build --all'.
+type workspaceBuild struct {
+	Name     string
+	Dir      string
+	Output   string
+	Size     int64
+	Duration time.Duration
+	Err      error
+}
+
+// discoverContracts returns the contract folders of the workspace rooted at
+// root: the folders matched by patterns, or every folder with a
+// @contract:state struct when there are none. outDir is never searched.
+func discoverContracts(root string, patterns []string, outDir string) ([]string, error) {
+	var dirs []string
+	if len(patterns) > 0 {
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(filepath.Join(root, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("%s: '%s': %w", ErrWorkspaceContracts, pattern, err)
+			}
+			found := false
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					dirs = append(dirs, match)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s: '%s' matches no folder", ErrWorkspaceContracts, pattern)
+			}
+		}
+	} else {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" ||
+				name == "testdata" || path == outDir) {
+				return filepath.SkipDir
+			}
+			if hasContractState(path) {
+				dirs = append(dirs, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ErrWorkspaceContracts, err)
+		}
+	}
+
+	sort.Strings(dirs)
+	unique := dirs[:0]
+	for i, dir := range dirs {
+		if i == 0 || dir != dirs[i-1] {
+			unique = append(unique, dir)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("%s: no folder with a @contract:state struct under %s", ErrWorkspaceContracts, root)
+	}
+	return unique, nil
+}
+
+// hasContractState reports whether a Go file directly in dir declares the contract state.
+func hasContractState(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") ||
+			strings.HasPrefix(name, "generated_") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil && strings.Contains(string(data), "@contract:state") {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleBuildAll builds every contract of the workspace into '<out>/<name>.wasm',
+// at most jobs at a time, and prints a summary. It fails when any build fails.
+func HandleBuildAll(config *ProjectConfig, opts BuildOptions, jobs int) error {
+	root, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	outDir := config.Workspace.Out
+	if outDir == "" {
+		outDir = WorkspaceOutDir
+	}
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(root, outDir)
+	}
+	if jobs <= 0 {
+		if jobs = config.Workspace.Jobs; jobs <= 0 {
+			jobs = runtime.NumCPU()
+		}
+	}
+
+	dirs, err := discoverContracts(root, config.Workspace.Contracts, outDir)
+	if err != nil {
+		return err
+	}
+	builds := make([]*workspaceBuild, len(dirs))
+	names := map[string]string{}
+	for i, dir := range dirs {
+		name := filepath.Base(dir)
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%s: '%s' and '%s' would both build %s.wasm", ErrWorkspaceContracts, other, dir, name)
+		}
+		names[name] = dir
+		builds[i] = &workspaceBuild{Name: name, Dir: dir, Output: filepath.Join(outDir, name+".wasm")}
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	if opts.Reproducible {
+		// Once up front, not concurrently from every build.
+		if err := ensureBundledTinyGo(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("🏗️  Building %d contract(s) with %d job(s) into %s\n", len(builds), jobs, outDir)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, jobs)
+	for _, build := range builds {
+		wg.Add(1)
+		go func(build *workspaceBuild) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			start := time.Now()
+			build.Err = HandleBuild(build.Dir, build.Output, opts)
+			build.Duration = time.Since(start)
+			if info, err := os.Stat(build.Output); err == nil && build.Err == nil {
+				build.Size = info.Size()
+			}
+		}(build)
+	}
+	wg.Wait()
+
+	return printWorkspaceSummary(root, builds)
+}
+
+func printWorkspaceSummary(root string, builds []*workspaceBuild) error {
+	failed := 0
+	for _, build := range builds {
+		if build.Err != nil {
+			failed++
+		}
+	}
+	fmt.Printf("\n📋 Workspace build: %d contract(s), %d succeeded, %d failed\n", len(builds), len(builds)-failed, failed)
+	for _, build := range builds {
+		dir, err := filepath.Rel(root, build.Dir)
+		if err != nil {
+			dir = build.Dir
+		}
+		if build.Err != nil {
+			fmt.Printf("   ❌ %-20s %-24s %v\n", build.Name, dir, build.Err)
+			continue
+		}
+		output, err := filepath.Rel(root, build.Output)
+		if err != nil {
+			output = build.Output
+		}
+		fmt.Printf("   ✅ %-20s %-24s %10s %6.1fs  %s\n", build.Name, dir, formatBytes(int(build.Size)),
+			build.Duration.Seconds(), output)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d of %d contract(s)", ErrWorkspaceBuildFailed, failed, len(builds))
+	}
+	return nil
+}
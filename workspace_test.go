@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newWorkspace creates a module with the given contract folders plus a shared
+// package, a hidden folder and an old build in out/ that must all be ignored.
+func newWorkspace(t *testing.T, contracts ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range append(contracts, ".hidden", "out/stale") {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeTemplate(ProjectTemplates[SmartContractTypeProject], filepath.Join(root, dir)); err != nil {
+			t.Fatalf("writeTemplate() failed: %v", err)
+		}
+	}
+	files := map[string]string{
+		"go.mod":                   "module example.com/workspace\n",
+		"internal/shared/share.go": "package shared\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDiscoverContracts(t *testing.T) {
+	root := newWorkspace(t, "contracts/token", "contracts/staking", "governance")
+	rel := func(dirs []string) string {
+		var names []string
+		for _, dir := range dirs {
+			name, _ := filepath.Rel(root, dir)
+			names = append(names, filepath.ToSlash(name))
+		}
+		return strings.Join(names, ",")
+	}
+
+	dirs, err := discoverContracts(root, nil, filepath.Join(root, "out"))
+	if err != nil {
+		t.Fatalf("discoverContracts() failed: %v", err)
+	}
+	if got := rel(dirs); got != "contracts/staking,contracts/token,governance" {
+		t.Errorf("Discovered %s", got)
+	}
+
+	dirs, err = discoverContracts(root, []string{"contracts/*", "contracts/token"}, filepath.Join(root, "out"))
+	if err != nil {
+		t.Fatalf("discoverContracts(patterns) failed: %v", err)
+	}
+	if got := rel(dirs); got != "contracts/staking,contracts/token" {
+		t.Errorf("Listed %s", got)
+	}
+
+	if _, err := discoverContracts(root, []string{"missing/*"}, ""); err == nil || !strings.Contains(err.Error(), "matches no folder") {
+		t.Errorf("discoverContracts(missing) error = %v", err)
+	}
+	if _, err := discoverContracts(filepath.Join(root, "internal"), nil, ""); err == nil || !strings.Contains(err.Error(), ErrWorkspaceContracts) {
+		t.Errorf("discoverContracts(no contracts) error = %v", err)
+	}
+}
+
+func TestHandleBuildAll(t *testing.T) {
+	// No TinyGo in the temporary home: every build fails, and the summary says so.
+	// The Go build cache stays where it is, for the type check's 'go list'.
+	if os.Getenv("GOCACHE") == "" {
+		if cache, err := os.UserCacheDir(); err == nil {
+			t.Setenv("GOCACHE", filepath.Join(cache, "go-build"))
+		}
+	}
+	t.Setenv("HOME", t.TempDir())
+	root := newWorkspace(t, "contracts/token", "contracts/staking")
+	config := &ProjectConfig{
+		Path:      filepath.Join(root, ProjectConfigFileName),
+		Workspace: WorkspaceConfig{Contracts: []string{"contracts/*"}, Jobs: 2},
+	}
+
+	err := HandleBuildAll(config, BuildOptions{NoCache: true}, 0)
+	if err == nil || !strings.Contains(err.Error(), ErrWorkspaceBuildFailed) || !strings.Contains(err.Error(), "2 of 2") {
+		t.Errorf("HandleBuildAll() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, WorkspaceOutDir)); err != nil {
+		t.Errorf("Output folder was not created: %v", err)
+	}
+	for _, dir := range []string{"contracts/token", "contracts/staking"} {
+		if _, err := os.Stat(filepath.Join(root, dir, GeneratedBuildFileName)); !os.IsNotExist(err) {
+			t.Errorf("%s: generated file left behind", dir)
+		}
+	}
+
+	if err := os.Rename(filepath.Join(root, "out", "stale"), filepath.Join(root, "out", "token")); err != nil {
+		t.Fatal(err)
+	}
+	config.Workspace.Contracts = []string{"contracts/token", "out/token"}
+	if err := HandleBuildAll(config, BuildOptions{}, 1); err == nil || !strings.Contains(err.Error(), "would both build token.wasm") {
+		t.Errorf("HandleBuildAll(duplicate names) error = %v", err)
+	}
+}